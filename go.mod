@@ -2,7 +2,10 @@ module github.com/birdmichael/RenderAPI
 
 go 1.22.12
 
-require github.com/dop251/goja v0.0.0-20231027120936-b396bb4c349d
+require (
+	github.com/dop251/goja v0.0.0-20231027120936-b396bb4c349d
+	github.com/gorilla/websocket v1.5.3
+)
 
 require (
 	github.com/dlclark/regexp2 v1.7.0 // indirect
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// JSONArrayToCSV 将JSON对象数组转换为CSV
+// 表头为所有对象键的并集（按键名排序），每个对象输出一行，缺少的键输出为空，没有任何键时输出为空；
+// null输出为空，嵌套的对象或数组输出为紧凑的JSON。顶层不是对象数组时返回错误
+func JSONArrayToCSV(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("只有顶层为JSON数组的响应才能转换为CSV，实际为: %s", jsonKind(value))
+	}
+
+	rows := make([]map[string]interface{}, 0, len(items))
+	seen := make(map[string]bool)
+	var header []string
+	for i, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("数组第%d个元素不是对象，实际为: %s", i, jsonKind(item))
+		}
+		for key := range row {
+			if !seen[key] {
+				seen[key] = true
+				header = append(header, key)
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Strings(header)
+	if len(header) == 0 {
+		return []byte{}, nil
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := make([]string, len(header))
+		for i, key := range header {
+			cell, err := csvCell(row[key])
+			if err != nil {
+				return nil, fmt.Errorf("转换字段%s失败: %w", key, err)
+			}
+			record[i] = cell
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvCell 将单个JSON值转换为CSV单元格内容
+func csvCell(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	case bool:
+		return fmt.Sprint(val), nil
+	default:
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
+
+// jsonKind 返回JSON值的类型名，用于错误信息
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "对象"
+	case []interface{}:
+		return "数组"
+	case string:
+		return "字符串"
+	case json.Number:
+		return "数字"
+	case bool:
+		return "布尔值"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
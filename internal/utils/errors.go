@@ -0,0 +1,22 @@
+package utils
+
+// sentinelError 保留原有的错误信息，同时可以通过errors.Is匹配哨兵错误和原始错误
+type sentinelError struct {
+	sentinel error
+	err      error
+}
+
+// Error 返回原有的错误信息
+func (e *sentinelError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 同时返回哨兵错误和原始错误
+func (e *sentinelError) Unwrap() []error {
+	return []error{e.sentinel, e.err}
+}
+
+// WrapSentinel 为err附加哨兵错误类别
+func WrapSentinel(sentinel, err error) error {
+	return &sentinelError{sentinel: sentinel, err: err}
+}
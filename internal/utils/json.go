@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PrettyJSONOrdered 格式化JSON并保留对象键的原始顺序
+// 与PrettyJSON不同，内容按json.Decoder的词法单元流式输出而不经过Map，
+// 因此键顺序与数字的原始写法都保持不变，HTML字符不转义；indent为空时输出紧凑格式
+func PrettyJSONOrdered(data []byte, indent string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var buf bytes.Buffer
+	if err := writeOrderedValue(decoder, &buf, indent, 0); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("JSON值之后存在多余的内容")
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeOrderedValue 从decoder读取一个完整的值并按原始顺序写入buf
+func writeOrderedValue(decoder *json.Decoder, buf *bytes.Buffer, indent string, depth int) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return writeJSONScalar(buf, token)
+	}
+
+	buf.WriteByte(byte(delim))
+	count := 0
+	for decoder.More() {
+		if count > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONIndent(buf, indent, depth+1)
+
+		if delim == '{' {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			if err := writeJSONScalar(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if indent != "" {
+				buf.WriteByte(' ')
+			}
+		}
+
+		if err := writeOrderedValue(decoder, buf, indent, depth+1); err != nil {
+			return err
+		}
+		count++
+	}
+
+	// 读取结束符
+	end, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		writeJSONIndent(buf, indent, depth)
+	}
+	buf.WriteByte(byte(end.(json.Delim)))
+	return nil
+}
+
+// writeJSONScalar 写入字符串、数字、布尔值或null
+func writeJSONScalar(buf *bytes.Buffer, token json.Token) error {
+	switch val := token.(type) {
+	case json.Number:
+		buf.WriteString(val.String())
+	case nil:
+		buf.WriteString("null")
+	default:
+		encoded, err := MarshalJSONNoEscape(val, "")
+		if err != nil {
+			return err
+		}
+		buf.Write(encoded)
+	}
+	return nil
+}
+
+// writeJSONIndent 换行并按层级缩进，indent为空时不输出
+func writeJSONIndent(buf *bytes.Buffer, indent string, depth int) {
+	if indent == "" {
+		return
+	}
+	buf.WriteByte('\n')
+	buf.WriteString(strings.Repeat(indent, depth))
+}
+
+// MarshalJSONNoEscape 序列化JSON且不转义HTML字符，避免URL中的&等被编码为\u0026
+// indent不为空时输出缩进格式
+func MarshalJSONNoEscape(v interface{}, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if indent != "" {
+		encoder.SetIndent("", indent)
+	}
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode会在末尾追加换行符，与json.Marshal保持一致需去掉
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment JSONPath中的一段：对象键、数组下标或通配符
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// String 返回该段在JSONPath中的写法，用于错误信息
+func (s jsonPathSegment) String() string {
+	switch {
+	case s.wildcard:
+		return "[*]"
+	case s.isIndex:
+		return fmt.Sprintf("[%d]", s.index)
+	default:
+		return "." + s.key
+	}
+}
+
+// JSONPath 按JSONPath表达式从已解码的JSON数据中取值
+// 支持根节点$、.key、['key']、[n]（负数表示从末尾计数）以及通配符.*和[*]；
+// 不使用通配符时返回单个值，使用通配符时返回所有匹配值组成的数组（缺少后续字段的元素会被跳过）。
+// 表达式无效、字段不存在或下标越界时返回错误
+func JSONPath(data interface{}, path string) (interface{}, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []interface{}{data}
+	multi := false
+	current := "$"
+	for _, seg := range segments {
+		var next []interface{}
+		for _, node := range nodes {
+			values, err := applyJSONPathSegment(node, seg)
+			if err != nil {
+				if multi {
+					continue
+				}
+				return nil, fmt.Errorf("%s%s: %w", current, seg, err)
+			}
+			next = append(next, values...)
+		}
+		nodes = next
+		multi = multi || seg.wildcard
+		current += seg.String()
+	}
+
+	if multi {
+		if nodes == nil {
+			nodes = []interface{}{}
+		}
+		return nodes, nil
+	}
+	return nodes[0], nil
+}
+
+// applyJSONPathSegment 对单个节点应用一段路径
+func applyJSONPathSegment(node interface{}, seg jsonPathSegment) ([]interface{}, error) {
+	switch val := node.(type) {
+	case map[string]interface{}:
+		if seg.wildcard {
+			// 按键名排序，保证结果顺序稳定
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			values := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				values = append(values, val[k])
+			}
+			return values, nil
+		}
+		if seg.isIndex {
+			return nil, fmt.Errorf("对象不能使用下标访问")
+		}
+		value, ok := val[seg.key]
+		if !ok {
+			return nil, fmt.Errorf("字段不存在")
+		}
+		return []interface{}{value}, nil
+	case []interface{}:
+		if seg.wildcard {
+			return val, nil
+		}
+		if !seg.isIndex {
+			return nil, fmt.Errorf("数组不能使用字段名访问")
+		}
+		index := seg.index
+		if index < 0 {
+			index += len(val)
+		}
+		if index < 0 || index >= len(val) {
+			return nil, fmt.Errorf("下标越界，数组长度为%d", len(val))
+		}
+		return []interface{}{val[index]}, nil
+	default:
+		return nil, fmt.Errorf("节点不是对象或数组")
+	}
+}
+
+// parseJSONPath 将JSONPath表达式解析为路径段
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath必须以$开头: %s", path)
+	}
+
+	var segments []jsonPathSegment
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, ".") {
+				return nil, fmt.Errorf("JSONPath不支持递归查找(..): %s", path)
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath中.之后缺少字段名: %s", path)
+			}
+			if name == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			} else {
+				segments = append(segments, jsonPathSegment{key: name})
+			}
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath中的[缺少对应的]: %s", path)
+			}
+			seg, err := parseJSONPathBracket(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", err, path)
+			}
+			segments = append(segments, seg)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath格式错误，位置%d处应为.或[: %s", len(path)-len(rest), path)
+		}
+	}
+	return segments, nil
+}
+
+// parseJSONPathBracket 解析方括号中的内容：*、下标或带引号的字段名
+func parseJSONPathBracket(content string) (jsonPathSegment, error) {
+	content = strings.TrimSpace(content)
+	if content == "*" {
+		return jsonPathSegment{wildcard: true}, nil
+	}
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		return jsonPathSegment{key: content[1 : len(content)-1]}, nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return jsonPathSegment{}, fmt.Errorf("JSONPath中的[%s]既不是下标也不是带引号的字段名", content)
+	}
+	return jsonPathSegment{index: index, isIndex: true}, nil
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/birdmichael/RenderAPI/pkg/logger"
+)
+
+// RedactedMask 日志中替换敏感值的内容
+const RedactedMask = "***"
+
+// defaultRedactKeys 默认隐藏的字段名、请求头名和查询参数名
+var defaultRedactKeys = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "apikey"}
+
+// RedactKeys 返回默认的敏感键加上extra
+func RedactKeys(extra ...string) []string {
+	return append(append([]string(nil), defaultRedactKeys...), extra...)
+}
+
+// IsSensitiveKey 判断键名是否敏感：忽略大小写、"-"和"_"后包含keys中任意一项即视为敏感，
+// 如X-Auth-Token、access_token、apiKey
+func IsSensitiveKey(key string, keys []string) bool {
+	normalized := normalizeRedactKey(key)
+	for _, k := range keys {
+		if k = normalizeRedactKey(k); k != "" && strings.Contains(normalized, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRedactKey 转为小写并去掉"-"和"_"
+func normalizeRedactKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+// RedactHeader 复制请求头并将敏感的值替换为RedactedMask
+func RedactHeader(header http.Header, keys []string) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		if IsSensitiveKey(name, keys) {
+			redacted[name] = []string{RedactedMask}
+			continue
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// RedactURL 返回将敏感查询参数的值替换为RedactedMask后的URL字符串
+func RedactURL(u *url.URL, keys []string) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return u.String()
+	}
+	changed := false
+	for name := range query {
+		if IsSensitiveKey(name, keys) {
+			query[name] = []string{RedactedMask}
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	copied := *u
+	copied.RawQuery = query.Encode()
+	return copied.String()
+}
+
+// RedactBody 返回用于日志的请求体或响应体：JSON中敏感字段的值（包括嵌套对象和数组中的）被替换为RedactedMask，
+// contentType为表单时替换敏感表单字段；没有敏感字段或无法解析的内容原样返回。不修改body
+func RedactBody(body []byte, contentType string, keys []string) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err == nil {
+		value, changed := redactValue(data, keys)
+		if !changed {
+			return body
+		}
+		if redacted, err := json.Marshal(value); err == nil {
+			return redacted
+		}
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			changed := false
+			for name := range form {
+				if IsSensitiveKey(name, keys) {
+					form[name] = []string{RedactedMask}
+					changed = true
+				}
+			}
+			if changed {
+				return []byte(form.Encode())
+			}
+		}
+	}
+	return body
+}
+
+// redactValue 递归替换JSON值中敏感字段的值，changed表示是否有值被替换
+func redactValue(value interface{}, keys []string) (redactedValue interface{}, changed bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if IsSensitiveKey(key, keys) {
+				redacted[key] = RedactedMask
+				changed = true
+				continue
+			}
+			var itemChanged bool
+			redacted[key], itemChanged = redactValue(item, keys)
+			changed = changed || itemChanged
+		}
+		return redacted, changed
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			var itemChanged bool
+			redacted[i], itemChanged = redactValue(item, keys)
+			changed = changed || itemChanged
+		}
+		return redacted, changed
+	default:
+		return value, false
+	}
+}
+
+// LogHeader 逐行记录请求头或响应头，敏感的值被隐藏
+func LogHeader(l logger.Logger, title string, header http.Header, keys []string) {
+	if len(header) == 0 {
+		return
+	}
+	l.Infof("%s:", title)
+	for k, v := range RedactHeader(header, keys) {
+		l.Infof("  %s: %s", k, strings.Join(v, ", "))
+	}
+}
+
+// LogBody 记录格式化后的请求体或响应体，敏感的值被隐藏
+func LogBody(l logger.Logger, title string, body []byte, contentType string, keys []string) {
+	if len(body) == 0 {
+		return
+	}
+	l.Infof("%s:", title)
+	l.Infof("%s", prettyOrRaw(RedactBody(body, contentType, keys)))
+}
@@ -3,11 +3,13 @@ package utils
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+
+	"github.com/birdmichael/RenderAPI/pkg/logger"
 )
 
 // PrettyJSON 格式化JSON字符串
@@ -52,46 +54,78 @@ func SaveDataToFile(filePath string, data interface{}) error {
 	return os.WriteFile(filePath, jsonData, 0644)
 }
 
-// LogHTTPRequest 记录HTTP请求信息
-func LogHTTPRequest(req *http.Request, body []byte) {
-	fmt.Printf("[请求] %s %s\n", req.Method, req.URL.String())
+// LogHTTPRequest 记录HTTP请求信息，l为nil时使用默认日志记录器
+// URL查询参数、请求头和请求体中的password、token、authorization等敏感值以及redactKeys中的键会被替换为***，
+// 只影响日志内容，不修改请求
+func LogHTTPRequest(l logger.Logger, req *http.Request, body []byte, redactKeys ...string) {
+	l = logger.OrDefault(l)
+	keys := RedactKeys(redactKeys...)
+	l.Infof("[请求] %s %s", req.Method, RedactURL(req.URL, keys))
+	LogHeader(l, "请求头", req.Header, keys)
+	LogBody(l, "请求体", body, req.Header.Get("Content-Type"), keys)
+}
 
-	if len(req.Header) > 0 {
-		fmt.Println("请求头:")
-		for k, v := range req.Header {
-			fmt.Printf("  %s: %s\n", k, strings.Join(v, ", "))
-		}
-	}
+// LogHTTPResponse 记录HTTP响应信息，l为nil时使用默认日志记录器，敏感值的处理与LogHTTPRequest相同
+func LogHTTPResponse(l logger.Logger, resp *http.Response, body []byte, redactKeys ...string) {
+	l = logger.OrDefault(l)
+	keys := RedactKeys(redactKeys...)
+	l.Infof("[响应] 状态码: %d", resp.StatusCode)
+	LogHeader(l, "响应头", resp.Header, keys)
+	LogBody(l, "响应体", body, resp.Header.Get("Content-Type"), keys)
+}
 
-	if len(body) > 0 {
-		fmt.Println("请求体:")
-		prettyBody, err := PrettyJSON(body)
-		if err == nil {
-			fmt.Println(string(prettyBody))
-		} else {
-			fmt.Println(string(body))
-		}
+// prettyOrRaw 格式化JSON内容，不是合法JSON时原样返回
+func prettyOrRaw(body []byte) []byte {
+	if prettyBody, err := PrettyJSON(body); err == nil {
+		return prettyBody
 	}
+	return body
 }
 
-// LogHTTPResponse 记录HTTP响应信息
-func LogHTTPResponse(resp *http.Response, body []byte) {
-	fmt.Printf("[响应] 状态码: %d\n", resp.StatusCode)
+// MergeDefaults 将defaults合并到data之下，返回新的Map
+// data中已有的键优先，嵌套的Map会递归合并；data和defaults都不会被修改
+func MergeDefaults(data, defaults map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(data)+len(defaults))
+	for k, v := range defaults {
+		result[k] = v
+	}
 
-	if len(resp.Header) > 0 {
-		fmt.Println("响应头:")
-		for k, v := range resp.Header {
-			fmt.Printf("  %s: %s\n", k, strings.Join(v, ", "))
+	for k, v := range data {
+		dataMap, dataIsMap := v.(map[string]interface{})
+		defaultMap, defaultIsMap := result[k].(map[string]interface{})
+		if dataIsMap && defaultIsMap {
+			result[k] = MergeDefaults(dataMap, defaultMap)
+			continue
 		}
+		result[k] = v
+	}
+	return result
+}
+
+// GetPath 按点分隔的路径获取嵌套值，数组元素使用数字下标，例如"error.details.0.message"
+func GetPath(data interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return data, true
 	}
 
-	if len(body) > 0 {
-		fmt.Println("响应体:")
-		prettyBody, err := PrettyJSON(body)
-		if err == nil {
-			fmt.Println(string(prettyBody))
-		} else {
-			fmt.Println(string(body))
+	current := data
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
 		}
 	}
+	return current, true
 }
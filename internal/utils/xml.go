@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// XMLToMap 将XML文档转换为Map，结果形如 {根元素名: 值}
+// 元素名使用去掉命名空间前缀的本地名；只有文本的元素转换为字符串，
+// 其余元素转换为Map：属性以"@"开头，文本内容存入"#text"，同名子元素合并为数组
+func XMLToMap(data []byte) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("XML文档缺少根元素")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析XML失败: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := decodeXMLElement(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// decodeXMLElement 递归解码元素直到对应的结束标签
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	node := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		// 命名空间声明不是数据，忽略
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		node["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	hasChildren := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("解析XML失败: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			hasChildren = true
+			child, err := decodeXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			name := t.Name.Local
+			switch existing := node[name].(type) {
+			case nil:
+				node[name] = child
+			case []interface{}:
+				node[name] = append(existing, child)
+			default:
+				node[name] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if !hasChildren && len(node) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				node["#text"] = content
+			}
+			return node, nil
+		}
+	}
+}
+
+// MapToXML 将数据转换为以root为根元素的XML
+// 规则与XMLToMap相反：Map的键转换为子元素（按键名排序），"@"开头的键转换为属性，
+// "#text"转换为文本内容，数组转换为多个同名元素，其他值按fmt.Sprint输出并转义
+func MapToXML(root string, v interface{}) ([]byte, error) {
+	if root == "" {
+		return nil, fmt.Errorf("XML根元素名不能为空")
+	}
+
+	var buf bytes.Buffer
+	if err := encodeXMLElement(&buf, root, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeXMLElement 输出单个元素，数组会输出多个同名元素
+func encodeXMLElement(buf *bytes.Buffer, name string, v interface{}) error {
+	switch val := v.(type) {
+	case []interface{}:
+		for _, item := range val {
+			if err := encodeXMLElement(buf, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf.WriteString("<" + name)
+		for _, k := range keys {
+			if strings.HasPrefix(k, "@") {
+				buf.WriteString(" " + k[1:] + `="`)
+				if err := xml.EscapeText(buf, []byte(fmt.Sprint(val[k]))); err != nil {
+					return err
+				}
+				buf.WriteString(`"`)
+			}
+		}
+		buf.WriteString(">")
+
+		if text, ok := val["#text"]; ok {
+			if err := xml.EscapeText(buf, []byte(fmt.Sprint(text))); err != nil {
+				return err
+			}
+		}
+		for _, k := range keys {
+			if strings.HasPrefix(k, "@") || k == "#text" {
+				continue
+			}
+			if err := encodeXMLElement(buf, k, val[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</" + name + ">")
+		return nil
+	case nil:
+		buf.WriteString("<" + name + "/>")
+		return nil
+	default:
+		buf.WriteString("<" + name + ">")
+		if err := xml.EscapeText(buf, []byte(fmt.Sprint(val))); err != nil {
+			return err
+		}
+		buf.WriteString("</" + name + ">")
+		return nil
+	}
+}
+
+// CheckXML 检查内容是否为格式良好的XML文档（有且只有一个根元素）
+func CheckXML(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	roots := 0
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("XML格式错误: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				return fmt.Errorf("XML格式错误: 根元素之外存在文本")
+			}
+		}
+	}
+
+	if roots != 1 {
+		return fmt.Errorf("XML格式错误: 应有且只有一个根元素，实际: %d", roots)
+	}
+	return nil
+}
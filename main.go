@@ -11,16 +11,21 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/birdmichael/RenderAPI/pkg/client"
 	"github.com/birdmichael/RenderAPI/pkg/config"
+	"github.com/birdmichael/RenderAPI/pkg/logger"
 )
 
 func main() {
 	// 定义命令行参数
 	baseURL := flag.String("url", "", "API基础URL")
 	templateFile := flag.String("template", "", "模板文件路径")
-	dataFile := flag.String("data", "", "数据文件路径")
+	var dataFiles, setValues stringList
+	flag.Var(&dataFiles, "data", "数据文件路径，可重复指定，后面的文件深度合并覆盖前面的")
+	flag.Var(&setValues, "set", "覆盖模板数据中的值，格式为key=value，key为点分隔路径，可重复指定")
 	configFile := flag.String("config", "", "配置文件路径")
 	token := flag.String("token", "", "认证令牌")
 	timeout := flag.Int("timeout", 30, "请求超时时间(秒)")
@@ -29,13 +34,31 @@ func main() {
 	method := flag.String("method", "GET", "HTTP方法(不使用模板时)")
 	path := flag.String("path", "", "API路径(不使用模板时)")
 	output := flag.String("output", "", "保存响应到文件")
-	rawData := flag.String("raw", "", "原始请求数据(JSON格式)")
+	progress := flag.Bool("progress", false, "保存响应到文件时在标准错误输出下载进度")
+	rawData := flag.String("raw", "", "原始请求数据(JSON格式)，使用模板时覆盖数据文件中的值")
+	maxRedirects := flag.Int("max-redirects", 10, "最大重定向次数")
+	noFollow := flag.Bool("no-follow", false, "不跟随重定向，直接返回3xx响应")
+	http1 := flag.Bool("http1.1", false, "强制使用HTTP/1.1，不协商HTTP/2")
+	quiet := flag.Bool("quiet", false, "不输出日志，只输出响应内容")
+	extract := flag.String("extract", "", "只输出响应中JSONPath对应的值，如$.data[0].id")
+	outputFormat := flag.String("output-format", "json", "响应输出格式: json(JSON美化输出)、csv(JSON对象数组转换为CSV)")
 
 	// 解析命令行参数
 	flag.Parse()
 
+	// 创建日志记录器，详细模式下输出调试信息
+	var log logger.Logger = logger.New(os.Stdout, *verbose)
+	if *quiet {
+		log = logger.Nop
+	}
+
+	if *outputFormat != "json" && *outputFormat != "csv" {
+		log.Errorf("不支持的输出格式: %s", *outputFormat)
+		os.Exit(1)
+	}
+
 	if *baseURL == "" {
-		fmt.Println("错误: 必须指定API基础URL")
+		log.Errorf("必须指定API基础URL")
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -46,7 +69,7 @@ func main() {
 	if *configFile != "" {
 		cfg, err = config.LoadConfig(*configFile)
 		if err != nil {
-			fmt.Printf("加载配置文件失败: %v\n", err)
+			log.Errorf("加载配置文件失败: %v", err)
 			os.Exit(1)
 		}
 	} else {
@@ -57,12 +80,25 @@ func main() {
 	}
 
 	// 创建客户端
-	c := client.NewClient(cfg.BaseURL, cfg.GetTimeout())
+	opts := []client.ClientOption{
+		client.WithRedirectPolicy(*maxRedirects, !*noFollow),
+		client.WithLogger(log),
+	}
+	if *http1 {
+		opts = append(opts, client.WithHTTP2(false))
+	}
+	if cfg.TemplatedHeaders {
+		opts = append(opts, client.WithTemplatedHeaders())
+	}
+	c := client.NewClient(cfg.BaseURL, cfg.GetTimeout(), opts...)
 
 	// 设置默认头部
 	for key, value := range cfg.DefaultHeaders {
 		c.SetHeader(key, value)
 	}
+	for key, value := range cfg.DefaultQueryParams {
+		c.SetDefaultQueryParam(key, value)
+	}
 
 	// 添加认证令牌
 	if *token != "" {
@@ -75,16 +111,16 @@ func main() {
 	// 添加脚本钩子
 	if *scriptFile != "" {
 		if err := c.AddJSHookFromFile(*scriptFile, false, 30); err != nil {
-			fmt.Printf("添加脚本钩子失败: %v\n", *scriptFile)
+			log.Errorf("添加脚本钩子失败: %v", err)
 		} else {
-			fmt.Printf("已添加脚本钩子: %s\n", *scriptFile)
+			log.Infof("已添加脚本钩子: %s", *scriptFile)
 		}
 	}
 
 	// 添加日志钩子
 	if *verbose || cfg.EnableLogging {
-		c.AddBeforeHook(&loggingHook{})
-		c.AddAfterHook(&responseLogHook{})
+		c.AddBeforeHook(&loggingHook{log: log})
+		c.AddAfterHook(&responseLogHook{log: log})
 	}
 
 	// 处理请求
@@ -93,27 +129,27 @@ func main() {
 
 	if *templateFile != "" {
 		// 使用模板文件
-		if *dataFile != "" {
-			fmt.Println("使用模板和数据文件发送请求...")
-			resp, err = c.ExecuteTemplateWithDataFile(ctx, *templateFile, *dataFile)
-		} else if *rawData != "" {
-			// 解析原始数据
-			var data map[string]interface{}
-			if err := json.Unmarshal([]byte(*rawData), &data); err != nil {
-				fmt.Printf("解析JSON数据失败: %v\n", err)
+		if len(dataFiles) == 1 && *rawData == "" && len(setValues) == 0 {
+			log.Infof("使用模板和数据文件发送请求...")
+			resp, err = c.ExecuteTemplateWithDataFile(ctx, *templateFile, dataFiles[0])
+		} else if len(dataFiles) > 0 || *rawData != "" || len(setValues) > 0 {
+			// 依次合并数据文件、原始数据和-set覆盖值，后面的优先
+			data, loadErr := loadTemplateData(dataFiles, *rawData, setValues)
+			if loadErr != nil {
+				log.Errorf("%v", loadErr)
 				os.Exit(1)
 			}
-			fmt.Println("使用模板和提供的数据发送请求...")
+			log.Infof("使用模板和合并后的数据发送请求...")
 			resp, err = c.ExecuteTemplateFile(ctx, *templateFile, data)
 		} else {
-			fmt.Println("错误: 使用模板文件时必须提供数据文件或原始数据")
+			log.Errorf("使用模板文件时必须提供数据文件或原始数据")
 			flag.Usage()
 			os.Exit(1)
 		}
 	} else if *path != "" {
 		// 使用原始HTTP方法
 		fullPath := cfg.BaseURL + *path
-		fmt.Printf("发送 %s 请求到 %s...\n", *method, fullPath)
+		log.Infof("发送 %s 请求到 %s...", *method, fullPath)
 
 		switch *method {
 		case "GET":
@@ -133,57 +169,160 @@ func main() {
 		case "DELETE":
 			resp, err = c.Delete(*path)
 		default:
-			fmt.Printf("不支持的HTTP方法: %s\n", *method)
+			log.Errorf("不支持的HTTP方法: %s", *method)
 			os.Exit(1)
 		}
 	} else {
-		fmt.Println("错误: 必须指定模板文件或API路径")
+		log.Errorf("必须指定模板文件或API路径")
 		flag.Usage()
 		os.Exit(1)
 	}
 
 	if err != nil {
-		fmt.Printf("请求失败: %v\n", err)
+		log.Errorf("请求失败: %v", err)
 		os.Exit(1)
 	}
 
 	// 处理响应
 	defer resp.Body.Close()
-	fmt.Printf("状态码: %d\n", resp.StatusCode)
+	log.Infof("状态码: %d", resp.StatusCode)
+
+	// 不需要处理响应内容时直接流式写入文件，不在内存中缓存整个响应体
+	if *output != "" && *extract == "" && *outputFormat != "csv" {
+		var onProgress client.ProgressFunc
+		if *progress {
+			onProgress = newProgressPrinter(os.Stderr)
+		}
+		written, err := client.SaveResponseBody(resp, *output, onProgress)
+		if *progress {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			log.Errorf("保存响应到文件失败: %v", err)
+			os.Exit(1)
+		}
+		log.Infof("响应已保存到文件: %s (%d字节)", *output, written)
+		return
+	}
 
 	// 读取响应体
 	responseBody, err := readResponseBody(resp)
 	if err != nil {
-		fmt.Printf("读取响应失败: %v\n", err)
+		log.Errorf("读取响应失败: %v", err)
 		os.Exit(1)
 	}
 
+	// 提取JSONPath对应的值，字符串原样输出，其他值输出为JSON
+	if *extract != "" {
+		value, err := (&client.Response{Body: []byte(responseBody)}).Get(*extract)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if text, ok := value.(string); ok {
+			responseBody = text
+		} else {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				log.Errorf("序列化提取结果失败: %v", err)
+				os.Exit(1)
+			}
+			responseBody = string(encoded)
+		}
+	}
+
+	// 转换为CSV
+	if *outputFormat == "csv" {
+		csvData, err := client.ToCSV([]byte(responseBody))
+		if err != nil {
+			log.Errorf("响应无法转换为CSV: %v", err)
+			os.Exit(1)
+		}
+		responseBody = string(csvData)
+	}
+
 	// 保存响应
 	if *output != "" {
 		err := os.WriteFile(*output, []byte(responseBody), 0644)
 		if err != nil {
-			fmt.Printf("保存响应到文件失败: %v\n", err)
+			log.Errorf("保存响应到文件失败: %v", err)
 			os.Exit(1)
 		}
-		fmt.Printf("响应已保存到文件: %s\n", *output)
+		log.Infof("响应已保存到文件: %s", *output)
+	} else if *outputFormat == "csv" {
+		fmt.Print(responseBody)
+	} else if *extract != "" {
+		fmt.Println(responseBody)
 	} else {
 		// 尝试美化JSON
 		var jsonData interface{}
 		if err := json.Unmarshal([]byte(responseBody), &jsonData); err == nil {
 			prettyJSON, err := json.MarshalIndent(jsonData, "", "  ")
 			if err == nil {
-				fmt.Println("响应内容:")
+				log.Infof("响应内容:")
 				fmt.Println(string(prettyJSON))
 				return
 			}
 		}
 
 		// 如果不是JSON，直接输出
-		fmt.Println("响应内容:")
+		log.Infof("响应内容:")
 		fmt.Println(responseBody)
 	}
 }
 
+// stringList 可重复指定的字符串参数
+type stringList []string
+
+// String 实现flag.Value
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set 实现flag.Value，每次指定时追加
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// loadTemplateData 按数据文件、原始JSON数据、-set覆盖值的顺序深度合并模板数据
+func loadTemplateData(dataFiles []string, rawData string, setValues []string) (map[string]interface{}, error) {
+	data, err := client.LoadDataFiles(dataFiles...)
+	if err != nil {
+		return nil, err
+	}
+	if rawData != "" {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(rawData), &raw); err != nil {
+			return nil, fmt.Errorf("解析JSON数据失败: %w", err)
+		}
+		data = client.MergeData(data, raw)
+	}
+	overrides, err := client.ParseSetValues(setValues)
+	if err != nil {
+		return nil, err
+	}
+	return client.MergeData(data, overrides), nil
+}
+
+// newProgressPrinter 返回在w的同一行刷新下载进度的回调
+// 已知总大小时显示百分比，否则只显示已下载的字节数；最多每100毫秒刷新一次，下载完成时总会刷新
+func newProgressPrinter(w io.Writer) client.ProgressFunc {
+	var last time.Time
+	return func(written, total int64) {
+		done := total > 0 && written >= total
+		if !done && time.Since(last) < 100*time.Millisecond {
+			return
+		}
+		last = time.Now()
+		if total > 0 {
+			fmt.Fprintf(w, "\r已下载 %d/%d 字节 (%.1f%%)", written, total, float64(written)*100/float64(total))
+		} else {
+			fmt.Fprintf(w, "\r已下载 %d 字节", written)
+		}
+	}
+}
+
 // 读取响应体
 func readResponseBody(resp *http.Response) (string, error) {
 	bodyBytes, err := io.ReadAll(resp.Body)
@@ -225,10 +364,12 @@ func (h *authHook) BeforeAsync(req *http.Request) (chan *http.Request, chan erro
 }
 
 // 自定义日志钩子
-type loggingHook struct{}
+type loggingHook struct {
+	log logger.Logger
+}
 
 func (h *loggingHook) Before(req *http.Request) (*http.Request, error) {
-	fmt.Printf("发送 %s 请求到 %s\n", req.Method, req.URL.String())
+	h.log.Infof("发送 %s 请求到 %s", req.Method, req.URL.String())
 	return req, nil
 }
 
@@ -250,10 +391,12 @@ func (h *loggingHook) BeforeAsync(req *http.Request) (chan *http.Request, chan e
 }
 
 // 响应日志钩子
-type responseLogHook struct{}
+type responseLogHook struct {
+	log logger.Logger
+}
 
 func (h *responseLogHook) After(resp *http.Response) (*http.Response, error) {
-	fmt.Printf("收到响应: 状态码 %d\n", resp.StatusCode)
+	h.log.Infof("收到响应: 状态码 %d", resp.StatusCode)
 	return resp, nil
 }
 
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// acceptMismatchSnippetSize 错误信息中保留的响应体前缀长度
+const acceptMismatchSnippetSize = 200
+
+// AcceptMismatchError 响应的Content-Type不在请求Accept声明的类型范围内时返回的错误
+type AcceptMismatchError struct {
+	StatusCode  int
+	Accept      string // 请求的Accept头
+	ContentType string // 响应的Content-Type头
+	Snippet     string // 响应体开头的内容，便于判断是否为错误页
+}
+
+// Error 实现error接口
+func (e *AcceptMismatchError) Error() string {
+	msg := fmt.Sprintf("响应类型与请求的Accept不符: Accept为%s，响应状态码%d的Content-Type为%s", e.Accept, e.StatusCode, e.ContentType)
+	if e.Snippet != "" {
+		msg += "，响应内容: " + e.Snippet
+	}
+	return msg
+}
+
+// WithAcceptCheck 校验2xx响应的Content-Type与请求的Accept头是否匹配，不匹配时返回*AcceptMismatchError
+// 例如请求声明Accept: application/json却收到text/html（常见于网关或登录页返回的错误页面）。
+// 支持*/*、type/*通配符以及结构化后缀（application/json可匹配application/problem+json），
+// q=0的类型视为不接受；请求没有Accept头或响应没有Content-Type时不校验
+func WithAcceptCheck() ClientOption {
+	return func(c *Client) {
+		c.validators = append(c.validators, checkAcceptType)
+	}
+}
+
+// checkAcceptType 校验响应的Content-Type是否被请求的Accept接受
+func checkAcceptType(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || resp.Request == nil {
+		return nil
+	}
+	accept := resp.Request.Header.Get("Accept")
+	contentType := resp.Header.Get("Content-Type")
+	if accept == "" || contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && acceptsMediaType(accept, mediaType) {
+		return nil
+	}
+
+	mismatch := &AcceptMismatchError{
+		StatusCode:  resp.StatusCode,
+		Accept:      accept,
+		ContentType: contentType,
+	}
+	if resp.Body != nil {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, acceptMismatchSnippetSize))
+		// 截断处可能落在多字节字符中间
+		for len(snippet) > 0 && !utf8.Valid(snippet) {
+			snippet = snippet[:len(snippet)-1]
+		}
+		mismatch.Snippet = strings.TrimSpace(string(snippet))
+	}
+	return mismatch
+}
+
+// acceptsMediaType 判断Accept头是否接受指定的媒体类型
+func acceptsMediaType(accept, mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	// application/problem+json 的结构化后缀为json
+	_, suffix, hasSuffix := strings.Cut(subtype, "+")
+
+	for _, item := range strings.Split(accept, ",") {
+		accepted, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+
+		acceptedType, acceptedSubtype, _ := strings.Cut(accepted, "/")
+		switch {
+		case accepted == "*/*", accepted == "*":
+			return true
+		case acceptedType != typ:
+			continue
+		case acceptedSubtype == "*", acceptedSubtype == subtype:
+			return true
+		case hasSuffix && acceptedSubtype == suffix:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAcceptCheck 测试响应Content-Type与请求Accept不符时返回错误
+func TestAcceptCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html><body>请先登录</body></html>"))
+		case "/problem":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.Write([]byte(`{"title": "ok"}`))
+		case "/error":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": 1}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second, WithAcceptCheck())
+	c.SetHeader("Accept", "application/json")
+
+	_, err := c.Get("/html")
+	var mismatch *AcceptMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Accept与Content-Type不符时应返回*AcceptMismatchError，实际: %v", err)
+	}
+	if mismatch.ContentType != "text/html; charset=utf-8" || !strings.Contains(mismatch.Snippet, "请先登录") {
+		t.Errorf("错误详情不正确: %+v", mismatch)
+	}
+	if !strings.Contains(err.Error(), "application/json") {
+		t.Errorf("错误信息应包含Accept: %v", err)
+	}
+
+	// 匹配的类型、结构化后缀和非2xx响应不校验
+	for _, path := range []string{"/json", "/problem", "/error"} {
+		resp, err := c.Get(path)
+		if err != nil {
+			t.Errorf("%s 不应返回错误: %v", path, err)
+			continue
+		}
+		resp.Body.Close()
+	}
+}
+
+// TestAcceptsMediaType 测试Accept头的匹配规则
+func TestAcceptsMediaType(t *testing.T) {
+	tests := []struct {
+		accept    string
+		mediaType string
+		expected  bool
+	}{
+		{"application/json", "application/json", true},
+		{"application/json", "text/html", false},
+		{"application/json, text/plain;q=0.5", "text/plain", true},
+		{"text/*", "text/csv", true},
+		{"*/*", "image/png", true},
+		{"application/json", "application/vnd.api+json", true},
+		{"application/xml", "application/soap+xml", true},
+		{"application/json, text/html;q=0", "text/html", false},
+		{"Application/JSON", "application/json", true},
+	}
+	for _, tt := range tests {
+		if actual := acceptsMediaType(tt.accept, tt.mediaType); actual != tt.expected {
+			t.Errorf("Accept %q 与 %q 匹配结果错误，期望: %v", tt.accept, tt.mediaType, tt.expected)
+		}
+	}
+}
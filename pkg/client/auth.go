@@ -0,0 +1,122 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// TokenRefresher 获取新的Bearer令牌
+type TokenRefresher func() (string, error)
+
+// WithTokenRefresher 设置Bearer令牌刷新函数
+// 响应状态码为401时，客户端调用refresher获取新令牌，之后的请求使用"Authorization: Bearer <新令牌>"，
+// 并用新令牌重放一次原请求；重放后仍为401时直接返回该响应，不会再次刷新。
+// 多个请求同时收到401时只刷新一次，其余请求等待刷新完成（或自身的上下文结束）后使用新令牌重放。
+// 调用refresher时不持有客户端的锁，refresher可以通过同一个客户端发送请求；
+// 但该请求本身返回401时会等待正在进行的刷新，直到其上下文结束
+func WithTokenRefresher(refresher TokenRefresher) ClientOption {
+	return func(c *Client) {
+		c.tokenRefresher = refresher
+	}
+}
+
+// sendWithTokenRefresh 发送请求，收到401时刷新令牌并重放一次
+func (c *Client) sendWithTokenRefresh(client *http.Client, req *http.Request) (*http.Response, error) {
+	// 保存请求体用于重放
+	var reqBody []byte
+	if req.Body != nil {
+		body, err := hooks.ReadRequestBody(req)
+		if err != nil {
+			return nil, fmt.Errorf("读取请求体失败: %w", err)
+		}
+		reqBody = body
+	}
+
+	generation := c.applyToken(req)
+	resp, err := c.transmit(client, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	token, err := c.refreshToken(req.Context(), generation)
+	closeResponseBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("刷新令牌失败: %w", err)
+	}
+	c.log().Debugf("请求 %s %s 返回401，已刷新令牌并重放请求", req.Method, req.URL)
+
+	replay := req.Clone(req.Context())
+	if reqBody != nil {
+		replay.Body = io.NopCloser(bytes.NewReader(reqBody))
+		replay.ContentLength = int64(len(reqBody))
+	}
+	replay.Header.Set("Authorization", "Bearer "+token)
+	return c.transmit(client, replay)
+}
+
+// applyToken 为请求设置当前令牌，返回令牌的版本号
+func (c *Client) applyToken(req *http.Request) int {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.tokenGeneration
+}
+
+// tokenRefreshCall 一次正在进行的令牌刷新，done关闭后token和err可读
+type tokenRefreshCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// refreshToken 刷新令牌，generation之后令牌已被其他请求刷新过时直接返回当前令牌
+// 同一时间只有一个请求调用刷新函数，其他请求等待其结果；刷新函数在锁外执行，
+// 等待的请求在ctx结束时放弃等待
+func (c *Client) refreshToken(ctx context.Context, generation int) (string, error) {
+	c.tokenMutex.Lock()
+	if c.tokenGeneration != generation {
+		token := c.token
+		c.tokenMutex.Unlock()
+		return token, nil
+	}
+	if call := c.tokenRefresh; call != nil {
+		c.tokenMutex.Unlock()
+		select {
+		case <-call.done:
+			return call.token, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	call := &tokenRefreshCall{done: make(chan struct{})}
+	c.tokenRefresh = call
+	c.tokenMutex.Unlock()
+
+	token, err := c.tokenRefresher()
+	if err == nil && token == "" {
+		err = fmt.Errorf("刷新函数返回了空令牌")
+	}
+
+	c.tokenMutex.Lock()
+	if err == nil {
+		c.token = token
+		c.tokenGeneration++
+	}
+	call.token, call.err = token, err
+	c.tokenRefresh = nil
+	c.tokenMutex.Unlock()
+	close(call.done)
+
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
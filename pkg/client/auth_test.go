@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestTokenRefresher 测试收到401时刷新令牌并重放请求，且每个请求只重放一次
+func TestTokenRefresher(t *testing.T) {
+	var requests int
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		// 只接受第二个刷新得到的令牌
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	refreshes := 0
+	c := NewClient(server.URL, 5*time.Second, WithTokenRefresher(func() (string, error) {
+		refreshes++
+		return fmt.Sprintf("token-%d", refreshes), nil
+	}))
+	c.SetHeader("Authorization", "Bearer token-0")
+
+	// 刷新得到token-1后重放仍为401，直接返回，不会再次刷新
+	resp, err := c.Post("/api/orders", []byte(`{"id": 1}`))
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("重放后仍为401时应返回401，实际: %d", resp.StatusCode)
+	}
+	if refreshes != 1 || requests != 2 {
+		t.Errorf("每个请求只应刷新并重放一次，刷新: %d, 请求: %d", refreshes, requests)
+	}
+
+	// 使用token-1收到401后刷新得到token-2，重放成功
+	resp, err = c.Post("/api/orders", []byte(`{"id": 2}`))
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("刷新令牌后应成功，实际: %d", resp.StatusCode)
+	}
+	if refreshes != 2 || requests != 4 {
+		t.Errorf("刷新或请求次数错误，刷新: %d, 请求: %d", refreshes, requests)
+	}
+	if bodies[3] != `{"id": 2}` {
+		t.Errorf("重放的请求体错误: %s", bodies[3])
+	}
+
+	// 之后的请求直接使用新令牌
+	resp, err = c.Get("/api/orders")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || refreshes != 2 {
+		t.Errorf("应直接使用刷新后的令牌，状态码: %d, 刷新: %d", resp.StatusCode, refreshes)
+	}
+}
+
+// TestTokenRefresherError 测试刷新令牌失败时返回错误
+func TestTokenRefresherError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	refreshErr := errors.New("凭据已失效")
+	c := NewClient(server.URL, 5*time.Second, WithTokenRefresher(func() (string, error) {
+		return "", refreshErr
+	}))
+
+	_, err := c.Get("/api/orders")
+	if !errors.Is(err, refreshErr) {
+		t.Errorf("应返回刷新函数的错误，实际: %v", err)
+	}
+}
+
+// TestTokenRefresherNotHoldingLock 测试刷新期间其他收到401的请求可以随上下文结束放弃等待，
+// 且刷新函数可以通过同一个客户端发送请求
+func TestTokenRefresherNotHoldingLock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Write([]byte("fresh"))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var c *Client
+	c = NewClient(server.URL, 5*time.Second, WithTokenRefresher(func() (string, error) {
+		close(started)
+		<-release
+		resp, err := c.Get("/token")
+		if err != nil {
+			return "", err
+		}
+		body, err := ReadResponseBody(resp)
+		return string(body), err
+	}))
+	defer c.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := c.Get("/api/orders")
+		if err == nil && resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("状态码: %d", resp.StatusCode)
+		}
+		done <- err
+	}()
+	<-started
+
+	// 刷新进行中，另一个请求在自身的上下文超时后返回
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.ExecuteTemplateJSON(ctx, `{"request": {"method": "GET", "path": "/api/orders"}}`, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("等待刷新的请求应随上下文超时返回，实际: %v", err)
+	}
+
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("刷新后重放的请求应成功: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("刷新函数通过同一个客户端发送请求时阻塞")
+	}
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// BatchCountHeader 自动分批时聚合响应中记录实际请求次数的响应头
+const BatchCountHeader = "X-Batch-Count"
+
+// SetAutoBatchBody 设置模板请求体的自动分批
+// field为请求体中数组字段的点分隔路径（为空表示请求体本身是数组），数组元素超过maxItems时，
+// ExecuteTemplateJSON会按maxItems拆分为多个请求依次发送，其余字段保持不变；maxItems<=0时关闭分批。
+// 分批时返回聚合响应：响应体为各请求响应体组成的JSON数组（非JSON响应体作为字符串），
+// 状态码取第一个非2xx响应的状态码（均成功时取第一个响应），响应头X-Batch-Count记录请求次数。
+// 启用缓存时各批按自身的URL和请求体缓存（忽略keyPattern）；上下文中通过hooks.WithIdempotencyKey
+// 指定的幂等键按批次加上序号（<key>-1、<key>-2……）
+func (c *Client) SetAutoBatchBody(field string, maxItems int) {
+	c.batchField = field
+	c.batchMaxItems = maxItems
+}
+
+// splitBatchBody 按分批设置拆分请求体，不需要拆分时返回nil
+func (c *Client) splitBatchBody(body []byte) ([][]byte, error) {
+	if c.batchMaxItems <= 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("解析请求体失败: %w", err))
+	}
+
+	value, ok := utils.GetPath(data, c.batchField)
+	if !ok {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok || len(items) <= c.batchMaxItems {
+		return nil, nil
+	}
+
+	var batches [][]byte
+	for start := 0; start < len(items); start += c.batchMaxItems {
+		end := start + c.batchMaxItems
+		if end > len(items) {
+			end = len(items)
+		}
+
+		batchBody, err := c.marshalJSON(replacePath(data, c.batchField, items[start:end]))
+		if err != nil {
+			return nil, fmt.Errorf("序列化分批请求体失败: %w", err)
+		}
+		batches = append(batches, batchBody)
+	}
+	return batches, nil
+}
+
+// replacePath 返回将path处的值替换为value后的数据，沿途的Map会被复制，不修改原数据
+func replacePath(data interface{}, path string, value interface{}) interface{} {
+	if path == "" {
+		return value
+	}
+
+	key, rest, _ := strings.Cut(path, ".")
+	switch node := data.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			copied[k] = v
+		}
+		copied[key] = replacePath(node[key], rest, value)
+		return copied
+	case []interface{}:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(node) {
+			return data
+		}
+		copied := append([]interface{}(nil), node...)
+		copied[index] = replacePath(node[index], rest, value)
+		return copied
+	default:
+		return data
+	}
+}
+
+// executeBatches 依次发送分批请求并聚合响应
+func (c *Client) executeBatches(ctx context.Context, tmplDef *templateDefinition, templateID string, batches [][]byte, data interface{}) (*http.Response, error) {
+	responses := make([]*http.Response, 0, len(batches))
+	closeAll := func() {
+		for _, resp := range responses {
+			closeResponseBody(resp)
+		}
+	}
+
+	// keyPattern按模板数据渲染，各批的渲染结果相同，分批请求改用各自的URL和请求体作为缓存键
+	batchDef := *tmplDef
+	caching := c.defaultCaching
+	if tmplDef.Caching != nil {
+		caching = *tmplDef.Caching
+	}
+	if caching.KeyPattern != "" {
+		caching.KeyPattern = ""
+		batchDef.Caching = &caching
+	}
+
+	key, hasKey := hooks.IdempotencyKeyFromContext(ctx)
+	for i, batchBody := range batches {
+		// 各批是不同的逻辑请求，指定的幂等键按批次加上序号，避免服务端把后续批次当作重放
+		batchCtx := ctx
+		if hasKey {
+			batchCtx = hooks.WithIdempotencyKey(ctx, key+"-"+strconv.Itoa(i+1))
+		}
+
+		resp, err := c.executeRendered(batchCtx, &batchDef, templateID, batchBody, data)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("第%d/%d批请求失败: %w", i+1, len(batches), err)
+		}
+		responses = append(responses, resp)
+	}
+
+	return aggregateResponses(responses)
+}
+
+// aggregateResponses 将多个响应合并为一个，响应体为各响应体组成的JSON数组
+func aggregateResponses(responses []*http.Response) (*http.Response, error) {
+	bodies := make([]json.RawMessage, 0, len(responses))
+	selected := responses[0]
+	for _, resp := range responses {
+		body, err := ReadResponseBody(resp)
+		if err != nil {
+			for _, rest := range responses {
+				closeResponseBody(rest)
+			}
+			return nil, fmt.Errorf("读取分批响应体失败: %w", err)
+		}
+
+		trimmed := bytes.TrimSpace(body)
+		if len(trimmed) == 0 || !json.Valid(trimmed) {
+			trimmed, _ = utils.MarshalJSONNoEscape(string(body), "")
+		}
+		bodies = append(bodies, trimmed)
+
+		if (selected.StatusCode >= 200 && selected.StatusCode < 300) && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+			selected = resp
+		}
+	}
+
+	aggregated, err := utils.MarshalJSONNoEscape(bodies, "")
+	if err != nil {
+		return nil, fmt.Errorf("聚合分批响应失败: %w", err)
+	}
+
+	header := selected.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Content-Type", "application/json")
+	header.Set(BatchCountHeader, strconv.Itoa(len(responses)))
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        selected.Status,
+		StatusCode:    selected.StatusCode,
+		Proto:         selected.Proto,
+		ProtoMajor:    selected.ProtoMajor,
+		ProtoMinor:    selected.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(aggregated)),
+		ContentLength: int64(len(aggregated)),
+		Request:       selected.Request,
+	}, nil
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// TestAutoBatchBody 测试超过上限的数组请求体被拆分为多个请求并聚合响应
+func TestAutoBatchBody(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Data struct {
+				Source string        `json:"source"`
+				Items  []interface{} `json:"items"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("解析请求体失败: %v", err)
+		}
+		// 数组字段之外的内容在每个分批请求中保持不变
+		if body.Data.Source != "import" {
+			t.Errorf("分批请求应保留其他字段，实际: %q", body.Data.Source)
+		}
+
+		mu.Lock()
+		sizes = append(sizes, len(body.Data.Items))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"accepted": %d}`, len(body.Data.Items))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetAutoBatchBody("data.items", 100)
+
+	newTemplate := func(count int) string {
+		items := make([]map[string]interface{}, count)
+		for i := range items {
+			items[i] = map[string]interface{}{"id": i, "owner": "{{ .owner }}"}
+		}
+		encoded, _ := json.Marshal(items)
+		return fmt.Sprintf(`{
+			"request": {"method": "POST", "path": "/api/import"},
+			"body": {"data": {"source": "import", "items": %s}}
+		}`, encoded)
+	}
+	data := map[string]interface{}{"owner": "张三"}
+
+	resp, err := c.ExecuteTemplateJSON(context.Background(), newTemplate(250), data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+
+	if len(sizes) != 3 || sizes[0] != 100 || sizes[1] != 100 || sizes[2] != 50 {
+		t.Fatalf("应拆分为100/100/50三个请求，实际: %v", sizes)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("聚合响应状态码错误: %d", resp.StatusCode)
+	}
+	if count := resp.Header.Get(BatchCountHeader); count != "3" {
+		t.Errorf("%s错误: %s", BatchCountHeader, count)
+	}
+
+	var results []struct {
+		Accepted int `json:"accepted"`
+	}
+	body, err := ReadResponseBody(resp)
+	if err != nil {
+		t.Fatalf("读取聚合响应失败: %v", err)
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		t.Fatalf("解析聚合响应失败: %v", err)
+	}
+	if len(results) != 3 || results[0].Accepted != 100 || results[2].Accepted != 50 {
+		t.Errorf("聚合响应错误: %+v", results)
+	}
+
+	// 不超过上限时只发送一个请求，响应保持原样
+	sizes = nil
+	resp, err = c.ExecuteTemplateJSON(context.Background(), newTemplate(100), data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+	if len(sizes) != 1 || resp.Header.Get(BatchCountHeader) != "" {
+		t.Errorf("未超过上限时不应分批，实际请求: %v", sizes)
+	}
+}
+
+// TestAutoBatchBodyCachingAndIdempotency 测试分批请求各自缓存、各自使用带序号的幂等键，聚合响应不转义HTML字符
+func TestAutoBatchBodyCachingAndIdempotency(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		encoded, _ := json.Marshal(body["items"])
+		fmt.Fprintf(w, `{"items": %s, "note": "a<b&c"}`, encoded)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+	c.AddBeforeHook(hooks.NewIdempotencyHook())
+	c.SetAutoBatchBody("items", 2)
+
+	tmpl := `{
+		"request": {"method": "POST", "path": "/api/import"},
+		"body": {"items": [1, 2, 3, 4, 5]},
+		"caching": {"enabled": true, "ttl": 60, "keyPattern": "k-{{ .id }}"}
+	}`
+	want := `[{"items":[1,2],"note":"a<b&c"},{"items":[3,4],"note":"a<b&c"},{"items":[5],"note":"a<b&c"}]`
+
+	ctx := hooks.WithIdempotencyKey(context.Background(), "import-1")
+	for i := 0; i < 2; i++ {
+		resp, err := c.ExecuteTemplateJSON(ctx, tmpl, map[string]interface{}{"id": 1})
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		body, err := ReadResponseBody(resp)
+		if err != nil {
+			t.Fatalf("读取聚合响应失败: %v", err)
+		}
+		if string(body) != want {
+			t.Errorf("第%d次执行的聚合响应错误: %s", i+1, body)
+		}
+	}
+
+	// 第二次执行的各批请求都命中各自的缓存
+	wantKeys := []string{"import-1-1", "import-1-2", "import-1-3"}
+	if len(keys) != len(wantKeys) {
+		t.Fatalf("期望发送%d个请求，实际: %v", len(wantKeys), keys)
+	}
+	for i, key := range wantKeys {
+		if keys[i] != key {
+			t.Errorf("第%d批的幂等键错误，期望: %s, 实际: %s", i+1, key, keys[i])
+		}
+	}
+}
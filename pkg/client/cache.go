@@ -0,0 +1,312 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CachedResponse 缓存的响应
+// 只保存不可变的部分，每次命中时重新构建*http.Response，
+// 避免钩子或调用方修改返回的响应时污染缓存条目。
+// 响应带有ETag或Last-Modified时，条目过期后仍会保留，用于发送条件请求重新验证
+type CachedResponse struct {
+	Status     string
+	StatusCode int
+	Proto      string
+	ProtoMajor int
+	ProtoMinor int
+	Header     http.Header
+	Body       []byte
+	ExpireTime time.Time
+	StoredAt   time.Time // 保存到缓存的时间，用于计算Age
+
+	ETag         string // 用于If-None-Match
+	LastModified string // 用于If-Modified-Since
+}
+
+// Cache 响应缓存的存储接口，默认使用内存中的MemoryCache，可通过WithCache替换为FileCache等实现
+// 实现必须可以被多个协程并发调用；Get返回的条目由调用方只读使用
+type Cache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, entry *CachedResponse) error
+	Delete(key string) error
+}
+
+// ExpiredSweeper 可选接口，缓存实现此接口时WithCacheSweepInterval会定期调用DeleteExpired
+type ExpiredSweeper interface {
+	DeleteExpired(now time.Time)
+}
+
+// WithCache 设置客户端使用的响应缓存，例如用FileCache在重启后保留缓存
+func WithCache(cache Cache) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// CachingConfig 模板请求的缓存配置，对应模板中的caching
+type CachingConfig struct {
+	Enabled    bool   `json:"enabled"`
+	TTL        int    `json:"ttl"`                  // 缓存有效期（秒）
+	KeyPattern string `json:"keyPattern,omitempty"` // 自定义缓存键模式，为空时使用URL和请求体生成
+}
+
+// WithDefaultCaching 设置模板请求的默认缓存配置，模板没有caching配置时使用
+// 模板中写了caching（包括"enabled": false）时以模板为准
+func WithDefaultCaching(cfg CachingConfig) ClientOption {
+	return func(c *Client) {
+		c.defaultCaching = cfg
+	}
+}
+
+// DefaultMaxStale 带有ETag或Last-Modified的缓存条目过期后默认保留的时间，超过后由DeleteExpired删除
+const DefaultMaxStale = 24 * time.Hour
+
+// MemoryCache 基于内存Map的缓存，是客户端的默认缓存
+type MemoryCache struct {
+	MaxStale time.Duration // 可重新验证的条目过期后保留的时间，<=0时使用DefaultMaxStale
+
+	mutex   sync.RWMutex
+	entries map[string]*CachedResponse
+}
+
+// NewMemoryCache 创建内存缓存
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]*CachedResponse)}
+}
+
+// Get 获取缓存条目
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	entry, ok := m.entries[key]
+	return entry, ok
+}
+
+// Set 保存缓存条目
+func (m *MemoryCache) Set(key string, entry *CachedResponse) error {
+	m.mutex.Lock()
+	m.entries[key] = entry
+	m.mutex.Unlock()
+	return nil
+}
+
+// Delete 删除缓存条目
+func (m *MemoryCache) Delete(key string) error {
+	m.mutex.Lock()
+	delete(m.entries, key)
+	m.mutex.Unlock()
+	return nil
+}
+
+// DeleteExpired 删除所有已过期的缓存条目，带有ETag或Last-Modified的条目在过期后保留MaxStale用于条件请求
+func (m *MemoryCache) DeleteExpired(now time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for key, entry := range m.entries {
+		if sweepable(now, entry.ExpireTime, entry.revalidatable(), m.MaxStale) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// Len 返回缓存条目数
+func (m *MemoryCache) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.entries)
+}
+
+// sweepable 判断过期时间为expire的条目能否被清理，可重新验证的条目在过期后再保留maxStale
+func sweepable(now, expire time.Time, revalidatable bool, maxStale time.Duration) bool {
+	if revalidatable {
+		if maxStale <= 0 {
+			maxStale = DefaultMaxStale
+		}
+		expire = expire.Add(maxStale)
+	}
+	return !now.Before(expire)
+}
+
+// revalidatable 条目是否可以通过条件请求重新验证
+func (cached *CachedResponse) revalidatable() bool {
+	return cached.ETag != "" || cached.LastModified != ""
+}
+
+// age 返回条目在now时的年龄（秒），包括源站响应中已有的Age
+func (cached *CachedResponse) age(now time.Time) int64 {
+	age := int64(now.Sub(cached.StoredAt) / time.Second)
+	if age < 0 {
+		age = 0
+	}
+	if upstream, err := strconv.ParseInt(cached.Header.Get("Age"), 10, 64); err == nil && upstream > 0 {
+		age += upstream
+	}
+	return age
+}
+
+// setConditionalHeaders 为请求设置条件请求头
+func (cached *CachedResponse) setConditionalHeaders(req *http.Request) {
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+}
+
+// notModifiedResponse 收到304时根据缓存条目构建完整响应，并使用304响应中的头部更新缓存的头部
+func (cached *CachedResponse) notModifiedResponse(req *http.Request, notModified *http.Response) *http.Response {
+	resp := cached.newResponse(req)
+	for key, values := range notModified.Header {
+		// 304响应没有响应体，不能覆盖实体长度相关的头部
+		if key == "Content-Length" || key == "Content-Type" {
+			continue
+		}
+		resp.Header[key] = append([]string(nil), values...)
+	}
+	return resp
+}
+
+// newResponse 根据缓存条目构建一个全新的响应
+func (cached *CachedResponse) newResponse(req *http.Request) *http.Response {
+	body := make([]byte, len(cached.Body))
+	copy(body, cached.Body)
+	header := cached.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        cached.Status,
+		StatusCode:    cached.StatusCode,
+		Proto:         cached.Proto,
+		ProtoMajor:    cached.ProtoMajor,
+		ProtoMinor:    cached.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// generateCacheKey 根据请求URL和请求体生成默认的缓存键
+func (c *Client) generateCacheKey(req *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, req.URL.String())
+	h.Write(body)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// renderCacheKey 使用请求数据渲染缓存键模式，pattern为空时返回空字符串
+func (c *Client) renderCacheKey(templateID, pattern string, data interface{}) (string, error) {
+	if pattern == "" {
+		return "", nil
+	}
+	name := templateID + "_cache_key"
+	if err := c.templateEngine.AddTemplate(name, pattern); err != nil {
+		return "", &TemplateError{Err: fmt.Errorf("解析缓存键模式失败: %w", err)}
+	}
+	key, err := c.templateEngine.Execute(name, data)
+	if err != nil {
+		return "", &TemplateError{Err: fmt.Errorf("渲染缓存键模式失败: %w", err)}
+	}
+	return key, nil
+}
+
+// getFromCache 从缓存中获取key对应的响应，每次命中返回独立的响应副本
+func (c *Client) getFromCache(req *http.Request, key string) (*http.Response, bool) {
+	cached, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	if now := time.Now(); now.Before(cached.ExpireTime) {
+		resp := cached.newResponse(req)
+		resp.Header.Set("Age", strconv.FormatInt(cached.age(now), 10))
+		return resp, true
+	}
+
+	// 可重新验证的条目保留，等待条件请求刷新
+	if cached.revalidatable() {
+		return nil, false
+	}
+
+	// 缓存已过期，删除
+	if err := c.cache.Delete(key); err != nil {
+		c.log().Errorf("删除过期缓存失败: %v", err)
+	}
+	return nil, false
+}
+
+// getRevalidationEntry 获取key对应的已过期但可以通过条件请求重新验证的缓存条目
+func (c *Client) getRevalidationEntry(key string) *CachedResponse {
+	cached, ok := c.cache.Get(key)
+	if !ok || !cached.revalidatable() {
+		return nil
+	}
+	return cached
+}
+
+// saveToCache 以key保存响应到缓存
+func (c *Client) saveToCache(key string, resp *http.Response, respBody []byte, duration time.Duration) {
+	// 只缓存成功的响应
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return
+	}
+
+	body := make([]byte, len(respBody))
+	copy(body, respBody)
+	now := time.Now()
+	cached := &CachedResponse{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		ProtoMajor: resp.ProtoMajor,
+		ProtoMinor: resp.ProtoMinor,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		ExpireTime: now.Add(duration),
+		StoredAt:   now,
+
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	if err := c.cache.Set(key, cached); err != nil {
+		c.log().Errorf("保存缓存失败: %v", err)
+	}
+}
+
+// sweepExpiredCache 删除所有已过期的缓存条目（可重新验证的条目超过保留时间后才删除），缓存未实现ExpiredSweeper时不做任何事
+func (c *Client) sweepExpiredCache() {
+	if sweeper, ok := c.cache.(ExpiredSweeper); ok {
+		sweeper.DeleteExpired(time.Now())
+	}
+}
+
+// startCacheSweeper 启动定期清理过期缓存的后台协程，Close时退出
+func (c *Client) startCacheSweeper(interval time.Duration) {
+	c.workers.Add(1)
+	go func() {
+		defer c.workers.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.sweepExpiredCache()
+			case <-c.closeCh:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,420 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestClientClose 测试关闭客户端
+func TestClientClose(t *testing.T) {
+	c := NewClient("http://example.com", 5*time.Second, WithCacheSweepInterval(10*time.Millisecond))
+
+	// 写入一个已过期的缓存条目
+	c.saveToCache("expired", &http.Response{StatusCode: http.StatusOK}, []byte("{}"), -time.Second)
+
+	// 等待清理协程删除过期条目
+	deadline := time.Now().Add(time.Second)
+	for {
+		remaining := c.cache.(*MemoryCache).Len()
+		if remaining == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("清理协程未删除过期缓存")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Close应该等待清理协程退出
+	done := make(chan struct{})
+	go func() {
+		c.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close未能停止清理协程")
+	}
+
+	// 多次关闭是安全的
+	if err := c.Close(); err != nil {
+		t.Errorf("重复关闭返回错误: %v", err)
+	}
+
+	// 关闭后写入的过期条目不会再被清理
+	c.saveToCache("expired", &http.Response{StatusCode: http.StatusOK}, []byte("{}"), -time.Second)
+	time.Sleep(30 * time.Millisecond)
+	remaining := c.cache.(*MemoryCache).Len()
+	if remaining != 1 {
+		t.Errorf("关闭后清理协程仍在运行，缓存条目数: %d", remaining)
+	}
+}
+
+// TestClientCloseWithoutWorkers 测试未启动后台协程时关闭客户端
+func TestClientCloseWithoutWorkers(t *testing.T) {
+	c := NewClient("http://example.com", 5*time.Second)
+	if err := c.Close(); err != nil {
+		t.Errorf("关闭客户端失败: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("重复关闭返回错误: %v", err)
+	}
+}
+
+// TestCachedResponseIsolation 测试修改缓存命中的响应不会污染缓存条目
+func TestCachedResponseIsolation(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-Origin", "server")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+
+	templateJSON := `{
+		"request": {"method": "GET", "path": "/api/users"},
+		"caching": {"enabled": true, "ttl": 60}
+	}`
+
+	// 第一次请求写入缓存，第二次命中缓存后修改响应
+	for i := 0; i < 2; i++ {
+		resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		resp.Header.Set("X-Origin", "mutated")
+		resp.Header.Set("X-Extra", "mutated")
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	// 第三次命中缓存应看到原始数据
+	resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if requests != 1 {
+		t.Errorf("后续请求应命中缓存，服务器收到请求数: %d", requests)
+	}
+	if got := resp.Header.Get("X-Origin"); got != "server" {
+		t.Errorf("缓存的响应头被修改，期望: server, 实际: %s", got)
+	}
+	if got := resp.Header.Get("X-Extra"); got != "" {
+		t.Errorf("缓存的响应头不应包含调用方添加的头: %s", got)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"id": 1}` {
+		t.Errorf("缓存的响应体错误: %s", body)
+	}
+}
+
+// TestConditionalGet 测试基于ETag/Last-Modified的条件请求
+func TestConditionalGet(t *testing.T) {
+	var notModified, conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			conditional++
+		}
+		if r.URL.Path == "/etag" && r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.URL.Path == "/modified" && r.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.URL.Path == "/modified" {
+			w.Header().Del("ETag")
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"version": 1}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+
+	for _, path := range []string{"/etag", "/modified"} {
+		// TTL为0，每次请求都需要重新验证
+		templateJSON := `{
+			"request": {"method": "GET", "path": "` + path + `"},
+			"caching": {"enabled": true, "ttl": 0}
+		}`
+
+		for i := 0; i < 3; i++ {
+			resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("%s 第%d次请求状态码错误: %d", path, i+1, resp.StatusCode)
+			}
+			if string(body) != `{"version": 1}` {
+				t.Errorf("%s 第%d次请求响应体错误: %s", path, i+1, body)
+			}
+		}
+	}
+
+	if conditional != 4 || notModified != 4 {
+		t.Errorf("条件请求次数错误，条件请求: %d, 304响应: %d", conditional, notModified)
+	}
+
+	// 未启用缓存时不发送条件请求
+	conditional = 0
+	resp, err := c.Get("/etag")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if conditional != 0 {
+		t.Error("未启用缓存的请求不应发送条件请求头")
+	}
+}
+
+// TestCacheSweeperKeepsRevalidatable 测试清理协程保留带ETag的过期条目，后续请求仍能通过304重新验证
+func TestCacheSweeperKeepsRevalidatable(t *testing.T) {
+	var conditional, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			atomic.AddInt32(&conditional, 1)
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"version": 1}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second, WithCacheSweepInterval(5*time.Millisecond))
+	defer c.Close()
+
+	templateJSON := `{
+		"request": {"method": "GET", "path": "/etag"},
+		"caching": {"enabled": true, "ttl": 0}
+	}`
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || string(body) != `{"version": 1}` {
+			t.Errorf("第%d次请求的响应错误: %d %s", i+1, resp.StatusCode, body)
+		}
+
+		// 等待清理协程运行若干次
+		time.Sleep(30 * time.Millisecond)
+		if remaining := c.cache.(*MemoryCache).Len(); remaining != 1 {
+			t.Fatalf("清理协程不应删除可重新验证的条目，缓存条目数: %d", remaining)
+		}
+	}
+
+	if atomic.LoadInt32(&conditional) != 1 || atomic.LoadInt32(&notModified) != 1 {
+		t.Errorf("条件请求次数错误，条件请求: %d, 304响应: %d", conditional, notModified)
+	}
+}
+
+// TestMemoryCacheMaxStale 测试可重新验证的过期条目超过保留时间后被清理
+func TestMemoryCacheMaxStale(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+
+	cache := NewMemoryCache()
+	cache.Set("plain", &CachedResponse{ExpireTime: expired})
+	cache.Set("etag", &CachedResponse{ExpireTime: expired, ETag: `"v1"`})
+
+	cache.DeleteExpired(now)
+	if _, ok := cache.Get("plain"); ok {
+		t.Error("无法重新验证的过期条目应被删除")
+	}
+	if _, ok := cache.Get("etag"); !ok {
+		t.Fatal("可重新验证的条目在保留时间内不应被删除")
+	}
+
+	// 默认保留DefaultMaxStale
+	cache.DeleteExpired(expired.Add(DefaultMaxStale))
+	if cache.Len() != 0 {
+		t.Error("超过DefaultMaxStale的可重新验证条目应被删除")
+	}
+
+	// 自定义保留时间
+	cache.MaxStale = time.Second
+	cache.Set("etag", &CachedResponse{ExpireTime: expired, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"})
+	cache.DeleteExpired(now)
+	if cache.Len() != 0 {
+		t.Error("超过MaxStale的可重新验证条目应被删除")
+	}
+}
+
+// TestCachedResponseAge 测试命中缓存时Age头为保存后经过的秒数
+func TestCachedResponseAge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+
+	templateJSON := `{
+		"request": {"method": "GET", "path": "/api/users"},
+		"caching": {"enabled": true, "ttl": 60}
+	}`
+
+	ages := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		// 两次命中缓存之间间隔超过1秒
+		if i == 2 {
+			time.Sleep(1100 * time.Millisecond)
+		}
+		resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		closeResponseBody(resp)
+		ages = append(ages, resp.Header.Get("Age"))
+	}
+
+	if ages[0] != "" {
+		t.Errorf("来自服务器的响应不应设置Age，实际: %q", ages[0])
+	}
+	first, err := strconv.Atoi(ages[1])
+	if err != nil {
+		t.Fatalf("命中缓存的响应应包含数字Age头，实际: %q", ages[1])
+	}
+	second, err := strconv.Atoi(ages[2])
+	if err != nil {
+		t.Fatalf("命中缓存的响应应包含数字Age头，实际: %q", ages[2])
+	}
+	if second <= first {
+		t.Errorf("Age应随时间增长，第一次: %d，第二次: %d", first, second)
+	}
+}
+
+// TestFileCachePersistence 测试共用缓存目录的两个客户端之间缓存持久有效
+func TestFileCachePersistence(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Origin", "server")
+		w.Write([]byte(`{"id": 1, "name": "张三"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	templateJSON := `{
+		"request": {"method": "GET", "path": "/api/users"},
+		"caching": {"enabled": true, "ttl": 60}
+	}`
+
+	// 模拟进程重启：每次使用新的客户端和新的FileCache实例
+	for i := 0; i < 2; i++ {
+		cache, err := NewFileCache(dir)
+		if err != nil {
+			t.Fatalf("创建文件缓存失败: %v", err)
+		}
+		c := NewClient(server.URL, 5*time.Second, WithCache(cache))
+
+		resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+		if err != nil {
+			t.Fatalf("第%d个客户端执行模板失败: %v", i+1, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		c.Close()
+
+		if resp.StatusCode != http.StatusOK || string(body) != `{"id": 1, "name": "张三"}` {
+			t.Errorf("第%d个客户端的响应错误: %d %s", i+1, resp.StatusCode, body)
+		}
+		if got := resp.Header.Get("X-Origin"); got != "server" {
+			t.Errorf("第%d个客户端的响应头错误: %s", i+1, got)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("第二个客户端应命中文件缓存，服务器收到请求数: %d", requests)
+	}
+
+	// 过期条目由DeleteExpired清理
+	cache, _ := NewFileCache(dir)
+	cache.DeleteExpired(time.Now().Add(2 * time.Minute))
+	files, _ := os.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("过期的缓存文件应被删除，剩余: %d", len(files))
+	}
+}
+
+// TestFileCacheSweepKeepsRevalidatable 测试DeleteExpired保留带ETag或Last-Modified的过期缓存文件，超过保留时间后删除
+func TestFileCacheSweepKeepsRevalidatable(t *testing.T) {
+	cache, err := NewFileCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建文件缓存失败: %v", err)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	entries := map[string]*CachedResponse{
+		"plain":    {StatusCode: http.StatusOK, Body: []byte("{}"), ExpireTime: expired},
+		"etag":     {StatusCode: http.StatusOK, Body: []byte("{}"), ExpireTime: expired, ETag: `"v1"`},
+		"modified": {StatusCode: http.StatusOK, Body: []byte("{}"), ExpireTime: expired, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"},
+	}
+	for key, entry := range entries {
+		if err := cache.Set(key, entry); err != nil {
+			t.Fatalf("写入缓存失败: %v", err)
+		}
+	}
+
+	cache.DeleteExpired(time.Now())
+
+	if _, ok := cache.Get("plain"); ok {
+		t.Error("无法重新验证的过期文件应被删除")
+	}
+	for _, key := range []string{"etag", "modified"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("可重新验证的过期文件 %s 不应被删除", key)
+		}
+	}
+
+	// 超过保留时间后同样被删除
+	cache.DeleteExpired(expired.Add(DefaultMaxStale))
+	for key := range entries {
+		if _, ok := cache.Get(key); ok {
+			t.Errorf("超过DefaultMaxStale的过期文件 %s 应被删除", key)
+		}
+	}
+
+	cache.MaxStale = time.Second
+	cache.Set("etag", entries["etag"])
+	cache.DeleteExpired(time.Now())
+	if _, ok := cache.Get("etag"); ok {
+		t.Error("超过MaxStale的过期文件应被删除")
+	}
+}
@@ -4,25 +4,42 @@ package client
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/birdmichael/RenderAPI/internal/utils"
 	"github.com/birdmichael/RenderAPI/pkg/hooks"
+	"github.com/birdmichael/RenderAPI/pkg/logger"
 	"github.com/birdmichael/RenderAPI/pkg/template"
 )
 
-// CachedResponse 缓存的响应
-type CachedResponse struct {
-	Response   *http.Response
-	Body       []byte
-	ExpireTime time.Time
+// templateDefinition JSON请求模板的定义结构
+type templateDefinition struct {
+	Request struct {
+		Method      string            `json:"method"`
+		BaseURL     string            `json:"baseURL,omitempty"`
+		Path        string            `json:"path"`
+		Headers     map[string]string `json:"headers,omitempty"`
+		HeadersExpr string            `json:"headersExpr,omitempty"` // 渲染结果为JSON对象的模板表达式，覆盖Headers中的同名请求头
+		Timeout     int               `json:"timeout,omitempty"`
+	} `json:"request"`
+	Body        interface{}            `json:"body,omitempty"`  // JSON对象或数组（批量请求）
+	Files       map[string]string      `json:"files,omitempty"` // 字段名 -> 文件路径模板，存在时以multipart/form-data发送
+	BeforeHooks []hooks.HookDefinition `json:"beforeHooks,omitempty"`
+	AfterHooks  []hooks.HookDefinition `json:"afterHooks,omitempty"`
+	Caching     *CachingConfig         `json:"caching,omitempty"` // 为nil时使用WithDefaultCaching设置的默认值
+	Retry       *RetryConfig           `json:"retry,omitempty"`   // 为nil时使用WithDefaultRetry设置的默认值
 }
 
 // Client 提供HTTP请求功能
@@ -32,22 +49,85 @@ type Client struct {
 	headers        map[string]string
 	beforeHook     []hooks.BeforeRequestHook
 	afterHook      []hooks.AfterResponseHook
+	beforePriority []int // 与beforeHook一一对应的优先级，beforeHook按优先级升序排列
+	afterPriority  []int // 与afterHook一一对应的优先级
 	templateEngine *template.Engine
-	cache          map[string]*CachedResponse // 缓存
-	cacheMutex     sync.RWMutex               // 缓存锁
+	cache          Cache // 响应缓存
+
+	asyncHooks           bool                // 是否并发执行相邻的独立钩子
+	validators           []ResponseValidator // 全局响应校验器
+	failOnErrorStatus    bool                // 非2xx响应是否返回错误
+	failOnGraphQLErrors  bool                // GraphQL响应包含errors时是否返回错误
+	healthPath           string              // Ping使用的健康检查路径
+	jsonMarshal          JSONMarshaler       // 请求体序列化函数，nil表示使用默认实现
+	retryPolicy          RetryPolicy         // 模板请求的重试策略
+	defaultRetry         RetryConfig         // 模板没有retry配置时使用的重试配置
+	defaultCaching       CachingConfig       // 模板没有caching配置时使用的缓存配置
+	maxResponseBytes     int64               // 响应体最大字节数，<=0表示不限制
+	bodyReadAttempts     int                 // 响应体不完整时的最大尝试次数，<=1表示不重试
+	keepOriginalResponse bool                // 是否保留钩子处理前的原始响应体
+	logger               logger.Logger       // 客户端及钩子的日志记录器，nil表示钩子各自使用默认实现
+	batchField           string              // 自动分批的数组字段路径，为空表示请求体本身
+	batchMaxItems        int                 // 每批最多的数组元素数，<=0表示不分批
+	tokenRefresher       TokenRefresher      // 收到401时获取新令牌的函数，nil表示不刷新
+	templatedHeaders     bool                // 发送请求时是否渲染默认请求头中的模板
+	proxyURL             *url.URL            // 代理地址，nil表示使用环境变量中的代理
+	proxyAuth            *url.Userinfo       // 代理的Basic认证信息
+	shareCache           bool                // Clone时是否与原客户端共用缓存
+	hostHeader           string              // 覆盖URL主机的Host请求头，为空表示使用URL中的主机
+	keepNilBody          bool                // 为true时nil请求体的JSON请求不补充{}
+	queryParams          map[string]string   // 添加到每个请求URL中的默认查询参数
+
+	token           string            // 刷新得到的当前令牌，为空时使用原有的Authorization请求头
+	tokenGeneration int               // 令牌版本号，每次刷新加一
+	tokenRefresh    *tokenRefreshCall // 正在进行的刷新，nil表示没有
+	tokenMutex      sync.Mutex        // 保护令牌状态，调用刷新函数时不持有
+
+	metricsWriter io.Writer  // 指标日志输出，nil表示不记录
+	metricsMutex  sync.Mutex // 保证多协程写入的每行完整
+
+	transcript atomic.Pointer[transcriptLog] // 请求记录，nil表示不记录
+
+	sweepInterval time.Duration  // 过期缓存清理间隔，0表示不启动清理协程
+	closeCh       chan struct{}  // 关闭信号，通知后台协程退出
+	closeOnce     sync.Once      // 保证Close只执行一次
+	workers       sync.WaitGroup // 后台协程
 }
 
-// NewClient 创建一个新的HTTP客户端
-func NewClient(baseURL string, timeout time.Duration) *Client {
-	return &Client{
+// NewClient 创建一个新的HTTP客户端，可通过ClientOption调整默认行为
+func NewClient(baseURL string, timeout time.Duration, opts ...ClientOption) *Client {
+	c := &Client{
 		client: &http.Client{
 			Timeout: timeout,
 		},
 		baseURL:        baseURL,
 		headers:        make(map[string]string),
 		templateEngine: template.NewEngine(),
-		cache:          make(map[string]*CachedResponse),
+		cache:          NewMemoryCache(),
+		closeCh:        make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	// 启动后台协程
+	if c.sweepInterval > 0 {
+		c.startCacheSweeper(c.sweepInterval)
+	}
+
+	return c
+}
+
+// Close 停止客户端的所有后台协程并关闭空闲连接
+// Close可以安全地多次调用；关闭后客户端仍可发送请求，但不会再清理过期缓存
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+		c.workers.Wait()
+		c.client.CloseIdleConnections()
+	})
+	return nil
 }
 
 // SetHeader 设置HTTP请求头
@@ -55,14 +135,82 @@ func (c *Client) SetHeader(key, value string) {
 	c.headers[key] = value
 }
 
-// AddBeforeHook 添加请求前钩子
+// AddBeforeHook 添加优先级为0的请求前钩子
 func (c *Client) AddBeforeHook(hook hooks.BeforeRequestHook) {
-	c.beforeHook = append(c.beforeHook, hook)
+	c.AddBeforeHookPriority(hook, 0)
 }
 
-// AddAfterHook 添加响应后钩子
+// AddAfterHook 添加优先级为0的响应后钩子
 func (c *Client) AddAfterHook(hook hooks.AfterResponseHook) {
-	c.afterHook = append(c.afterHook, hook)
+	c.AddAfterHookPriority(hook, 0)
+}
+
+// AddBeforeHookPriority 按优先级添加请求前钩子，优先级小的先执行，优先级相同的按添加顺序执行
+// 例如签名钩子可以使用较大的优先级，保证在认证、修改请求体的钩子之后执行，不受添加顺序影响
+func (c *Client) AddBeforeHookPriority(hook hooks.BeforeRequestHook, priority int) {
+	c.injectLogger(hook)
+	i := priorityPosition(c.beforePriority, priority)
+	c.beforeHook = slices.Insert(c.beforeHook, i, hook)
+	c.beforePriority = slices.Insert(c.beforePriority, i, priority)
+}
+
+// AddAfterHookPriority 按优先级添加响应后钩子，规则与AddBeforeHookPriority相同
+func (c *Client) AddAfterHookPriority(hook hooks.AfterResponseHook, priority int) {
+	c.injectLogger(hook)
+	i := priorityPosition(c.afterPriority, priority)
+	c.afterHook = slices.Insert(c.afterHook, i, hook)
+	c.afterPriority = slices.Insert(c.afterPriority, i, priority)
+}
+
+// priorityPosition 返回新钩子的插入位置：所有优先级不大于priority的钩子之后
+func priorityPosition(priorities []int, priority int) int {
+	i := len(priorities)
+	for i > 0 && priorities[i-1] > priority {
+		i--
+	}
+	return i
+}
+
+// ClearBeforeHooks 清除所有请求前钩子
+func (c *Client) ClearBeforeHooks() {
+	c.beforeHook = nil
+	c.beforePriority = nil
+}
+
+// ClearAfterHooks 清除所有响应后钩子
+func (c *Client) ClearAfterHooks() {
+	c.afterHook = nil
+	c.afterPriority = nil
+}
+
+// BeforeHookCount 返回已注册的请求前钩子数量
+func (c *Client) BeforeHookCount() int {
+	return len(c.beforeHook)
+}
+
+// AfterHookCount 返回已注册的响应后钩子数量
+func (c *Client) AfterHookCount() int {
+	return len(c.afterHook)
+}
+
+// RemoveBeforeHookAt 删除执行顺序中指定位置的请求前钩子，其余钩子保持原有顺序
+func (c *Client) RemoveBeforeHookAt(i int) error {
+	if i < 0 || i >= len(c.beforeHook) {
+		return fmt.Errorf("钩子索引越界: %d", i)
+	}
+	c.beforeHook = append(c.beforeHook[:i:i], c.beforeHook[i+1:]...)
+	c.beforePriority = append(c.beforePriority[:i:i], c.beforePriority[i+1:]...)
+	return nil
+}
+
+// RemoveAfterHookAt 删除执行顺序中指定位置的响应后钩子，其余钩子保持原有顺序
+func (c *Client) RemoveAfterHookAt(i int) error {
+	if i < 0 || i >= len(c.afterHook) {
+		return fmt.Errorf("钩子索引越界: %d", i)
+	}
+	c.afterHook = append(c.afterHook[:i:i], c.afterHook[i+1:]...)
+	c.afterPriority = append(c.afterPriority[:i:i], c.afterPriority[i+1:]...)
+	return nil
 }
 
 // AddJSHookFromFile 从文件添加JavaScript钩子
@@ -101,30 +249,57 @@ func (c *Client) ExecuteTemplateFile(ctx context.Context, templateFile string, d
 	// 加载模板文件
 	tmplContent, err := os.ReadFile(templateFile)
 	if err != nil {
-		return nil, fmt.Errorf("读取模板文件失败: %w", err)
+		return nil, templateFileError(err)
 	}
+	ctx = withTemplateSnapshotName(ctx, templateFile)
 
 	return c.ExecuteTemplateJSON(ctx, string(tmplContent), data)
 }
 
+// withTemplateSnapshotName 上下文中没有快照名称时以模板文件名（不含扩展名）作为hooks.SnapshotHook的快照名称
+func withTemplateSnapshotName(ctx context.Context, templateFile string) context.Context {
+	if _, ok := hooks.SnapshotNameFromContext(ctx); ok {
+		return ctx
+	}
+	base := filepath.Base(templateFile)
+	return hooks.WithSnapshotName(ctx, strings.TrimSuffix(base, filepath.Ext(base)))
+}
+
+// templateFileError 包装读取模板文件的错误，文件不存在时可以匹配ErrTemplateNotFound
+func templateFileError(err error) error {
+	err = fmt.Errorf("读取模板文件失败: %w", err)
+	if errors.Is(err, os.ErrNotExist) {
+		err = utils.WrapSentinel(ErrTemplateNotFound, err)
+	}
+	return &TemplateError{Err: err}
+}
+
+// ExecuteTemplateWithData 使用数据和默认值执行JSON模板请求
+// defaults会合并到data之下（data中的值优先，嵌套Map递归合并），
+// 避免可选字段缺失时渲染出<no value>
+func (c *Client) ExecuteTemplateWithData(ctx context.Context, templateJSON string, data, defaults map[string]interface{}) (*http.Response, error) {
+	return c.ExecuteTemplateJSON(ctx, templateJSON, utils.MergeDefaults(data, defaults))
+}
+
 // ExecuteTemplateWithDataFile 使用模板文件和数据文件执行请求
 func (c *Client) ExecuteTemplateWithDataFile(ctx context.Context, templateFile, dataFile string) (*http.Response, error) {
 	// 加载模板文件
 	tmplContent, err := os.ReadFile(templateFile)
 	if err != nil {
-		return nil, fmt.Errorf("读取模板文件失败: %w", err)
+		return nil, templateFileError(err)
 	}
+	ctx = withTemplateSnapshotName(ctx, templateFile)
 
 	// 加载数据文件
 	dataContent, err := os.ReadFile(dataFile)
 	if err != nil {
-		return nil, fmt.Errorf("读取数据文件失败: %w", err)
+		return nil, &TemplateError{Err: fmt.Errorf("读取数据文件失败: %w", err)}
 	}
 
 	// 解析数据
 	var data interface{}
 	if err := json.Unmarshal(dataContent, &data); err != nil {
-		return nil, fmt.Errorf("解析数据文件失败: %w", err)
+		return nil, &TemplateError{Err: utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("解析数据文件失败: %w", err))}
 	}
 
 	return c.ExecuteTemplateJSON(ctx, string(tmplContent), data)
@@ -133,53 +308,51 @@ func (c *Client) ExecuteTemplateWithDataFile(ctx context.Context, templateFile,
 // ExecuteTemplateJSON 使用JSON字符串模板执行请求
 func (c *Client) ExecuteTemplateJSON(ctx context.Context, templateJSON string, data interface{}) (*http.Response, error) {
 	// 解析模板定义
-	var tmplDef struct {
-		Request struct {
-			Method  string            `json:"method"`
-			BaseURL string            `json:"baseURL"`
-			Path    string            `json:"path"`
-			Headers map[string]string `json:"headers"`
-			Timeout int               `json:"timeout"`
-		} `json:"request"`
-		Body        map[string]interface{} `json:"body"`
-		BeforeHooks []hooks.HookDefinition `json:"beforeHooks"`
-		AfterHooks  []hooks.HookDefinition `json:"afterHooks"`
-		Caching     struct {
-			Enabled    bool   `json:"enabled"`
-			TTL        int    `json:"ttl"`
-			KeyPattern string `json:"keyPattern"`
-		} `json:"caching"`
-		Retry struct {
-			Enabled       bool `json:"enabled"`
-			MaxAttempts   int  `json:"maxAttempts"`
-			InitialDelay  int  `json:"initialDelay"`
-			BackoffFactor int  `json:"backoffFactor"`
-		} `json:"retry"`
-	}
-
+	var tmplDef templateDefinition
 	if err := json.Unmarshal([]byte(templateJSON), &tmplDef); err != nil {
-		return nil, fmt.Errorf("解析模板定义失败: %w", err)
+		return nil, &TemplateError{Err: utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("解析模板定义失败: %w", err))}
 	}
 
 	// 生成唯一模板ID
 	templateID := fmt.Sprintf("template_%d", time.Now().UnixNano())
 
+	// 请求体只能是JSON对象或数组
+	switch tmplDef.Body.(type) {
+	case nil, map[string]interface{}, []interface{}:
+	default:
+		return nil, &TemplateError{Err: utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("请求体必须是JSON对象或数组: %T", tmplDef.Body))}
+	}
+
 	// 添加正文模板
-	bodyTemplate, err := json.Marshal(tmplDef.Body)
+	bodyTemplate, err := utils.MarshalJSONNoEscape(tmplDef.Body, "")
 	if err != nil {
-		return nil, fmt.Errorf("序列化请求体模板失败: %w", err)
+		return nil, &TemplateError{Err: fmt.Errorf("序列化请求体模板失败: %w", err)}
 	}
 
 	if err := c.templateEngine.AddTemplate(templateID, string(bodyTemplate)); err != nil {
-		return nil, fmt.Errorf("添加请求体模板失败: %w", err)
+		return nil, &TemplateError{Err: fmt.Errorf("添加请求体模板失败: %w", err)}
 	}
 
 	// 渲染请求体
-	renderedBody, err := c.templateEngine.RenderJSONTemplate(templateID, data)
+	renderedBody, err := c.renderBody(templateID, data)
 	if err != nil {
-		return nil, fmt.Errorf("渲染请求体失败: %w", err)
+		return nil, &TemplateError{Err: fmt.Errorf("渲染请求体失败: %w", err)}
 	}
 
+	// 数组字段超过SetAutoBatchBody设置的上限时拆分为多个请求
+	batches, err := c.splitBatchBody(renderedBody)
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) > 1 {
+		return c.executeBatches(ctx, &tmplDef, templateID, batches, data)
+	}
+
+	return c.executeRendered(ctx, &tmplDef, templateID, renderedBody, data)
+}
+
+// executeRendered 使用已渲染的请求体发送模板请求，处理请求头、钩子、缓存和重试
+func (c *Client) executeRendered(ctx context.Context, tmplDef *templateDefinition, templateID string, renderedBody []byte, data interface{}) (*http.Response, error) {
 	// 确定URL和路径
 	baseURL := c.baseURL
 	if tmplDef.Request.BaseURL != "" {
@@ -201,32 +374,67 @@ func (c *Client) ExecuteTemplateJSON(ctx context.Context, templateJSON string, d
 		headers[k] = v
 	}
 
+	// 声明了文件字段时，请求体字段作为表单字段，文件以流式方式上传
+	var body io.Reader = bytes.NewReader(renderedBody)
+	var multipartContentType string
+	if len(tmplDef.Files) > 0 {
+		files, err := c.renderFilePaths(templateID, tmplDef.Files, data)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(renderedBody, &fields); err != nil {
+			return nil, &TemplateError{Err: fmt.Errorf("解析表单字段失败: %w", err)}
+		}
+		mb, err := newMultipartBody(fields, files)
+		if err != nil {
+			return nil, err
+		}
+		body = mb
+		multipartContentType = mb.ContentType()
+	}
+
 	// 创建请求对象
 	req, err := http.NewRequestWithContext(
 		ctx,
 		method,
 		baseURL+tmplDef.Request.Path,
-		bytes.NewReader(renderedBody),
+		body,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("创建HTTP请求失败: %w", err)
+		return nil, utils.WrapSentinel(ErrRequestFailed, fmt.Errorf("创建HTTP请求失败: %w", err))
 	}
 
 	// 设置请求头
 	for key, value := range headers {
 		// 使用模板引擎渲染头部值
 		if err := c.templateEngine.AddTemplate(templateID+"_header_"+key, value); err != nil {
-			return nil, fmt.Errorf("添加头部模板失败: %w", err)
+			return nil, &TemplateError{Err: fmt.Errorf("添加头部模板失败: %w", err)}
 		}
 		renderedValue, err := c.templateEngine.Execute(templateID+"_header_"+key, data)
 		if err != nil {
-			return nil, fmt.Errorf("渲染请求头值失败: %w", err)
+			return nil, &TemplateError{Err: fmt.Errorf("渲染请求头值失败: %w", err)}
 		}
 		req.Header.Set(key, renderedValue)
 	}
+	if c.hostHeader != "" {
+		req.Host = c.hostHeader
+	}
+	c.applyDefaultQuery(req)
+	if tmplDef.Request.HeadersExpr != "" {
+		dynamic, err := c.renderHeadersExpr(templateID, tmplDef.Request.HeadersExpr, data)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range dynamic {
+			req.Header.Set(key, value)
+		}
+	}
 
-	// 设置Content-Type（如果未指定）
-	if req.Header.Get("Content-Type") == "" && (method == "POST" || method == "PUT" || method == "PATCH") {
+	// 设置Content-Type（multipart必须使用生成的boundary，其他情况仅在未指定时设置）
+	if multipartContentType != "" {
+		req.Header.Set("Content-Type", multipartContentType)
+	} else if req.Header.Get("Content-Type") == "" && (method == "POST" || method == "PUT" || method == "PATCH") {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
@@ -234,28 +442,27 @@ func (c *Client) ExecuteTemplateJSON(ctx context.Context, templateJSON string, d
 	for _, hookDef := range tmplDef.BeforeHooks {
 		hook, err := hooks.CreateHookFromDefinition(&hookDef)
 		if err != nil {
-			return nil, fmt.Errorf("创建请求前钩子失败: %w", err)
+			return nil, hookError(fmt.Errorf("创建请求前钩子失败: %w", err))
 		}
+		c.injectLogger(hook)
 
 		// 根据接口类型添加钩子
 		beforeHook, ok := hook.(hooks.BeforeRequestHook)
 		if !ok {
-			return nil, fmt.Errorf("钩子类型不是请求前钩子: %T", hook)
+			return nil, hookError(fmt.Errorf("钩子类型不是请求前钩子: %T", hook))
 		}
 
 		// 执行请求前钩子
-		req, err = beforeHook.Before(req)
+		req, err = callBeforeHook(req, beforeHook)
 		if err != nil {
-			return nil, fmt.Errorf("执行请求前钩子失败: %w", err)
+			return nil, hookError(fmt.Errorf("执行请求前钩子失败: %w", err))
 		}
 	}
 
 	// 应用全局钩子（在模板钩子之后应用，可以覆盖模板钩子的设置）
-	for _, hook := range c.beforeHook {
-		req, err = hook.Before(req)
-		if err != nil {
-			return nil, fmt.Errorf("执行请求前钩子失败: %w", err)
-		}
+	req, err = c.applyBeforeHooks(req, c.beforeHook)
+	if err != nil {
+		return nil, hookError(fmt.Errorf("执行请求前钩子失败: %w", err))
 	}
 
 	// 设置超时
@@ -264,99 +471,123 @@ func (c *Client) ExecuteTemplateJSON(ctx context.Context, templateJSON string, d
 		clientCopy.Timeout = time.Duration(tmplDef.Request.Timeout) * time.Second
 	}
 
+	start := time.Now()
+
+	// 模板没有缓存和重试配置时使用客户端的默认配置
+	caching, retry := c.defaultCaching, c.defaultRetry
+	if tmplDef.Caching != nil {
+		caching = *tmplDef.Caching
+	}
+	if tmplDef.Retry != nil {
+		retry = *tmplDef.Retry
+	}
+
 	// 处理缓存逻辑
-	if tmplDef.Caching.Enabled {
-		// 读取请求体
-		var reqBodyBytes []byte
-		if req.Body != nil {
-			reqBodyBytes, _ = hooks.ReadRequestBody(req)
-			// 重新设置请求体
-			req.Body = io.NopCloser(bytes.NewReader(reqBodyBytes))
+	var cacheKey string
+	var stale *CachedResponse
+	if caching.Enabled {
+		// 生成缓存键，设置了keyPattern时使用渲染结果，否则使用请求URL和请求体
+		cacheKey, err = c.renderCacheKey(templateID, caching.KeyPattern, data)
+		if err != nil {
+			return nil, err
 		}
-
-		// 生成缓存键
-		cacheKey := tmplDef.Caching.KeyPattern
 		if cacheKey == "" {
-			// 使用请求URL和正文作为缓存键
-			cacheKey = req.URL.String()
-			if len(reqBodyBytes) > 0 {
-				bodyHash := fmt.Sprintf("%x", sha256.Sum256(reqBodyBytes))
-				cacheKey = cacheKey + ":" + bodyHash
-			}
-		} else {
-			// 使用模板渲染缓存键模式
-			renderedKey, err := c.templateEngine.Execute(templateID+"_cache_key", data)
-			if err == nil && renderedKey != "" {
-				cacheKey = renderedKey
+			// 读取请求体，发送前保留一份用于生成缓存键
+			var reqBodyBytes []byte
+			if req.Body != nil {
+				reqBodyBytes, _ = hooks.ReadRequestBody(req)
+				// 重新设置请求体
+				req.Body = io.NopCloser(bytes.NewReader(reqBodyBytes))
 			}
+			cacheKey = c.generateCacheKey(req, reqBodyBytes)
 		}
 
 		// 检查缓存
-		cachedResp, cachedBody, found := c.getFromCache(req, reqBodyBytes)
+		cachedResp, found := c.getFromCache(req, cacheKey)
 		if found {
-			// 重新设置响应体
-			cachedResp.Body = io.NopCloser(bytes.NewReader(cachedBody))
-
 			// 应用响应后钩子
-			for _, hook := range c.afterHook {
-				cachedResp, err = hook.After(cachedResp)
-				if err != nil {
-					return nil, fmt.Errorf("执行响应后钩子失败: %w", err)
-				}
+			cachedResp, err = c.applyAfterHooks(cachedResp, c.afterHook)
+			c.recordMetrics(req, cachedResp, start, true, 0, err)
+			if err != nil {
+				return nil, hookError(fmt.Errorf("执行响应后钩子失败: %w", err))
+			}
+			if err := c.validateResponse(cachedResp); err != nil {
+				return nil, fmt.Errorf("响应校验失败: %w", err)
 			}
 			return cachedResp, nil
 		}
+
+		// 缓存已过期但带有验证器时发送条件请求
+		if stale = c.getRevalidationEntry(cacheKey); stale != nil {
+			stale.setConditionalHeaders(req)
+		}
 	}
 
 	// 发送请求并处理重试逻辑
 	var resp *http.Response
-	if tmplDef.Retry.Enabled && tmplDef.Retry.MaxAttempts > 0 {
-		resp, err = c.doWithRetry(req, &clientCopy, tmplDef.Retry.MaxAttempts,
-			tmplDef.Retry.InitialDelay, tmplDef.Retry.BackoffFactor)
+	var retries int
+	if retry.Enabled && retry.MaxAttempts > 0 {
+		retryOnBody := c.retryPolicy.RetryOnBody
+		if retry.RetryOnBody != nil {
+			retryOnBody = retry.RetryOnBody.Match
+		}
+		resp, retries, err = c.doWithRetry(req, &clientCopy, retry.MaxAttempts,
+			retry.InitialDelay, retry.BackoffFactor,
+			time.Duration(retry.MaxElapsed)*time.Millisecond, retryOnBody)
 	} else {
-		resp, err = clientCopy.Do(req)
+		resp, err = c.send(&clientCopy, req)
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("发送HTTP请求失败: %w", err)
+		c.recordMetrics(req, nil, start, false, retries, err)
+		return nil, transportError(req, fmt.Errorf("发送HTTP请求失败: %w", err))
+	}
+
+	// 304表示缓存内容仍然有效，使用缓存的响应体，随后按正常流程刷新缓存有效期
+	cacheHit := false
+	if stale != nil && resp.StatusCode == http.StatusNotModified {
+		closeResponseBody(resp)
+		resp = stale.notModifiedResponse(req, resp)
+		cacheHit = true
 	}
 
 	// 处理模板中定义的后置钩子
+	afterHooks := make([]hooks.AfterResponseHook, 0, len(tmplDef.AfterHooks)+len(c.afterHook))
 	for _, hookDef := range tmplDef.AfterHooks {
 		hook, err := hooks.CreateHookFromDefinition(&hookDef)
 		if err != nil {
-			return nil, fmt.Errorf("创建响应后钩子失败: %w", err)
+			resp.Body.Close()
+			return nil, hookError(fmt.Errorf("创建响应后钩子失败: %w", err))
 		}
+		c.injectLogger(hook)
 
 		// 根据接口类型添加钩子
 		afterHook, ok := hook.(hooks.AfterResponseHook)
 		if !ok {
-			return nil, fmt.Errorf("钩子类型不是响应后钩子: %T", hook)
+			resp.Body.Close()
+			return nil, hookError(fmt.Errorf("钩子类型不是响应后钩子: %T", hook))
 		}
+		afterHooks = append(afterHooks, afterHook)
+	}
 
-		// 执行响应后钩子
-		resp, err = afterHook.After(resp)
-		if err != nil {
-			return nil, fmt.Errorf("执行响应后钩子失败: %w", err)
-		}
+	// 执行模板钩子后再应用全局响应后钩子
+	afterHooks = append(afterHooks, c.afterHook...)
+	resp, err = c.applyAfterHooks(resp, afterHooks)
+	c.recordMetrics(req, resp, start, cacheHit, retries, err)
+	if err != nil {
+		return nil, hookError(fmt.Errorf("执行响应后钩子失败: %w", err))
 	}
 
-	// 应用全局响应后钩子
-	for _, hook := range c.afterHook {
-		resp, err = hook.After(resp)
-		if err != nil {
-			return nil, fmt.Errorf("执行响应后钩子失败: %w", err)
-		}
+	// 校验未通过的响应不会被缓存
+	if err := c.validateResponse(resp); err != nil {
+		return nil, fmt.Errorf("响应校验失败: %w", err)
+	}
+	if err := c.checkStatus(resp); err != nil {
+		return nil, err
 	}
 
 	// 处理缓存保存
-	if tmplDef.Caching.Enabled && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		// 读取请求体
-		var reqBodyBytes []byte
-		if req.Body != nil {
-			reqBodyBytes, _ = hooks.ReadRequestBody(req)
-		}
-
+	if caching.Enabled && resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		// 读取响应体
 		respBodyBytes, err := ReadResponseBody(resp)
 		if err == nil {
@@ -364,18 +595,21 @@ func (c *Client) ExecuteTemplateJSON(ctx context.Context, templateJSON string, d
 			resp.Body = io.NopCloser(bytes.NewReader(respBodyBytes))
 
 			// 保存到缓存
-			c.saveToCache(req, reqBodyBytes, resp, respBodyBytes, time.Duration(tmplDef.Caching.TTL)*time.Second)
+			c.saveToCache(cacheKey, resp, respBodyBytes, time.Duration(caching.TTL)*time.Second)
 		}
 	}
 
 	return resp, nil
 }
 
-// doWithRetry 执行带有重试逻辑的请求
-func (c *Client) doWithRetry(req *http.Request, client *http.Client, maxAttempts, initialDelay, backoffFactor int) (*http.Response, error) {
+// doWithRetry 执行带有重试逻辑的请求，同时返回实际发生的重试次数
+// maxElapsed>0时，下一次重试的开始时间会超过从第一次请求起算的时间上限时不再重试；
+// 请求上下文设置了截止时间时同样不会在截止后重试。此时返回最后一次的响应或错误。
+// retryOnBody不为nil时，响应体满足该条件的响应同样会重试
+func (c *Client) doWithRetry(req *http.Request, client *http.Client, maxAttempts, initialDelay, backoffFactor int, maxElapsed time.Duration, retryOnBody func([]byte) bool) (*http.Response, int, error) {
 	var resp *http.Response
 	var err error
-	delay := initialDelay
+	start := time.Now()
 
 	// 如果没有设置适当的值，使用默认值
 	if maxAttempts <= 0 {
@@ -387,30 +621,56 @@ func (c *Client) doWithRetry(req *http.Request, client *http.Client, maxAttempts
 	if backoffFactor <= 0 {
 		backoffFactor = 2
 	}
+	delay := initialDelay
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		// 创建请求体的副本
 		reqCopy := c.cloneRequest(req)
-		resp, err = client.Do(reqCopy)
+		resp, err = c.send(client, reqCopy)
 
-		// 成功或不可恢复的错误，直接返回
-		if err == nil || !c.isRetryableError(err) {
-			return resp, err
+		// 成功或不满足重试条件，直接返回
+		if !c.shouldRetry(req, resp, err, retryOnBody) {
+			return resp, attempt, err
 		}
 
-		// 最后一次尝试失败，直接返回错误
+		// 最后一次尝试失败，返回错误或最后一次的响应
 		if attempt == maxAttempts-1 {
-			return nil, fmt.Errorf("最大重试次数(%d)已用尽: %w", maxAttempts, err)
+			if err != nil {
+				return nil, attempt, fmt.Errorf("最大重试次数(%d)已用尽: %w", maxAttempts, err)
+			}
+			return resp, attempt, nil
+		}
+
+		// 等待后会超过时间上限，不再重试
+		if reason := retryBudgetExceeded(req.Context(), start, maxElapsed, time.Duration(delay)*time.Millisecond); reason != "" {
+			c.log().Debugf("请求 %s %s 的%s，停止重试", req.Method, req.URL, reason)
+			if err != nil {
+				return nil, attempt, fmt.Errorf("%s，已尝试%d次: %w", reason, attempt+1, err)
+			}
+			return resp, attempt, nil
 		}
 
-		// 等待一段时间后重试
-		time.Sleep(time.Duration(delay) * time.Millisecond)
+		// 丢弃需要重试的响应
+		closeResponseBody(resp)
+		if err != nil {
+			c.log().Debugf("请求 %s %s 失败，%dms后第%d次重试: %v", req.Method, req.URL, delay, attempt+1, err)
+		} else {
+			c.log().Debugf("请求 %s %s 返回状态码 %d，%dms后第%d次重试", req.Method, req.URL, resp.StatusCode, delay, attempt+1)
+		}
+
+		// 等待一段时间后重试，等待期间请求被取消时立即返回
+		if waitErr := sleepContext(req.Context(), time.Duration(delay)*time.Millisecond); waitErr != nil {
+			if err != nil {
+				return nil, attempt, fmt.Errorf("等待重试时请求已取消，已尝试%d次: %w（上次错误: %w）", attempt+1, waitErr, err)
+			}
+			return nil, attempt, fmt.Errorf("等待重试时请求已取消，已尝试%d次: %w", attempt+1, waitErr)
+		}
 
 		// 计算下一次延迟（指数退避）
 		delay *= backoffFactor
 	}
 
-	return resp, err
+	return resp, maxAttempts - 1, err
 }
 
 // cloneRequest 创建请求的深度副本
@@ -437,70 +697,104 @@ func (c *Client) cloneRequest(req *http.Request) *http.Request {
 	return reqCopy
 }
 
-// isRetryableError 判断错误是否可重试
-func (c *Client) isRetryableError(err error) bool {
-	// 网络连接错误通常是可重试的
+// Request 发送HTTP请求
+func (c *Client) Request(method, path string, body []byte) (*http.Response, error) {
+	req, err := c.newRequest(method, path, body)
 	if err != nil {
-		// 检查常见的临时网络错误
-		// 这些错误通常是因为网络故障、服务器过载等暂时性问题
-		errMsg := err.Error()
-
-		// 常见的可重试错误模式
-		retryablePatterns := []string{
-			"connection refused",
-			"connection reset",
-			"timeout",
-			"temporary failure",
-			"EOF",
-			"i/o timeout",
-			"too many open files",
-			"no such host",
-		}
+		return nil, err
+	}
+	return c.do(req)
+}
 
-		for _, pattern := range retryablePatterns {
-			if strings.Contains(strings.ToLower(errMsg), pattern) {
-				return true
-			}
-		}
+// RequestWithTimeout 发送HTTP请求，使用单独的超时时间
+// 超时通过请求上下文实现，不会修改客户端共享的http.Client；
+// 超时时间覆盖读取响应体的过程，调用方关闭响应体后释放相关资源
+func (c *Client) RequestWithTimeout(method, path string, body []byte, timeout time.Duration) (*http.Response, error) {
+	req, err := c.newRequest(method, path, body)
+	if err != nil {
+		return nil, err
 	}
 
-	return false
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := c.do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.Body == nil {
+		cancel()
+		return resp, nil
+	}
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
 }
 
-// Request 发送HTTP请求
-func (c *Client) Request(method, path string, body []byte) (*http.Response, error) {
+// cancelOnCloseBody 关闭响应体时取消对应的上下文
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close 关闭响应体并取消上下文
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// newRequest 创建带有客户端默认请求头的请求
+func (c *Client) newRequest(method, path string, body []byte) (*http.Request, error) {
 	url := c.baseURL + path
 	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return nil, utils.WrapSentinel(ErrRequestFailed, fmt.Errorf("创建请求失败: %w", err))
 	}
 
 	// 设置请求头
-	for key, value := range c.headers {
-		req.Header.Set(key, value)
+	if err := c.setDefaultHeaders(req); err != nil {
+		return nil, err
 	}
 
-	// 执行前置钩子
-	for _, hook := range c.beforeHook {
-		req, err = hook.Before(req)
-		if err != nil {
-			return nil, fmt.Errorf("前置钩子执行失败: %w", err)
+	// 声明了JSON类型却没有请求体时发送{}，避免严格的服务端拒绝空请求体
+	if body == nil && !c.keepNilBody && hasJSONBodyMethod(method) && isJSONContentType(req.Header.Get("Content-Type")) {
+		emptyObject := []byte("{}")
+		req.Body = io.NopCloser(bytes.NewReader(emptyObject))
+		req.ContentLength = int64(len(emptyObject))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(emptyObject)), nil
 		}
 	}
+	return req, nil
+}
+
+// do 执行全局钩子、发送请求并校验响应
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	// 执行前置钩子
+	req, err := c.applyBeforeHooks(req, c.beforeHook)
+	if err != nil {
+		return nil, hookError(fmt.Errorf("前置钩子执行失败: %w", err))
+	}
 
 	// 发送请求
-	resp, err := c.client.Do(req)
+	start := time.Now()
+	resp, err := c.send(c.client, req)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		c.recordMetrics(req, nil, start, false, 0, err)
+		return nil, transportError(req, fmt.Errorf("请求失败: %w", err))
 	}
 
 	// 执行后置钩子
-	for _, hook := range c.afterHook {
-		resp, err = hook.After(resp)
-		if err != nil {
-			resp.Body.Close()
-			return nil, fmt.Errorf("后置钩子执行失败: %w", err)
-		}
+	resp, err = c.applyAfterHooks(resp, c.afterHook)
+	c.recordMetrics(req, resp, start, false, 0, err)
+	if err != nil {
+		return nil, hookError(fmt.Errorf("后置钩子执行失败: %w", err))
+	}
+
+	if err := c.validateResponse(resp); err != nil {
+		return nil, fmt.Errorf("响应校验失败: %w", err)
+	}
+	if err := c.checkStatus(resp); err != nil {
+		return nil, err
 	}
 
 	return resp, nil
@@ -521,12 +815,19 @@ func (c *Client) Put(path string, body []byte) (*http.Response, error) {
 	return c.Request(http.MethodPut, path, body)
 }
 
+// Patch 发送PATCH请求
+func (c *Client) Patch(path string, body []byte) (*http.Response, error) {
+	return c.Request(http.MethodPatch, path, body)
+}
+
 // Delete 发送DELETE请求
 func (c *Client) Delete(path string) (*http.Response, error) {
 	return c.Request(http.MethodDelete, path, nil)
 }
 
-// ReadResponseBody 读取响应主体
+// ReadResponseBody 读取并关闭响应主体
+// 客户端返回的响应（包括响应后钩子替换的和来自缓存的响应）在设置了WithMaxResponseBytes时受该上限约束，
+// 超限会返回ErrResponseTooLarge；其他来源的响应会被完整读取
 func ReadResponseBody(resp *http.Response) ([]byte, error) {
 	defer resp.Body.Close()
 	return io.ReadAll(resp.Body)
@@ -537,6 +838,13 @@ type Response struct {
 	StatusCode int
 	Headers    map[string]string
 	Body       []byte
+	RawBody    []byte // 响应后钩子处理前的原始响应体，仅在启用SetKeepOriginalResponse时设置
+
+	// 产生该响应的请求，用于Resend重新发送
+	RequestMethod  string
+	RequestURL     string
+	RequestHeaders http.Header
+	RequestBody    []byte
 }
 
 // NewResponseFromHTTP 从http.Response创建Response
@@ -553,11 +861,16 @@ func NewResponseFromHTTP(resp *http.Response) (*Response, error) {
 		}
 	}
 
-	return &Response{
+	raw, _ := RawBody(resp)
+
+	result := &Response{
 		StatusCode: resp.StatusCode,
 		Headers:    headers,
 		Body:       body,
-	}, nil
+		RawBody:    raw,
+	}
+	captureRequest(result, resp.Request)
+	return result, nil
 }
 
 // String 返回响应体的字符串表示
@@ -565,6 +878,24 @@ func (r *Response) String() string {
 	return string(r.Body)
 }
 
+// Get 按JSONPath表达式（如$.data[0].id）从JSON响应体中取值
+// 支持$、.key、['key']、[n]（负数从末尾计数）和通配符[*]，使用通配符时返回所有匹配值组成的数组；
+// 数字以json.Number返回以保留精度。响应体不是JSON、表达式无效或节点不存在时返回错误
+func (r *Response) Get(path string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(r.Body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, &DecodeError{Format: "JSON", Err: utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("解析响应JSON失败: %w", err))}
+	}
+
+	value, err := utils.JSONPath(data, path)
+	if err != nil {
+		return nil, fmt.Errorf("提取%s失败: %w", path, err)
+	}
+	return value, nil
+}
+
 // JSON 返回响应体的格式化JSON字符串
 func (r *Response) JSON() (string, error) {
 	var data interface{}
@@ -579,47 +910,3 @@ func (r *Response) JSON() (string, error) {
 
 	return string(formattedJSON), nil
 }
-
-// generateCacheKey 生成缓存键
-func (c *Client) generateCacheKey(req *http.Request, body []byte) string {
-	h := sha256.New()
-	io.WriteString(h, req.URL.String())
-	h.Write(body)
-	return fmt.Sprintf("%x", h.Sum(nil))
-}
-
-// getFromCache 从缓存中获取响应
-func (c *Client) getFromCache(req *http.Request, body []byte) (*http.Response, []byte, bool) {
-	c.cacheMutex.RLock()
-	defer c.cacheMutex.RUnlock()
-
-	key := c.generateCacheKey(req, body)
-	if cached, ok := c.cache[key]; ok {
-		if time.Now().Before(cached.ExpireTime) {
-			// 复制响应以确保安全返回
-			respCopy := *cached.Response
-			bodyCopy := make([]byte, len(cached.Body))
-			copy(bodyCopy, cached.Body)
-			return &respCopy, bodyCopy, true
-		}
-		// 缓存已过期，删除
-		delete(c.cache, key)
-	}
-	return nil, nil, false
-}
-
-// saveToCache 保存响应到缓存
-func (c *Client) saveToCache(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, duration time.Duration) {
-	c.cacheMutex.Lock()
-	defer c.cacheMutex.Unlock()
-
-	// 只缓存成功的响应
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		key := c.generateCacheKey(req, reqBody)
-		c.cache[key] = &CachedResponse{
-			Response:   resp,
-			Body:       respBody,
-			ExpireTime: time.Now().Add(duration),
-		}
-	}
-}
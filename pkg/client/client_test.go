@@ -2,17 +2,22 @@ package client
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/birdmichael/RenderAPI/internal/utils"
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
 )
 
 // setupTestServer 创建一个测试HTTP服务器
@@ -367,6 +372,180 @@ func TestTemplateWithFiles(t *testing.T) {
 			t.Errorf("状态不正确，期望: %s, 实际: %v", "success", jsonData["status"])
 		}
 	})
+
+	t.Run("以模板文件名保存响应快照", func(t *testing.T) {
+		snapshotDir := filepath.Join(tempDir, "snapshots")
+		c := NewClient(server.URL, 5*time.Second)
+		c.AddAfterHook(hooks.NewSnapshotHook(snapshotDir))
+
+		resp, err := c.ExecuteTemplateFile(context.Background(), templatePath, nil)
+		if err != nil {
+			t.Fatalf("执行文件模板失败: %v", err)
+		}
+		body, err := ReadResponseBody(resp)
+		if err != nil {
+			t.Fatalf("读取响应失败: %v", err)
+		}
+
+		saved, err := os.ReadFile(filepath.Join(snapshotDir, "test-template.json"))
+		if err != nil {
+			t.Fatalf("应以模板文件名保存快照: %v", err)
+		}
+		var savedData, bodyData interface{}
+		json.Unmarshal(saved, &savedData)
+		json.Unmarshal(body, &bodyData)
+		if !reflect.DeepEqual(savedData, bodyData) {
+			t.Errorf("快照内容与响应体不一致，快照: %s, 响应体: %s", saved, body)
+		}
+	})
+}
+
+// TestTemplateWithDefaults 测试默认值填充缺失的数据字段
+func TestTemplateWithDefaults(t *testing.T) {
+	var body map[string]interface{}
+	var locale string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = r.Header.Get("Accept-Language")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+
+	templateJSON := `{
+		"request": {
+			"method": "POST",
+			"path": "/api/users",
+			"headers": {"Accept-Language": "{{ .locale }}"}
+		},
+		"body": {
+			"name": "{{ .name }}",
+			"role": "{{ .role }}",
+			"city": "{{ .address.city }}",
+			"zip": "{{ .address.zip }}"
+		}
+	}`
+
+	data := map[string]interface{}{
+		"name":    "张三",
+		"address": map[string]interface{}{"city": "上海"},
+	}
+	defaults := map[string]interface{}{
+		"name":    "匿名",
+		"role":    "member",
+		"locale":  "zh-CN",
+		"address": map[string]interface{}{"city": "北京", "zip": "000000"},
+	}
+
+	resp, err := c.ExecuteTemplateWithData(context.Background(), templateJSON, data, defaults)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+
+	expected := map[string]interface{}{
+		"name": "张三",     // 数据优先
+		"role": "member", // 默认值填充
+		"city": "上海",     // 嵌套数据优先
+		"zip":  "000000", // 嵌套默认值填充
+	}
+	for key, value := range expected {
+		if body[key] != value {
+			t.Errorf("字段%s错误，期望: %v, 实际: %v", key, value, body[key])
+		}
+	}
+	if locale != "zh-CN" {
+		t.Errorf("请求头应使用默认值，实际: %s", locale)
+	}
+	if _, ok := data["role"]; ok {
+		t.Error("不应修改传入的数据")
+	}
+}
+
+// TestTemplateArrayBody 测试数组请求体（批量请求）
+func TestTemplateArrayBody(t *testing.T) {
+	var received []map[string]interface{}
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("请求体不是JSON数组: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+
+	templateJSON := `{
+		"request": {"method": "POST", "path": "/api/users/bulk"},
+		"body": [
+			{"name": "{{ .first }}", "role": "admin"},
+			{"name": "{{ .second }}", "role": "member"}
+		]
+	}`
+	data := map[string]interface{}{"first": "张三", "second": "李四"}
+
+	resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(received) != 2 {
+		t.Fatalf("期望收到2个元素，实际: %d", len(received))
+	}
+	if received[0]["name"] != "张三" || received[1]["name"] != "李四" {
+		t.Errorf("数组元素渲染错误: %v", received)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type错误: %s", contentType)
+	}
+
+	// 标量请求体应被拒绝
+	_, err = c.ExecuteTemplateJSON(context.Background(), `{"request": {"method": "POST", "path": "/"}, "body": "text"}`, nil)
+	if err == nil {
+		t.Error("标量请求体应该返回错误")
+	}
+}
+
+// TestRequestWithTimeout 测试单次请求超时不影响客户端超时
+func TestRequestWithTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+
+	// 单次请求超时
+	start := time.Now()
+	if _, err := c.RequestWithTimeout(http.MethodGet, "/slow", nil, 50*time.Millisecond); err == nil {
+		t.Error("超过单次超时时间应返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("单次超时未生效，耗时: %v", elapsed)
+	}
+
+	// 超时时间足够时可以正常读取响应体
+	resp, err := c.RequestWithTimeout(http.MethodGet, "/slow", nil, time.Second)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	body, err := ReadResponseBody(resp)
+	if err != nil || string(body) != `{"status": "ok"}` {
+		t.Errorf("读取响应体失败: %s, %v", body, err)
+	}
+
+	// 客户端超时不受影响
+	resp, err = c.Get("/slow")
+	if err != nil {
+		t.Fatalf("普通请求不应超时: %v", err)
+	}
+	resp.Body.Close()
 }
 
 // TestSetHeader 测试设置请求头
@@ -465,3 +644,297 @@ func TestLoadDataFromFile(t *testing.T) {
 		t.Errorf("嵌套数据内容错误: %v", data["items"])
 	}
 }
+
+// trackingBody 记录是否被关闭的响应体
+type trackingBody struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return b.ReadCloser.Close()
+}
+
+// TestAfterHookSyntheticResponse 测试响应后钩子返回全新的响应
+func TestAfterHookSyntheticResponse(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+
+	// 第一个钩子包装原响应体以便检查是否被关闭
+	var original *trackingBody
+	c.AddAfterHook(&hooks.CustomFunctionHook{
+		AfterFn: func(resp *http.Response) (*http.Response, error) {
+			original = &trackingBody{ReadCloser: resp.Body}
+			resp.Body = original
+			return resp, nil
+		},
+	})
+
+	// 第二个钩子将500响应替换为合成的200响应
+	c.AddAfterHook(&hooks.CustomFunctionHook{
+		AfterFn: func(resp *http.Response) (*http.Response, error) {
+			if resp.StatusCode != http.StatusInternalServerError {
+				return resp, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"status": "mocked"}`)),
+				Request:    resp.Request,
+			}, nil
+		},
+	})
+
+	resp, err := c.Get("/error")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("状态码错误，期望: %d, 实际: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	body, err := ReadResponseBody(resp)
+	if err != nil {
+		t.Fatalf("读取响应体失败: %v", err)
+	}
+	if string(body) != `{"status": "mocked"}` {
+		t.Errorf("响应体错误: %s", string(body))
+	}
+
+	if original == nil || !original.closed {
+		t.Error("被替换的原响应体应该被关闭")
+	}
+}
+
+// TestAfterHookErrorClosesBody 测试响应后钩子失败时关闭响应体
+func TestAfterHookErrorClosesBody(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+
+	var original *trackingBody
+	c.AddAfterHook(&hooks.CustomFunctionHook{
+		AfterFn: func(resp *http.Response) (*http.Response, error) {
+			original = &trackingBody{ReadCloser: resp.Body}
+			resp.Body = original
+			return nil, fmt.Errorf("钩子失败")
+		},
+	})
+
+	if _, err := c.Get("/api/users"); err == nil {
+		t.Fatal("钩子失败时应该返回错误")
+	}
+	if original == nil || !original.closed {
+		t.Error("钩子失败时响应体应该被关闭")
+	}
+}
+
+// headerHook 创建一个设置指定请求头的钩子
+func headerHook(key, value string) *hooks.CustomFunctionHook {
+	return &hooks.CustomFunctionHook{
+		BeforeFn: func(req *http.Request) (*http.Request, error) {
+			req.Header.Add(key, value)
+			return req, nil
+		},
+	}
+}
+
+// TestHookRemoval 测试钩子的删除与查询
+func TestHookRemoval(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.AddBeforeHook(headerHook("X-Order", "first"))
+	c.AddBeforeHook(headerHook("X-Order", "second"))
+	c.AddBeforeHook(headerHook("X-Order", "third"))
+	c.AddAfterHook(&hooks.ResponseLogHook{})
+
+	if c.BeforeHookCount() != 3 || c.AfterHookCount() != 1 {
+		t.Fatalf("钩子数量错误: before=%d, after=%d", c.BeforeHookCount(), c.AfterHookCount())
+	}
+
+	// 删除中间的钩子，其余钩子保持顺序
+	if err := c.RemoveBeforeHookAt(1); err != nil {
+		t.Fatalf("删除钩子失败: %v", err)
+	}
+	if _, err := c.Get("/"); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if got := strings.Join(received.Values("X-Order"), ","); got != "first,third" {
+		t.Errorf("钩子执行顺序错误，期望: first,third, 实际: %s", got)
+	}
+
+	// 越界索引
+	if err := c.RemoveBeforeHookAt(5); err == nil {
+		t.Error("越界索引应该返回错误")
+	}
+
+	// 清除所有钩子
+	c.ClearBeforeHooks()
+	c.ClearAfterHooks()
+	if c.BeforeHookCount() != 0 || c.AfterHookCount() != 0 {
+		t.Fatalf("清除后钩子数量应为0: before=%d, after=%d", c.BeforeHookCount(), c.AfterHookCount())
+	}
+	if _, err := c.Get("/"); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if len(received.Values("X-Order")) != 0 {
+		t.Errorf("清除后钩子不应再执行，实际收到: %v", received.Values("X-Order"))
+	}
+}
+
+// TestHookPriority 测试钩子按优先级执行，相同优先级按添加顺序执行
+func TestHookPriority(t *testing.T) {
+	var received http.Header
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// 对最终的请求体签名
+	signHook := &hooks.CustomFunctionHook{
+		BeforeFn: func(req *http.Request) (*http.Request, error) {
+			body, err := hooks.ReadRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("X-Signature", fmt.Sprintf("%x", sha256.Sum256(body)))
+			return req, nil
+		},
+	}
+
+	// 签名钩子先添加，但优先级更高，应在修改请求体的钩子之后执行
+	c := NewClient(server.URL, 5*time.Second)
+	c.AddBeforeHookPriority(signHook, 100)
+	c.AddBeforeHook(hooks.NewFieldTransformHook(map[string]string{"name": "full_name"}))
+	c.AddBeforeHookPriority(headerHook("X-Order", "early"), -10)
+
+	resp, err := c.Post("/users", []byte(`{"name": "张三"}`))
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.Contains(string(receivedBody), "full_name") {
+		t.Fatalf("请求体应被转换: %s", receivedBody)
+	}
+	if expected := fmt.Sprintf("%x", sha256.Sum256(receivedBody)); received.Get("X-Signature") != expected {
+		t.Errorf("签名应基于修改后的请求体, 期望: %s, 实际: %s", expected, received.Get("X-Signature"))
+	}
+
+	// 删除钩子的索引对应执行顺序：优先级为-10的钩子位于第一个
+	if err := c.RemoveBeforeHookAt(0); err != nil {
+		t.Fatalf("删除钩子失败: %v", err)
+	}
+	if _, err := c.Post("/users", []byte(`{}`)); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if received.Get("X-Order") != "" || received.Get("X-Signature") == "" {
+		t.Errorf("应删除优先级最低的钩子: %v", received)
+	}
+
+	// 相同优先级按添加顺序执行，不同优先级按优先级执行
+	ordered := NewClient(server.URL, 5*time.Second)
+	ordered.AddBeforeHookPriority(headerHook("X-Order", "c"), 5)
+	ordered.AddBeforeHookPriority(headerHook("X-Order", "a"), 1)
+	ordered.AddBeforeHookPriority(headerHook("X-Order", "d"), 5)
+	ordered.AddBeforeHookPriority(headerHook("X-Order", "b"), 1)
+	ordered.AddBeforeHook(headerHook("X-Order", "default"))
+
+	// 克隆保留优先级，之后添加的钩子仍按优先级插入
+	clone := ordered.Clone()
+	clone.AddBeforeHookPriority(headerHook("X-Order", "e"), 3)
+
+	for _, tt := range []struct {
+		c        *Client
+		expected string
+	}{
+		{ordered, "default,a,b,c,d"},
+		{clone, "default,a,b,e,c,d"},
+	} {
+		if _, err := tt.c.Get("/"); err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		if got := strings.Join(received.Values("X-Order"), ","); got != tt.expected {
+			t.Errorf("钩子执行顺序错误，期望: %s, 实际: %s", tt.expected, got)
+		}
+	}
+
+	// 响应后钩子同样按优先级执行
+	var afterOrder []string
+	afterHook := func(name string) *hooks.CustomFunctionHook {
+		return hooks.NewCustomFunctionHook(nil, func(resp *http.Response) (*http.Response, error) {
+			afterOrder = append(afterOrder, name)
+			return resp, nil
+		})
+	}
+	after := NewClient(server.URL, 5*time.Second)
+	after.AddAfterHookPriority(afterHook("decompress"), -1)
+	after.AddAfterHookPriority(afterHook("log"), 10)
+	after.AddAfterHook(afterHook("transform"))
+	if _, err := after.Get("/"); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if got := strings.Join(afterOrder, ","); got != "decompress,transform,log" {
+		t.Errorf("响应后钩子执行顺序错误: %s", got)
+	}
+}
+
+// TestResponseGet 测试按JSONPath从响应体中取值
+func TestResponseGet(t *testing.T) {
+	resp := &Response{Body: []byte(`{
+		"data": [
+			{"id": 9007199254740993, "user": {"name": "张三", "tags": ["admin", "dev"]}},
+			{"id": 2, "user": {"name": "李四"}}
+		],
+		"meta": {"total": 2, "page.size": 20}
+	}`)}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"$.data[0].id", "9007199254740993"},
+		{"$.data[0].user.name", "张三"},
+		{"$.data[0].user.tags[1]", "dev"},
+		{"$.data[-1].user.name", "李四"},
+		{"$['meta']['page.size']", "20"},
+		{"$.data[*].user.name", "[张三 李四]"},
+		{"$.data[*].user.tags[0]", "[admin]"},
+		{"$.meta.*", "[20 2]"},
+	}
+	for _, tt := range tests {
+		value, err := resp.Get(tt.path)
+		if err != nil {
+			t.Errorf("提取%s失败: %v", tt.path, err)
+			continue
+		}
+		if actual := fmt.Sprint(value); actual != tt.expected {
+			t.Errorf("提取%s错误，期望: %s, 实际: %s", tt.path, tt.expected, actual)
+		}
+	}
+
+	// 节点不存在或表达式无效时返回错误
+	for _, path := range []string{"$.data[0].missing", "$.data[5]", "$.meta[0]", "data.id", "$.data[x]", "$..id", "$.data[0"} {
+		if _, err := resp.Get(path); err == nil {
+			t.Errorf("%s 应返回错误", path)
+		}
+	}
+
+	if _, err := (&Response{Body: []byte("<html>")}).Get("$.id"); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("响应体不是JSON时应返回ErrInvalidJSON，实际: %v", err)
+	}
+}
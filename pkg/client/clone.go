@@ -0,0 +1,98 @@
+package client
+
+import "github.com/birdmichael/RenderAPI/pkg/hooks"
+
+// WithSharedCache 设置Clone得到的客户端是否与原客户端共用响应缓存
+// 默认克隆使用全新的空缓存；共用时双方写入的缓存彼此可见。
+// 缓存键只包含URL和请求体，克隆设置了不同的认证请求头时不应共用缓存
+func WithSharedCache(shared bool) ClientOption {
+	return func(c *Client) {
+		c.shareCache = shared
+	}
+}
+
+// Clone 复制客户端并应用opts，用于以不同的baseURL或额外的请求头派生客户端
+// 默认请求头、默认查询参数、钩子、校验器及其他配置被复制，之后对任一客户端的修改不影响另一个；
+// 底层传输层（连接池）和模板引擎与原客户端共用。缓存默认为全新的内存缓存，
+// 可通过WithSharedCache(true)共用原客户端的缓存，或通过WithCache指定。克隆不继承原客户端的后台清理协程，
+// 设置了清理间隔时会启动自己的协程，需要单独调用Close
+func (c *Client) Clone(opts ...ClientOption) *Client {
+	httpClient := *c.client
+
+	headers := make(map[string]string, len(c.headers))
+	for k, v := range c.headers {
+		headers[k] = v
+	}
+
+	var queryParams map[string]string
+	if c.queryParams != nil {
+		queryParams = make(map[string]string, len(c.queryParams))
+		for k, v := range c.queryParams {
+			queryParams[k] = v
+		}
+	}
+
+	c.tokenMutex.Lock()
+	token, tokenGeneration := c.token, c.tokenGeneration
+	c.tokenMutex.Unlock()
+
+	clone := &Client{
+		client:         &httpClient,
+		baseURL:        c.baseURL,
+		headers:        headers,
+		beforeHook:     append([]hooks.BeforeRequestHook(nil), c.beforeHook...),
+		afterHook:      append([]hooks.AfterResponseHook(nil), c.afterHook...),
+		beforePriority: append([]int(nil), c.beforePriority...),
+		afterPriority:  append([]int(nil), c.afterPriority...),
+		templateEngine: c.templateEngine,
+
+		asyncHooks:           c.asyncHooks,
+		validators:           append([]ResponseValidator(nil), c.validators...),
+		failOnErrorStatus:    c.failOnErrorStatus,
+		failOnGraphQLErrors:  c.failOnGraphQLErrors,
+		healthPath:           c.healthPath,
+		jsonMarshal:          c.jsonMarshal,
+		retryPolicy:          c.retryPolicy,
+		defaultRetry:         c.defaultRetry,
+		defaultCaching:       c.defaultCaching,
+		maxResponseBytes:     c.maxResponseBytes,
+		bodyReadAttempts:     c.bodyReadAttempts,
+		keepOriginalResponse: c.keepOriginalResponse,
+		logger:               c.logger,
+		batchField:           c.batchField,
+		batchMaxItems:        c.batchMaxItems,
+		tokenRefresher:       c.tokenRefresher,
+		templatedHeaders:     c.templatedHeaders,
+		proxyURL:             c.proxyURL,
+		proxyAuth:            c.proxyAuth,
+		hostHeader:           c.hostHeader,
+		keepNilBody:          c.keepNilBody,
+		queryParams:          queryParams,
+
+		token:           token,
+		tokenGeneration: tokenGeneration,
+
+		metricsWriter: c.metricsWriter,
+
+		sweepInterval: c.sweepInterval,
+		closeCh:       make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(clone)
+	}
+
+	// opts中没有通过WithCache指定缓存时，按WithSharedCache决定共用还是新建
+	if clone.cache == nil {
+		clone.cache = NewMemoryCache()
+		if clone.shareCache {
+			clone.cache = c.cache
+		}
+	}
+
+	if clone.sweepInterval > 0 {
+		clone.startCacheSweeper(clone.sweepInterval)
+	}
+
+	return clone
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// TestClone 测试克隆客户端应用覆盖配置且不影响原客户端
+func TestClone(t *testing.T) {
+	type seen struct {
+		path, token, tenant string
+	}
+	var requests []seen
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, seen{r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("X-Tenant")})
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	original := NewClient(server.URL+"/v1", 5*time.Second)
+	original.AddBeforeHook(hooks.NewAuthHook("secret"))
+
+	clone := original.Clone(WithBaseURL(server.URL + "/v2"))
+	clone.SetHeader("X-Tenant", "acme")
+	clone.AddAfterHook(hooks.NewResponseLogHook())
+
+	if clone.client == original.client || clone.client.Transport != original.client.Transport {
+		t.Error("克隆应使用独立的http.Client并共用传输层")
+	}
+	if original.AfterHookCount() != 0 || clone.BeforeHookCount() != 1 {
+		t.Errorf("钩子应被复制且互不影响，原客户端后置钩子%d个，克隆前置钩子%d个", original.AfterHookCount(), clone.BeforeHookCount())
+	}
+
+	for _, c := range []*Client{original, clone} {
+		resp, err := c.Get("/users")
+		if err != nil {
+			t.Fatalf("发送请求失败: %v", err)
+		}
+		closeResponseBody(resp)
+	}
+
+	expected := []seen{
+		{"/v1/users", "Bearer secret", ""},
+		{"/v2/users", "Bearer secret", "acme"},
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("期望%d个请求，实际: %d", len(expected), len(requests))
+	}
+	for i, want := range expected {
+		if requests[i] != want {
+			t.Errorf("第%d个请求期望: %+v，实际: %+v", i+1, want, requests[i])
+		}
+	}
+}
+
+// TestCloneCache 测试克隆默认使用全新缓存，WithSharedCache(true)时共用缓存
+func TestCloneCache(t *testing.T) {
+	original := NewClient("http://example.com", 5*time.Second)
+	req, _ := http.NewRequest("GET", "http://example.com/cached", nil)
+	original.saveToCache("cached", &http.Response{StatusCode: http.StatusOK}, []byte("{}"), time.Minute)
+
+	fresh := original.Clone()
+	if _, ok := fresh.getFromCache(req, "cached"); ok {
+		t.Error("默认克隆不应看到原客户端的缓存")
+	}
+
+	shared := original.Clone(WithSharedCache(true))
+	if _, ok := shared.getFromCache(req, "cached"); !ok {
+		t.Fatal("共用缓存的克隆应命中原客户端的缓存")
+	}
+
+	other, _ := http.NewRequest("GET", "http://example.com/other", nil)
+	shared.saveToCache("other", &http.Response{StatusCode: http.StatusOK}, []byte("{}"), time.Minute)
+	if _, ok := original.getFromCache(other, "other"); !ok {
+		t.Error("共用缓存的克隆写入的缓存应对原客户端可见")
+	}
+	if _, ok := fresh.getFromCache(other, "other"); ok {
+		t.Error("独立缓存的克隆不应看到其他客户端写入的缓存")
+	}
+}
@@ -0,0 +1,12 @@
+package client
+
+import (
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// ToCSV 将JSON对象数组格式的响应体转换为CSV
+// 表头为所有对象键的并集（按键名排序），缺少的键和null输出为空，嵌套的对象或数组输出为JSON；
+// 响应体顶层不是对象数组时返回错误
+func ToCSV(body []byte) ([]byte, error) {
+	return utils.JSONArrayToCSV(body)
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"testing"
+)
+
+// TestToCSV 测试将JSON对象数组转换为CSV
+func TestToCSV(t *testing.T) {
+	body := []byte(`[
+		{"id": 1, "name": "张三", "email": "zhangsan@example.com"},
+		{"id": 2, "name": "李四, Jr.", "active": true},
+		{"id": 9007199254740993, "name": null, "tags": ["a", "b"], "note": "说\"你好\""}
+	]`)
+
+	result, err := ToCSV(body)
+	if err != nil {
+		t.Fatalf("转换CSV失败: %v", err)
+	}
+
+	// 表头为键的并集，缺少的键输出为空，逗号和引号按CSV规则转义
+	expected := "active,email,id,name,note,tags\n" +
+		",zhangsan@example.com,1,张三,,\n" +
+		"true,,2,\"李四, Jr.\",,\n" +
+		",,9007199254740993,,\"说\"\"你好\"\"\",\"[\"\"a\"\",\"\"b\"\"]\"\n"
+	if string(result) != expected {
+		t.Errorf("CSV结果错误，期望:\n%s\n实际:\n%s", expected, result)
+	}
+
+	// 空数组没有表头，输出为空
+	if result, err := ToCSV([]byte(`[]`)); err != nil || len(result) != 0 {
+		t.Errorf("空数组转换结果错误: %q, %v", result, err)
+	}
+
+	for _, invalid := range []string{`{"id": 1}`, `[1, 2]`, `[{"id": 1}, "text"]`, `not json`} {
+		if _, err := ToCSV([]byte(invalid)); err == nil {
+			t.Errorf("%s 不是对象数组，应返回错误", invalid)
+		}
+	}
+}
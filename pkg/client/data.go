@@ -0,0 +1,70 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// MergeData 按顺序深度合并多个模板数据源，后面的数据源优先
+// 两侧都是Map的键递归合并，其他情况（包括数组）整体替换；各数据源都不会被修改
+func MergeData(layers ...map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, layer := range layers {
+		result = utils.MergeDefaults(layer, result)
+	}
+	return result
+}
+
+// LoadDataFiles 依次加载JSON数据文件并按MergeData的规则合并，后面的文件优先
+// 每个文件的顶层必须是JSON对象
+func LoadDataFiles(paths ...string) (map[string]interface{}, error) {
+	layers := make([]map[string]interface{}, 0, len(paths))
+	for _, path := range paths {
+		layer, err := utils.LoadDataFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("加载数据文件%s失败: %w", path, err)
+		}
+		layers = append(layers, layer)
+	}
+	return MergeData(layers...), nil
+}
+
+// ParseSetValues 将key=value形式的覆盖值解析为嵌套Map，key为点分隔路径，如"user.address.city=上海"
+// 值是合法JSON时（数字、布尔值、null、带引号的字符串、数组或对象）按JSON解析，否则作为字符串；
+// 同一路径出现多次时后面的优先
+func ParseSetValues(sets []string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for _, set := range sets {
+		key, raw, ok := strings.Cut(set, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("覆盖值格式错误，应为key=value: %s", set)
+		}
+		segments := strings.Split(key, ".")
+		for _, segment := range segments {
+			if segment == "" {
+				return nil, fmt.Errorf("覆盖值的路径包含空的字段名: %s", key)
+			}
+		}
+
+		var value interface{} = raw
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(raw), &parsed); err == nil {
+			value = parsed
+		}
+
+		node := result
+		for _, segment := range segments[:len(segments)-1] {
+			child, ok := node[segment].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[segment] = child
+			}
+			node = child
+		}
+		node[segments[len(segments)-1]] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDataLayers 测试多个数据源按顺序深度合并，后面的优先
+func TestDataLayers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.json": `{"env": "dev", "user": {"name": "张三", "roles": ["reader"], "address": {"city": "北京", "zip": "100000"}}, "retries": 1}`,
+		"prod.json": `{"env": "prod", "user": {"roles": ["admin"], "address": {"city": "上海"}}}`,
+	}
+	var paths []string
+	for _, name := range []string{"base.json", "prod.json"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			t.Fatalf("写入数据文件失败: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	data, err := LoadDataFiles(paths...)
+	if err != nil {
+		t.Fatalf("加载数据文件失败: %v", err)
+	}
+	sets, err := ParseSetValues([]string{`user.address.zip="200000"`, "retries=3", "debug=true", "user.nickname=007", "user.nickname=小张"})
+	if err != nil {
+		t.Fatalf("解析覆盖值失败: %v", err)
+	}
+	merged := MergeData(data, map[string]interface{}{"user": map[string]interface{}{"name": "李四"}}, sets)
+
+	encoded, _ := json.Marshal(merged)
+	expected := `{"debug":true,"env":"prod","retries":3,"user":{"address":{"city":"上海","zip":"200000"},"name":"李四","nickname":"小张","roles":["admin"]}}`
+	if string(encoded) != expected {
+		t.Errorf("合并结果错误，期望:\n%s\n实际:\n%s", expected, encoded)
+	}
+
+	// 合并不修改各数据源
+	if zip, _ := data["user"].(map[string]interface{})["address"].(map[string]interface{})["zip"]; zip != "100000" {
+		t.Errorf("数据源不应被修改，zip: %v", zip)
+	}
+
+	for _, invalid := range []string{"novalue", "=1", "user..name=1"} {
+		if _, err := ParseSetValues([]string{invalid}); err == nil {
+			t.Errorf("格式错误的覆盖值应返回错误: %s", invalid)
+		}
+	}
+	if _, err := LoadDataFiles(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("数据文件不存在时应返回错误")
+	}
+}
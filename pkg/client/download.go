@@ -0,0 +1,54 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// ProgressFunc 下载进度回调，written为已写入的字节数，total为响应的Content-Length，未知时为-1
+type ProgressFunc func(written, total int64)
+
+// SaveResponseBody 将响应体以流式方式直接写入path，不在内存中缓存整个响应体，读取后关闭响应体
+// progress不为nil时每次写入后调用。文件已存在时被覆盖；读取或写入失败时删除不完整的文件。
+// 返回写入的字节数，客户端通过WithMaxResponseBytes设置了上限时，超限会返回ErrResponseTooLarge
+func SaveResponseBody(resp *http.Response, path string, progress ProgressFunc) (int64, error) {
+	defer resp.Body.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("创建文件失败: %w", err)
+	}
+
+	var dst io.Writer = file
+	if progress != nil {
+		dst = &progressWriter{w: file, total: resp.ContentLength, progress: progress}
+	}
+
+	written, err := io.Copy(dst, resp.Body)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("写入文件失败: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(path)
+		return written, err
+	}
+	return written, nil
+}
+
+// progressWriter 写入时报告进度
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress ProgressFunc
+}
+
+// Write 写入数据并调用进度回调
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress(p.written, p.total)
+	return n, err
+}
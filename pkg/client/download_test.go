@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestSaveResponseBody 测试将大响应体流式写入文件并报告进度
+func TestSaveResponseBody(t *testing.T) {
+	const size = 5 << 20
+	payload := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 10*time.Second)
+	resp, err := c.Get("/download")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "download.bin")
+	var calls int
+	var lastWritten, lastTotal int64
+	written, err := SaveResponseBody(resp, path, func(written, total int64) {
+		if written < lastWritten {
+			t.Errorf("进度不应减少: %d < %d", written, lastWritten)
+		}
+		calls++
+		lastWritten, lastTotal = written, total
+	})
+	if err != nil {
+		t.Fatalf("保存响应失败: %v", err)
+	}
+
+	if written != size {
+		t.Errorf("写入的字节数错误: %d", written)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("读取文件信息失败: %v", err)
+	}
+	if info.Size() != size {
+		t.Errorf("文件大小错误: %d", info.Size())
+	}
+	saved, _ := os.ReadFile(path)
+	if !bytes.Equal(saved, payload) {
+		t.Error("文件内容与响应体不一致")
+	}
+
+	// 流式写入时进度被多次报告，最后一次等于Content-Length
+	if calls < 2 {
+		t.Errorf("进度回调次数过少: %d", calls)
+	}
+	if lastWritten != size || lastTotal != size {
+		t.Errorf("最后一次进度错误: %d/%d", lastWritten, lastTotal)
+	}
+
+	// 超过响应大小上限时返回错误并删除不完整的文件
+	limited := NewClient(server.URL, 10*time.Second, WithMaxResponseBytes(1<<20))
+	resp, err = limited.Get("/download")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	partial := filepath.Join(t.TempDir(), "partial.bin")
+	if _, err := SaveResponseBody(resp, partial, nil); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("应返回ErrResponseTooLarge, 实际: %v", err)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("不完整的文件应被删除: %v", err)
+	}
+}
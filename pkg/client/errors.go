@@ -0,0 +1,170 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+	"github.com/birdmichael/RenderAPI/pkg/template"
+)
+
+// ErrResponseTooLarge 响应体超过WithMaxResponseBytes设置的上限
+var ErrResponseTooLarge = hooks.ErrResponseTooLarge
+
+// 哨兵错误，客户端返回的错误会包装这些类别，调用方可以通过errors.Is判断
+var (
+	ErrTemplateNotFound = template.ErrTemplateNotFound // 模板或模板文件不存在
+	ErrInvalidJSON      = template.ErrInvalidJSON      // 模板定义、数据或渲染结果不是有效的JSON
+	ErrRequestFailed    = errors.New("请求失败")           // 创建或发送HTTP请求失败
+	ErrHookFailed       = errors.New("钩子执行失败")         // 创建或执行钩子失败
+)
+
+// TemplateError 读取模板文件或数据文件、解析模板定义或渲染模板失败时返回的错误
+type TemplateError struct {
+	Err error
+}
+
+// Error 返回原有的错误信息
+func (e *TemplateError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// HookError 创建或执行请求前钩子、响应后钩子失败时返回的错误，同时匹配ErrHookFailed
+type HookError struct {
+	Err error
+}
+
+// Error 返回原有的错误信息
+func (e *HookError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// TransportError 发送请求失败（连接失败、超时、重试用尽等）时返回的错误，同时匹配ErrRequestFailed
+type TransportError struct {
+	Method string
+	URL    string
+	Err    error
+}
+
+// Error 返回原有的错误信息
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError 响应体无法按预期的格式解码时返回的错误
+type DecodeError struct {
+	Format string // 预期的格式，如JSON、XML
+	Err    error
+}
+
+// Error 返回原有的错误信息
+func (e *DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// hookError 将钩子相关的错误包装为*HookError
+func hookError(err error) error {
+	return &HookError{Err: utils.WrapSentinel(ErrHookFailed, err)}
+}
+
+// transportError 将发送req失败的错误包装为*TransportError
+func transportError(req *http.Request, err error) error {
+	return &TransportError{Method: req.Method, URL: req.URL.String(), Err: utils.WrapSentinel(ErrRequestFailed, err)}
+}
+
+// HTTPStatusError 响应状态码不是2xx时返回的错误
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	Message    string      // 从响应体中提取的错误信息，仅DecodeOrError设置
+	Detail     interface{} // 错误路径对应的原始值，可能是字符串、对象或数组
+}
+
+// Error 实现error接口
+func (e *HTTPStatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("HTTP请求返回错误状态码: %d, 错误信息: %s", e.StatusCode, e.Message)
+	}
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("HTTP请求返回错误状态码: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP请求返回错误状态码: %d, 响应: %s", e.StatusCode, e.Body)
+}
+
+// checkStatus 启用FailOnErrorStatus时，将非2xx响应转换为*HTTPStatusError并关闭响应体
+func (c *Client) checkStatus(resp *http.Response) error {
+	if !c.failOnErrorStatus || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return nil
+	}
+
+	// 读取失败时仍返回状态码错误，响应体尽力而为
+	body, _ := ReadResponseBody(resp)
+	return &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+	}
+}
+
+// DecodeOrError 解码JSON响应或返回带有错误详情的*HTTPStatusError，读取后关闭响应体
+// 状态码为2xx时将响应体解码到v；否则按errorBodyPath（点分隔路径，如"error.message"）
+// 从响应体中提取错误信息，路径不存在或响应体不是JSON时Message为空
+func DecodeOrError(resp *http.Response, v interface{}, errorBodyPath string) error {
+	body, err := ReadResponseBody(resp)
+	if err != nil {
+		return fmt.Errorf("读取响应体失败: %w", err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if v == nil || len(body) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(body, v); err != nil {
+			return &DecodeError{Format: "JSON", Err: fmt.Errorf("解析响应JSON失败: %w", err)}
+		}
+		return nil
+	}
+
+	statusErr := &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+	}
+
+	var data interface{}
+	if errorBodyPath != "" && json.Unmarshal(body, &data) == nil {
+		if detail, ok := utils.GetPath(data, errorBodyPath); ok {
+			statusErr.Detail = detail
+			if message, ok := detail.(string); ok {
+				statusErr.Message = message
+			} else if encoded, err := json.Marshal(detail); err == nil {
+				statusErr.Message = string(encoded)
+			}
+		}
+	}
+	return statusErr
+}
@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// TestFailOnErrorStatus 测试非2xx响应的处理模式
+func TestFailOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "not found"}`))
+	}))
+	defer server.Close()
+
+	templateJSON := `{"request": {"method": "GET", "path": "/api/users/1"}}`
+
+	// 默认原样返回响应
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+	resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+	if err != nil {
+		t.Fatalf("默认模式不应返回错误: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("状态码错误: %d", resp.StatusCode)
+	}
+
+	// 启用后返回HTTPStatusError
+	strict := NewClient(server.URL, 5*time.Second, FailOnErrorStatus())
+	defer strict.Close()
+	_, err = strict.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("应返回HTTPStatusError, 实际: %v", err)
+	}
+	if statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("错误中的状态码错误: %d", statusErr.StatusCode)
+	}
+	if string(statusErr.Body) != `{"error": "not found"}` {
+		t.Errorf("错误中的响应体错误: %s", statusErr.Body)
+	}
+
+	// 普通请求同样生效
+	if _, err := strict.Get("/"); !errors.As(err, &statusErr) {
+		t.Errorf("Get应返回HTTPStatusError, 实际: %v", err)
+	}
+}
+
+// TestDecodeOrError 测试解码响应或提取错误详情
+func TestDecodeOrError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": 1, "name": "张三"}`))
+		case "/invalid":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"error": {"message": "邮箱格式错误", "fields": [{"name": "email"}]}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`internal error`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+
+	// 成功时解码
+	resp, err := c.Get("/ok")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	var user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := DecodeOrError(resp, &user, "error.message"); err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if user.ID != 1 || user.Name != "张三" {
+		t.Errorf("解码结果错误: %+v", user)
+	}
+
+	// 提取错误信息
+	resp, err = c.Get("/invalid")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	err = DecodeOrError(resp, &user, "error.message")
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("应返回HTTPStatusError, 实际: %v", err)
+	}
+	if statusErr.StatusCode != http.StatusUnprocessableEntity || statusErr.Message != "邮箱格式错误" {
+		t.Errorf("错误详情不正确: %+v", statusErr)
+	}
+
+	// 数组下标路径
+	resp, _ = c.Get("/invalid")
+	err = DecodeOrError(resp, nil, "error.fields.0.name")
+	if !errors.As(err, &statusErr) || statusErr.Message != "email" {
+		t.Errorf("数组路径提取错误: %v", err)
+	}
+
+	// 非JSON错误响应
+	resp, _ = c.Get("/other")
+	err = DecodeOrError(resp, nil, "error.message")
+	if !errors.As(err, &statusErr) || statusErr.Message != "" || string(statusErr.Body) != "internal error" {
+		t.Errorf("非JSON错误响应处理错误: %v", err)
+	}
+}
+
+// TestSentinelErrors 测试返回的错误可以通过errors.Is匹配哨兵错误
+func TestSentinelErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	hookErr := errors.New("签名失败")
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		run      func() error
+		sentinel error
+	}{
+		{"模板文件不存在", func() error {
+			_, err := NewClient(server.URL, 5*time.Second).ExecuteTemplateFile(ctx, "testdata/not-exist.json", nil)
+			return err
+		}, ErrTemplateNotFound},
+		{"模板定义不是有效的JSON", func() error {
+			_, err := NewClient(server.URL, 5*time.Second).ExecuteTemplateJSON(ctx, `{"request": `, nil)
+			return err
+		}, ErrInvalidJSON},
+		{"渲染结果不是有效的JSON", func() error {
+			_, err := NewClient(server.URL, 5*time.Second).ExecuteTemplateJSON(ctx,
+				`{"request": {"method": "POST", "path": "/"}, "body": {"name": "{{ .name }}"}}`,
+				map[string]interface{}{"name": `"`})
+			return err
+		}, ErrInvalidJSON},
+		{"发送请求失败", func() error {
+			_, err := NewClient(closed.URL, 5*time.Second).Get("/")
+			return err
+		}, ErrRequestFailed},
+		{"模板请求发送失败", func() error {
+			_, err := NewClient(closed.URL, 5*time.Second).ExecuteTemplateJSON(ctx,
+				`{"request": {"method": "GET", "path": "/"}}`, nil)
+			return err
+		}, ErrRequestFailed},
+		{"钩子执行失败", func() error {
+			c := NewClient(server.URL, 5*time.Second)
+			c.AddBeforeHook(hooks.NewCustomFunctionHook(func(req *http.Request) (*http.Request, error) {
+				return nil, hookErr
+			}, nil))
+			_, err := c.Get("/")
+			if err != nil && !errors.Is(err, hookErr) {
+				t.Errorf("应同时匹配钩子返回的原始错误: %v", err)
+			}
+			return err
+		}, ErrHookFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if err == nil {
+				t.Fatal("应返回错误")
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) 应为true", err, tt.sentinel)
+			}
+		})
+	}
+}
+
+// TestStructuredErrors 测试可以通过errors.As区分不同阶段的错误，且错误信息保持不变
+func TestStructuredErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	ctx := context.Background()
+
+	// classify 返回错误所属的类别
+	classify := func(err error) string {
+		var templateErr *TemplateError
+		var hookErr *HookError
+		var transportErr *TransportError
+		var decodeErr *DecodeError
+		switch {
+		case errors.As(err, &templateErr):
+			return "template"
+		case errors.As(err, &hookErr):
+			return "hook"
+		case errors.As(err, &transportErr):
+			return "transport"
+		case errors.As(err, &decodeErr):
+			return "decode"
+		}
+		return "unknown"
+	}
+
+	tests := []struct {
+		name    string
+		run     func() error
+		kind    string
+		message string
+	}{
+		{"模板定义不是有效的JSON", func() error {
+			_, err := NewClient(server.URL, 5*time.Second).ExecuteTemplateJSON(ctx, `{"request": `, nil)
+			return err
+		}, "template", "解析模板定义失败: unexpected end of JSON input"},
+		{"模板渲染失败", func() error {
+			_, err := NewClient(server.URL, 5*time.Second).ExecuteTemplateJSON(ctx,
+				`{"request": {"method": "POST", "path": "/"}, "body": {"name": "{{ notExist }}"}}`, nil)
+			return err
+		}, "template", ""},
+		{"模板文件不存在", func() error {
+			_, err := NewClient(server.URL, 5*time.Second).ExecuteTemplateFile(ctx, "testdata/not-exist.json", nil)
+			return err
+		}, "template", ""},
+		{"钩子执行失败", func() error {
+			c := NewClient(server.URL, 5*time.Second)
+			c.AddBeforeHook(hooks.NewCustomFunctionHook(func(req *http.Request) (*http.Request, error) {
+				return nil, errors.New("签名失败")
+			}, nil))
+			_, err := c.Get("/")
+			return err
+		}, "hook", "前置钩子执行失败: 签名失败"},
+		{"发送请求失败", func() error {
+			_, err := NewClient(closed.URL, 5*time.Second).ExecuteTemplateJSON(ctx,
+				`{"request": {"method": "GET", "path": "/"}}`, nil)
+			return err
+		}, "transport", ""},
+		{"响应不是JSON", func() error {
+			resp, err := NewClient(server.URL, 5*time.Second).Get("/")
+			if err != nil {
+				return err
+			}
+			result, err := NewResponseFromHTTP(resp)
+			if err != nil {
+				return err
+			}
+			_, err = result.Get("$.id")
+			return err
+		}, "decode", ""},
+		{"响应不是XML", func() error {
+			resp, err := NewClient(server.URL, 5*time.Second).Get("/")
+			if err != nil {
+				return err
+			}
+			_, err = DecodeXML(resp)
+			return err
+		}, "decode", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if err == nil {
+				t.Fatal("应返回错误")
+			}
+			if kind := classify(err); kind != tt.kind {
+				t.Errorf("错误类别应为%s, 实际: %s (%v)", tt.kind, kind, err)
+			}
+			if tt.message != "" && err.Error() != tt.message {
+				t.Errorf("错误信息不应改变, 实际: %s", err.Error())
+			}
+		})
+	}
+
+	// 类型化的错误仍然匹配原有的哨兵错误，并带有请求信息
+	_, err := NewClient(closed.URL, 5*time.Second).Get("/users")
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) || !errors.Is(err, ErrRequestFailed) {
+		t.Fatalf("应返回匹配ErrRequestFailed的TransportError, 实际: %v", err)
+	}
+	if transportErr.Method != http.MethodGet || transportErr.URL != closed.URL+"/users" {
+		t.Errorf("TransportError中的请求信息错误: %s %s", transportErr.Method, transportErr.URL)
+	}
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileCacheExt 缓存文件的扩展名
+const fileCacheExt = ".cache.gz"
+
+// FileCache 基于本地目录的缓存，进程重启后缓存仍然有效，多个客户端可以共用同一目录
+// 每个条目保存为一个gzip压缩的文件：第一行是JSON格式的元数据（状态码、响应头、过期时间等），
+// 之后是原始响应体。文件名为缓存键的SHA-256，写入时先写临时文件再重命名，读取方不会看到写了一半的文件
+type FileCache struct {
+	MaxStale time.Duration // 可重新验证的文件过期后保留的时间，<=0时使用DefaultMaxStale
+
+	dir string
+}
+
+// fileCacheMeta 缓存文件中的元数据
+type fileCacheMeta struct {
+	Status       string      `json:"status"`
+	StatusCode   int         `json:"statusCode"`
+	Proto        string      `json:"proto"`
+	ProtoMajor   int         `json:"protoMajor"`
+	ProtoMinor   int         `json:"protoMinor"`
+	Header       http.Header `json:"header"`
+	ExpireTime   time.Time   `json:"expireTime"`
+	StoredAt     time.Time   `json:"storedAt"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"lastModified,omitempty"`
+}
+
+// NewFileCache 创建使用dir目录的文件缓存，目录不存在时自动创建
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+// Get 读取缓存条目，文件不存在或已损坏时视为未命中
+func (f *FileCache) Get(key string) (*CachedResponse, bool) {
+	file, err := os.Open(f.path(key))
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	meta, reader, err := readFileCacheMeta(file)
+	if err != nil {
+		return nil, false
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false
+	}
+
+	return &CachedResponse{
+		Status:       meta.Status,
+		StatusCode:   meta.StatusCode,
+		Proto:        meta.Proto,
+		ProtoMajor:   meta.ProtoMajor,
+		ProtoMinor:   meta.ProtoMinor,
+		Header:       meta.Header,
+		Body:         body,
+		ExpireTime:   meta.ExpireTime,
+		StoredAt:     meta.StoredAt,
+		ETag:         meta.ETag,
+		LastModified: meta.LastModified,
+	}, true
+}
+
+// Set 将缓存条目写入文件
+func (f *FileCache) Set(key string, entry *CachedResponse) error {
+	meta, err := json.Marshal(fileCacheMeta{
+		Status:       entry.Status,
+		StatusCode:   entry.StatusCode,
+		Proto:        entry.Proto,
+		ProtoMajor:   entry.ProtoMajor,
+		ProtoMinor:   entry.ProtoMinor,
+		Header:       entry.Header,
+		ExpireTime:   entry.ExpireTime,
+		StoredAt:     entry.StoredAt,
+		ETag:         entry.ETag,
+		LastModified: entry.LastModified,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化缓存元数据失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(f.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建缓存文件失败: %w", err)
+	}
+	// 重命名成功后删除不会有任何效果
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	gz.Write(meta)
+	gz.Write([]byte("\n"))
+	gz.Write(entry.Body)
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		return fmt.Errorf("保存缓存文件失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除缓存条目，条目不存在时不返回错误
+func (f *FileCache) Delete(key string) error {
+	if err := os.Remove(f.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除缓存文件失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired 删除目录中所有已过期或已损坏的缓存文件，带有ETag或Last-Modified的文件在过期后保留MaxStale用于条件请求
+// 只读取元数据而不解压响应体
+func (f *FileCache) DeleteExpired(now time.Time) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileCacheExt) {
+			continue
+		}
+		path := filepath.Join(f.dir, entry.Name())
+		if expired, ok := fileCacheExpired(path, now, f.MaxStale); !ok || expired {
+			os.Remove(path)
+		}
+	}
+}
+
+// path 返回缓存键对应的文件路径
+func (f *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, fmt.Sprintf("%x", sum)+fileCacheExt)
+}
+
+// fileCacheExpired 判断缓存文件能否被清理，ok为false表示文件无法解析
+// 带有ETag或Last-Modified的文件在过期后再保留maxStale，用于条件请求
+func fileCacheExpired(path string, now time.Time, maxStale time.Duration) (expired, ok bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, false
+	}
+	defer file.Close()
+
+	meta, _, err := readFileCacheMeta(file)
+	if err != nil {
+		return false, false
+	}
+	revalidatable := meta.ETag != "" || meta.LastModified != ""
+	return sweepable(now, meta.ExpireTime, revalidatable, maxStale), true
+}
+
+// readFileCacheMeta 解压并解析缓存文件的元数据，返回位于响应体开头的reader
+func readFileCacheMeta(r io.Reader) (*fileCacheMeta, *bufio.Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(gz)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, nil, err
+	}
+	var meta fileCacheMeta
+	if err := json.Unmarshal(line, &meta); err != nil {
+		return nil, nil, err
+	}
+	return &meta, reader, nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GraphQLErrorItem GraphQL响应中errors数组的单个元素
+type GraphQLErrorItem struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLError GraphQL响应包含顶层errors时返回的错误
+type GraphQLError struct {
+	Errors []GraphQLErrorItem
+	Data   json.RawMessage // 部分成功时服务端返回的数据
+}
+
+// Error 实现error接口
+func (e *GraphQLError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, item := range e.Errors {
+		messages = append(messages, item.Message)
+	}
+	return fmt.Sprintf("GraphQL请求返回错误: %s", strings.Join(messages, "; "))
+}
+
+// FailOnGraphQLErrors PostGraphQL的响应包含顶层errors时返回*GraphQLError
+// 默认情况下响应原样返回，由调用方解析errors
+func FailOnGraphQLErrors() ClientOption {
+	return func(c *Client) {
+		c.failOnGraphQLErrors = true
+	}
+}
+
+// graphQLRequest GraphQL请求体
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// PostGraphQL 发送GraphQL请求
+// 请求体为标准的{query, variables}结构，会执行全局钩子和响应校验器
+func (c *Client) PostGraphQL(path, query string, variables map[string]interface{}) (*http.Response, error) {
+	body, err := c.marshalJSON(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return nil, fmt.Errorf("序列化GraphQL请求失败: %w", err)
+	}
+
+	req, err := c.newRequest(http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.failOnGraphQLErrors {
+		if err := checkGraphQLErrors(resp); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// checkGraphQLErrors 检查响应中的顶层errors，没有错误时重置响应体供调用方读取
+func checkGraphQLErrors(resp *http.Response) error {
+	body, err := ReadResponseBody(resp)
+	if err != nil {
+		return fmt.Errorf("读取响应体失败: %w", err)
+	}
+
+	var result struct {
+		Data   json.RawMessage    `json:"data"`
+		Errors []GraphQLErrorItem `json:"errors"`
+	}
+	// 非JSON响应不视为GraphQL错误，交由调用方处理
+	if err := json.Unmarshal(body, &result); err == nil && len(result.Errors) > 0 {
+		return &GraphQLError{Errors: result.Errors, Data: result.Data}
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// setupGraphQLServer 创建模拟GraphQL服务，id为"missing"时返回errors
+func setupGraphQLServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type错误: %s", r.Header.Get("Content-Type"))
+		}
+
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("请求体不是有效的GraphQL请求: %v", err)
+		}
+		if req.Query == "" {
+			t.Error("缺少query字段")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if req.Variables["id"] == "missing" {
+			w.Write([]byte(`{"data": {"user": null}, "errors": [{"message": "用户不存在", "path": ["user"]}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"user": {"id": "1", "name": "张三"}}}`))
+	}))
+}
+
+// TestPostGraphQL 测试GraphQL请求
+func TestPostGraphQL(t *testing.T) {
+	server := setupGraphQLServer(t)
+	defer server.Close()
+
+	query := `query($id: ID!) { user(id: $id) { id name } }`
+
+	c := NewClient(server.URL, 5*time.Second, FailOnGraphQLErrors())
+	defer c.Close()
+
+	// 正常数据
+	resp, err := c.PostGraphQL("/graphql", query, map[string]interface{}{"id": "1"})
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"data": {"user": {"id": "1", "name": "张三"}}}` {
+		t.Errorf("响应体错误: %s", body)
+	}
+
+	// 顶层errors转换为GraphQLError
+	_, err = c.PostGraphQL("/graphql", query, map[string]interface{}{"id": "missing"})
+	var gqlErr *GraphQLError
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("应返回GraphQLError, 实际: %v", err)
+	}
+	if len(gqlErr.Errors) != 1 || gqlErr.Errors[0].Message != "用户不存在" {
+		t.Errorf("GraphQL错误内容不正确: %+v", gqlErr.Errors)
+	}
+
+	// 默认不检查errors
+	plain := NewClient(server.URL, 5*time.Second)
+	defer plain.Close()
+	resp, err = plain.PostGraphQL("/graphql", query, map[string]interface{}{"id": "missing"})
+	if err != nil {
+		t.Fatalf("默认模式不应返回错误: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// headerTemplateSeq 默认请求头临时模板的序号，保证并发渲染时模板名不冲突
+var headerTemplateSeq atomic.Uint64
+
+// WithTemplatedHeaders 发送请求时通过模板引擎渲染SetHeader设置的默认请求头
+// 启用后形如"X-Date: {{ now | formatDate }}"的请求头在每次请求时重新渲染，渲染数据为nil；
+// 不包含模板动作的值按原样设置。模板请求中的默认请求头始终与模板请求头一起按模板数据渲染，不受此选项影响
+func WithTemplatedHeaders() ClientOption {
+	return func(c *Client) {
+		c.templatedHeaders = true
+	}
+}
+
+// SetHostHeader 设置发送的Host请求头，与URL中的主机无关
+// 用于连接IP地址或负载均衡器时按Host路由。Go发送请求时忽略Header中的Host，
+// 因此通过req.Host设置；传入空字符串恢复为URL中的主机。
+// HTTPS请求的TLS SNI和证书校验仍使用URL中的主机名
+func (c *Client) SetHostHeader(host string) {
+	c.hostHeader = host
+}
+
+// setDefaultHeaders 为请求设置客户端的默认请求头、Host和默认查询参数，启用WithTemplatedHeaders时先渲染其中的模板
+func (c *Client) setDefaultHeaders(req *http.Request) error {
+	c.applyDefaultQuery(req)
+	if c.hostHeader != "" {
+		req.Host = c.hostHeader
+	}
+	for key, value := range c.headers {
+		if c.templatedHeaders && c.templateEngine.HasActions(value) {
+			rendered, err := c.renderHeader(key, value)
+			if err != nil {
+				return err
+			}
+			value = rendered
+		}
+		req.Header.Set(key, value)
+	}
+	return nil
+}
+
+// renderHeader 渲染单个默认请求头的值
+func (c *Client) renderHeader(key, value string) (string, error) {
+	templateID := fmt.Sprintf("default_header_%s_%d", key, headerTemplateSeq.Add(1))
+	if err := c.templateEngine.AddTemplate(templateID, value); err != nil {
+		return "", &TemplateError{Err: fmt.Errorf("解析默认请求头%s失败: %w", key, err)}
+	}
+	defer c.templateEngine.RemoveTemplate(templateID)
+
+	rendered, err := c.templateEngine.Execute(templateID, nil)
+	if err != nil {
+		return "", &TemplateError{Err: fmt.Errorf("渲染默认请求头%s失败: %w", key, err)}
+	}
+	return rendered, nil
+}
+
+// renderHeadersExpr 渲染模板中的headersExpr，结果必须是JSON对象或为空
+// 值为null的请求头被忽略，非字符串的值按JSON中的写法转换为字符串
+func (c *Client) renderHeadersExpr(templateID, expr string, data interface{}) (map[string]string, error) {
+	name := templateID + "_headers_expr"
+	if err := c.templateEngine.AddTemplate(name, expr); err != nil {
+		return nil, &TemplateError{Err: fmt.Errorf("解析headersExpr失败: %w", err)}
+	}
+	rendered, err := c.templateEngine.Execute(name, data)
+	if err != nil {
+		return nil, &TemplateError{Err: fmt.Errorf("渲染headersExpr失败: %w", err)}
+	}
+	if strings.TrimSpace(rendered) == "" {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(rendered))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, &TemplateError{Err: utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("headersExpr的渲染结果不是JSON对象: %w", err))}
+	}
+
+	headers := make(map[string]string, len(values))
+	for key, value := range values {
+		switch val := value.(type) {
+		case nil:
+		case string:
+			headers[key] = val
+		case json.Number, bool:
+			headers[key] = fmt.Sprint(val)
+		default:
+			return nil, &TemplateError{Err: fmt.Errorf("请求头%s的值必须是字符串、数字或布尔值: %T", key, value)}
+		}
+	}
+	return headers, nil
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTemplatedHeaders 测试默认请求头在每次请求时按模板渲染
+func TestTemplatedHeaders(t *testing.T) {
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second, WithTemplatedHeaders())
+	seq := 0
+	c.GetTemplateEngine().AddFunc("nextSeq", func() int {
+		seq++
+		return seq
+	})
+	c.SetHeader("X-Date", "{{ now | formatDate }}")
+	c.SetHeader("X-Seq", "req-{{ nextSeq }}")
+	c.SetHeader("X-Literal", "plain value")
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get("/api")
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for i, header := range received {
+		if header.Get("X-Date") != today {
+			t.Errorf("第%d次请求X-Date错误: %s", i+1, header.Get("X-Date"))
+		}
+		if expected := fmt.Sprintf("req-%d", i+1); header.Get("X-Seq") != expected {
+			t.Errorf("第%d次请求应重新渲染X-Seq，期望: %s, 实际: %s", i+1, expected, header.Get("X-Seq"))
+		}
+		if header.Get("X-Literal") != "plain value" {
+			t.Errorf("不含模板动作的请求头应原样设置: %s", header.Get("X-Literal"))
+		}
+	}
+
+	// 模板无效时请求失败
+	c.SetHeader("X-Broken", "{{ .missing")
+	if _, err := c.Get("/api"); err == nil {
+		t.Error("默认请求头模板无效时应返回错误")
+	}
+
+	// 未启用时原样发送
+	received = nil
+	plain := NewClient(server.URL, 5*time.Second)
+	plain.SetHeader("X-Date", "{{ now | formatDate }}")
+	resp, err := plain.Get("/api")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if received[0].Get("X-Date") != "{{ now | formatDate }}" {
+		t.Errorf("未启用时应原样发送: %s", received[0].Get("X-Date"))
+	}
+}
+
+// TestHeadersExpr 测试模板中按条件添加的动态请求头
+func TestHeadersExpr(t *testing.T) {
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+	}))
+	defer server.Close()
+
+	tmpl, err := NewTemplateBuilder().
+		Path("/api").
+		Header("X-Tenant", "default").
+		HeadersExpr(`{{ jsonEncode (merge (headersIf .debug (dict "X-Debug" "1")) (headersIf .tenant (dict "X-Tenant" .tenant "X-Priority" 5))) }}`).
+		Build()
+	if err != nil {
+		t.Fatalf("构建模板失败: %v", err)
+	}
+
+	c := NewClient(server.URL, 5*time.Second)
+	for _, data := range []map[string]interface{}{
+		{"debug": true, "tenant": "acme"},
+		{"debug": false},
+	} {
+		resp, err := c.ExecuteTemplateJSON(context.Background(), tmpl, data)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := received[0]; got.Get("X-Debug") != "1" || got.Get("X-Tenant") != "acme" || got.Get("X-Priority") != "5" {
+		t.Errorf("条件成立时应添加并覆盖请求头，实际: %v", got)
+	}
+	if got := received[1]; got.Get("X-Debug") != "" || got.Get("X-Priority") != "" || got.Get("X-Tenant") != "default" {
+		t.Errorf("条件不成立时不应添加请求头，实际: %v", got)
+	}
+
+	// 渲染结果不是JSON对象时返回错误
+	tmpl, _ = NewTemplateBuilder().Path("/api").HeadersExpr(`{{ .debug }}`).Build()
+	if _, err := c.ExecuteTemplateJSON(context.Background(), tmpl, map[string]interface{}{"debug": true}); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("headersExpr的结果不是JSON对象时应返回ErrInvalidJSON，实际: %v", err)
+	}
+}
+
+// TestSetHostHeader 测试连接服务器地址时发送不同的Host
+func TestSetHostHeader(t *testing.T) {
+	var hosts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+	}))
+	defer server.Close()
+
+	// server.URL是127.0.0.1上的地址
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetHostHeader("api.internal.example")
+
+	resp, err := c.Get("/api")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+
+	tmpl, _ := NewTemplateBuilder().Path("/api").Build()
+	resp, err = c.ExecuteTemplateJSON(context.Background(), tmpl, nil)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+
+	// 恢复为URL中的主机
+	c.SetHostHeader("")
+	resp, err = c.Get("/api")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+
+	expected := []string{"api.internal.example", "api.internal.example", strings.TrimPrefix(server.URL, "http://")}
+	if len(hosts) != len(expected) {
+		t.Fatalf("期望%d个请求，实际: %d", len(expected), len(hosts))
+	}
+	for i, host := range expected {
+		if hosts[i] != host {
+			t.Errorf("第%d个请求的Host期望: %s, 实际: %s", i+1, host, hosts[i])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// defaultHealthPath 默认健康检查路径
+const defaultHealthPath = "/"
+
+// WithHealthPath 设置Ping使用的健康检查路径，默认为"/"
+func WithHealthPath(path string) ClientOption {
+	return func(c *Client) {
+		c.healthPath = path
+	}
+}
+
+// Ping 检查服务是否可达
+// 向基础URL下的健康检查路径发送HEAD请求，服务端不支持HEAD（405）时改用GET。
+// 连接失败或响应状态码不是2xx时返回错误。健康检查不执行钩子，也不使用缓存
+func (c *Client) Ping(ctx context.Context) error {
+	path := c.healthPath
+	if path == "" {
+		path = defaultHealthPath
+	}
+
+	status, err := c.ping(ctx, http.MethodHead, path)
+	if err == nil && status == http.StatusMethodNotAllowed {
+		status, err = c.ping(ctx, http.MethodGet, path)
+	}
+	if err != nil {
+		return fmt.Errorf("健康检查失败: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("健康检查失败: 状态码 %d", status)
+	}
+	return nil
+}
+
+// ping 发送一次健康检查请求并返回状态码
+func (c *Client) ping(ctx context.Context, method, path string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.setDefaultHeaders(req); err != nil {
+		return 0, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	closeResponseBody(resp)
+	return resp.StatusCode, nil
+}
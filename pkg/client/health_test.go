@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestPing 测试健康检查
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/get-only":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	healthy := NewClient(server.URL, 5*time.Second, WithHealthPath("/healthz"))
+	if err := healthy.Ping(ctx); err != nil {
+		t.Errorf("健康的服务不应返回错误: %v", err)
+	}
+
+	// 不支持HEAD时回退到GET
+	getOnly := NewClient(server.URL, 5*time.Second, WithHealthPath("/get-only"))
+	if err := getOnly.Ping(ctx); err != nil {
+		t.Errorf("不支持HEAD时应回退到GET: %v", err)
+	}
+
+	unhealthy := NewClient(server.URL, 5*time.Second)
+	if err := unhealthy.Ping(ctx); err == nil {
+		t.Error("返回503的服务应该返回错误")
+	}
+
+	// 无法连接
+	unreachable := NewClient("http://127.0.0.1:1", time.Second)
+	if err := unreachable.Ping(ctx); err == nil {
+		t.Error("无法连接的服务应该返回错误")
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// WithHTTP2 设置HTTPS请求是否使用HTTP/2
+// enabled为true时在TLS握手中协商h2（服务器不支持时回退到HTTP/1.1）；为false时强制使用HTTP/1.1。
+// 设置在底层*http.Transport上，通过WithTransport设置了其他类型的传输层时不生效；
+// 与WithTransport同时使用时需放在其后。明文HTTP请求始终使用HTTP/1.1
+func WithHTTP2(enabled bool) ClientOption {
+	return func(c *Client) {
+		transport, ok := c.cloneTransport()
+		if !ok {
+			return
+		}
+
+		if enabled {
+			transport.ForceAttemptHTTP2 = true
+			// TLSNextProto为nil时传输层自动配置h2
+			transport.TLSNextProto = nil
+		} else {
+			transport.ForceAttemptHTTP2 = false
+			// 非nil的空TLSNextProto禁用HTTP/2
+			transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+			// 已配置过HTTP/2的传输层会在TLS配置中声明h2，需要去掉，否则协商出h2后无法处理
+			if transport.TLSClientConfig != nil {
+				tlsConfig := transport.TLSClientConfig.Clone()
+				protos := make([]string, 0, len(tlsConfig.NextProtos))
+				for _, proto := range tlsConfig.NextProtos {
+					if proto != "h2" {
+						protos = append(protos, proto)
+					}
+				}
+				tlsConfig.NextProtos = protos
+				transport.TLSClientConfig = tlsConfig
+			}
+		}
+		c.client.Transport = transport
+	}
+}
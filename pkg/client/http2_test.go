@@ -0,0 +1,57 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWithHTTP2 测试启用和禁用HTTP/2时协商的协议
+func TestWithHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		expected string
+	}{
+		{"启用HTTP/2", true, "HTTP/2.0"},
+		{"强制HTTP/1.1", false, "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// server.Client()的传输层信任测试证书并已启用HTTP/2
+			c := NewClient(server.URL, 5*time.Second,
+				WithTransport(server.Client().Transport), WithHTTP2(tt.enabled))
+			resp, err := c.Get("/")
+			if err != nil {
+				t.Fatalf("发送请求失败: %v", err)
+			}
+			defer closeResponseBody(resp)
+
+			if resp.Proto != tt.expected {
+				t.Errorf("客户端协商的协议错误, 期望: %s, 实际: %s", tt.expected, resp.Proto)
+			}
+			if got := resp.Header.Get("X-Proto"); got != tt.expected {
+				t.Errorf("服务器收到的协议错误, 期望: %s, 实际: %s", tt.expected, got)
+			}
+		})
+	}
+
+	// 不影响传入的传输层
+	if resp, err := server.Client().Get(server.URL); err != nil {
+		t.Fatalf("发送请求失败: %v", err)
+	} else {
+		closeResponseBody(resp)
+		if resp.Proto != "HTTP/2.0" {
+			t.Errorf("原传输层不应被修改, 实际协议: %s", resp.Proto)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+	"github.com/birdmichael/RenderAPI/pkg/template"
+)
+
+// JSONMarshaler 请求体JSON序列化函数
+type JSONMarshaler func(v interface{}) ([]byte, error)
+
+// SetJSONMarshaler 设置序列化请求体使用的JSON函数，传入nil恢复默认实现
+// 默认实现基于encoding/json并关闭HTML转义，请求体中的&、<、>会原样发送
+func (c *Client) SetJSONMarshaler(marshal func(interface{}) ([]byte, error)) {
+	c.jsonMarshal = marshal
+}
+
+// marshalJSON 使用配置的JSON函数序列化请求体
+func (c *Client) marshalJSON(v interface{}) ([]byte, error) {
+	if c.jsonMarshal != nil {
+		return c.jsonMarshal(v)
+	}
+	return utils.MarshalJSONNoEscape(v, "")
+}
+
+// renderBody 渲染请求体模板，校验结果是有效的JSON后使用配置的JSON函数重新序列化
+func (c *Client) renderBody(templateID string, data interface{}) ([]byte, error) {
+	rendered, err := c.templateEngine.Execute(templateID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var body interface{}
+	if err := json.Unmarshal([]byte(rendered), &body); err != nil {
+		return nil, utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("渲染结果不是有效的JSON: %w", template.LocateJSONError([]byte(rendered), err)))
+	}
+	return c.marshalJSON(body)
+}
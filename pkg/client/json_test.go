@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestJSONMarshaler 测试请求体的JSON序列化
+func TestJSONMarshaler(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	templateJSON := `{
+		"request": {"method": "POST", "path": "/api/links"},
+		"body": {"url": "{{ .url }}"}
+	}`
+	data := map[string]interface{}{"url": "https://example.com/?a=1&b=2"}
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+
+	// 默认不转义&
+	resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+	if received != `{"url":"https://example.com/?a=1&b=2"}` {
+		t.Errorf("请求体中的&被转义: %s", received)
+	}
+
+	// 自定义序列化函数
+	c.SetJSONMarshaler(func(v interface{}) ([]byte, error) {
+		return json.MarshalIndent(v, "", "  ")
+	})
+	resp, err = c.ExecuteTemplateJSON(context.Background(), templateJSON, data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+	if !strings.Contains(received, "\n  \"url\"") {
+		t.Errorf("未使用自定义序列化函数: %s", received)
+	}
+}
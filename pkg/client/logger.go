@@ -0,0 +1,33 @@
+package client
+
+import (
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+	"github.com/birdmichael/RenderAPI/pkg/logger"
+)
+
+// WithLogger 设置客户端的日志记录器
+// 之后通过AddBeforeHook/AddAfterHook添加的钩子以及模板中定义的钩子，
+// 只要实现了hooks.LoggerAware接口，都会使用该记录器输出日志
+func WithLogger(l logger.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// log 返回客户端自身使用的日志记录器，未设置时不输出
+func (c *Client) log() logger.Logger {
+	if c.logger == nil {
+		return logger.Nop
+	}
+	return c.logger
+}
+
+// injectLogger 为支持注入日志记录器的钩子设置客户端的记录器
+func (c *Client) injectLogger(hook interface{}) {
+	if c.logger == nil {
+		return
+	}
+	if aware, ok := hook.(hooks.LoggerAware); ok {
+		aware.SetLogger(c.logger)
+	}
+}
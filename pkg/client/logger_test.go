@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// captureLogger 记录日志内容的测试日志记录器
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) record(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Debugf(format string, args ...interface{}) {
+	l.record("DEBUG", format, args...)
+}
+func (l *captureLogger) Infof(format string, args ...interface{}) { l.record("INFO", format, args...) }
+func (l *captureLogger) Errorf(format string, args ...interface{}) {
+	l.record("ERROR", format, args...)
+}
+
+// count 统计包含substr的日志条数
+func (l *captureLogger) count(substr string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, line := range l.lines {
+		if strings.Contains(line, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+// TestWithLogger 测试客户端日志记录器注入到钩子并记录重试
+func TestWithLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	log := &captureLogger{}
+	c := NewClient(server.URL, 5*time.Second, WithLogger(log))
+	c.AddBeforeHook(hooks.NewLoggingHook())
+	c.AddAfterHook(hooks.NewResponseLogHook())
+
+	t.Run("全局钩子", func(t *testing.T) {
+		resp, err := c.Get("/users")
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		resp.Body.Close()
+
+		if log.count("INFO 正在发送 GET 请求到 "+server.URL+"/users") != 1 {
+			t.Errorf("缺少请求日志: %v", log.lines)
+		}
+		if log.count("INFO 收到响应: 状态码 503") != 1 {
+			t.Errorf("缺少响应日志: %v", log.lines)
+		}
+	})
+
+	t.Run("模板钩子与重试", func(t *testing.T) {
+		tmpl := `{
+			"request": {"method": "GET", "path": "/orders"},
+			"beforeHooks": [{"type": "js", "script": "function processRequest(r) { console.log('模板钩子'); return r; }"}],
+			"retry": {"enabled": true, "maxAttempts": 2, "initialDelay": 10, "backoffFactor": 1}
+		}`
+		resp, err := c.ExecuteTemplateJSON(context.Background(), tmpl, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		resp.Body.Close()
+
+		if log.count("INFO [JS] [模板钩子]") != 1 {
+			t.Errorf("模板钩子应使用客户端日志记录器: %v", log.lines)
+		}
+		if log.count("DEBUG 请求 GET "+server.URL+"/orders 返回状态码 503，10ms后第1次重试") != 1 {
+			t.Errorf("缺少重试日志: %v", log.lines)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// RequestMetrics 单个请求的指标，以JSON Lines格式写入指标日志
+type RequestMetrics struct {
+	Time       time.Time `json:"time"`            // 请求开始时间
+	Method     string    `json:"method"`          // HTTP方法
+	URL        string    `json:"url"`             // 请求URL
+	Status     int       `json:"status"`          // 最终响应状态码，请求失败时为0
+	DurationMs float64   `json:"durationMs"`      // 请求耗时（毫秒），包含重试和响应后钩子
+	CacheHit   bool      `json:"cacheHit"`        // 是否命中缓存
+	Retries    int       `json:"retries"`         // 重试次数
+	Error      string    `json:"error,omitempty"` // 错误信息
+}
+
+// EnableMetricsLog 启用指标日志，每个请求完成后向w写入一行JSON
+// 可用于基于日志的监控系统；传入nil可关闭指标日志
+func (c *Client) EnableMetricsLog(w io.Writer) {
+	c.metricsMutex.Lock()
+	defer c.metricsMutex.Unlock()
+
+	c.metricsWriter = w
+}
+
+// recordMetrics 记录请求指标，未启用指标日志时不做任何事
+func (c *Client) recordMetrics(req *http.Request, resp *http.Response, start time.Time, cacheHit bool, retries int, err error) {
+	c.metricsMutex.Lock()
+	defer c.metricsMutex.Unlock()
+
+	if c.metricsWriter == nil {
+		return
+	}
+
+	metrics := RequestMetrics{
+		Time:       start,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		CacheHit:   cacheHit,
+		Retries:    retries,
+	}
+	if resp != nil {
+		metrics.Status = resp.StatusCode
+	}
+	if err != nil {
+		metrics.Error = err.Error()
+	}
+
+	line, jsonErr := json.Marshal(metrics)
+	if jsonErr != nil {
+		return
+	}
+	c.metricsWriter.Write(append(line, '\n'))
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestMetricsLog 测试指标日志输出
+func TestMetricsLog(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	var buf bytes.Buffer
+	c := NewClient(server.URL, 5*time.Second)
+	c.EnableMetricsLog(&buf)
+
+	resp, err := c.Get("/api/users")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+
+	// 启用缓存的模板请求，第二次应命中缓存
+	templateJSON := `{
+		"request": {"method": "GET", "path": "/api/users"},
+		"caching": {"enabled": true, "ttl": 60}
+	}`
+	for i := 0; i < 2; i++ {
+		resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var entries []RequestMetrics
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry RequestMetrics
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("指标日志不是有效的JSON: %v (%s)", err, scanner.Text())
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("指标日志条数错误，期望: 3, 实际: %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Method != http.MethodGet || first.URL != server.URL+"/api/users" || first.Status != http.StatusOK {
+		t.Errorf("指标内容错误: %+v", first)
+	}
+	if first.CacheHit || first.Retries != 0 || first.DurationMs <= 0 {
+		t.Errorf("指标内容错误: %+v", first)
+	}
+
+	if entries[1].CacheHit {
+		t.Errorf("首次模板请求不应命中缓存: %+v", entries[1])
+	}
+	if !entries[2].CacheHit || entries[2].Status != http.StatusOK {
+		t.Errorf("第二次模板请求应命中缓存: %+v", entries[2])
+	}
+
+	// 关闭后不再记录
+	c.EnableMetricsLog(nil)
+	buf.Reset()
+	resp, err = c.Get("/api/users")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if buf.Len() != 0 {
+		t.Errorf("关闭指标日志后不应输出: %s", buf.String())
+	}
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// multipartBody 以流式方式生成multipart/form-data请求体
+// 文件在第一次读取时才打开并逐块写入，不会整体加载到内存
+type multipartBody struct {
+	fields map[string]interface{}
+	files  map[string]string // 字段名 -> 文件路径
+
+	writer *multipart.Writer
+	pr     *io.PipeReader
+	pw     *io.PipeWriter
+	once   sync.Once
+}
+
+// newMultipartBody 创建multipart请求体，文件路径不可读时立即返回错误
+func newMultipartBody(fields map[string]interface{}, files map[string]string) (*multipartBody, error) {
+	for field, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取上传文件失败(%s): %w", field, err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("上传文件路径是目录(%s): %s", field, path)
+		}
+	}
+
+	pr, pw := io.Pipe()
+	return &multipartBody{
+		fields: fields,
+		files:  files,
+		writer: multipart.NewWriter(pw),
+		pr:     pr,
+		pw:     pw,
+	}, nil
+}
+
+// ContentType 返回包含boundary的Content-Type
+func (b *multipartBody) ContentType() string {
+	return b.writer.FormDataContentType()
+}
+
+// Read 实现io.Reader，第一次读取时启动写入协程
+func (b *multipartBody) Read(p []byte) (int, error) {
+	b.once.Do(func() {
+		go func() {
+			b.pw.CloseWithError(b.write())
+		}()
+	})
+	return b.pr.Read(p)
+}
+
+// Close 实现io.Closer，提前关闭时写入协程会因管道关闭而退出
+func (b *multipartBody) Close() error {
+	return b.pr.Close()
+}
+
+// write 依次写入普通字段和文件字段，字段按名称排序保证输出稳定
+func (b *multipartBody) write() error {
+	for _, name := range sortedKeys(b.fields) {
+		value, err := formValue(b.fields[name])
+		if err != nil {
+			return err
+		}
+		if err := b.writer.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+
+	fileFields := make([]string, 0, len(b.files))
+	for name := range b.files {
+		fileFields = append(fileFields, name)
+	}
+	sort.Strings(fileFields)
+
+	for _, name := range fileFields {
+		if err := b.writeFile(name, b.files[name]); err != nil {
+			return err
+		}
+	}
+	return b.writer.Close()
+}
+
+// writeFile 写入单个文件字段
+func (b *multipartBody) writeFile(field, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开上传文件失败(%s): %w", field, err)
+	}
+	defer f.Close()
+
+	part, err := b.writer.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, f)
+	return err
+}
+
+// formValue 将请求体字段转换为表单值，嵌套结构编码为JSON
+func formValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case map[string]interface{}, []interface{}:
+		data, err := utils.MarshalJSONNoEscape(val, "")
+		if err != nil {
+			return "", fmt.Errorf("编码表单字段失败: %w", err)
+		}
+		return string(data), nil
+	default:
+		return fmt.Sprint(val), nil
+	}
+}
+
+// sortedKeys 返回排序后的Map键
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderFilePaths 使用模板引擎渲染文件字段的路径
+func (c *Client) renderFilePaths(templateID string, files map[string]string, data interface{}) (map[string]string, error) {
+	rendered := make(map[string]string, len(files))
+	for field, pathTemplate := range files {
+		name := templateID + "_file_" + field
+		if err := c.templateEngine.AddTemplate(name, pathTemplate); err != nil {
+			return nil, &TemplateError{Err: fmt.Errorf("添加文件路径模板失败: %w", err)}
+		}
+		path, err := c.templateEngine.Execute(name, data)
+		if err != nil {
+			return nil, &TemplateError{Err: fmt.Errorf("渲染文件路径失败: %w", err)}
+		}
+		rendered[field] = path
+	}
+	return rendered, nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTemplateFileUpload 测试文件路径来自数据的multipart上传
+func TestTemplateFileUpload(t *testing.T) {
+	dir := t.TempDir()
+	avatarPath := filepath.Join(dir, "avatar.png")
+	if err := os.WriteFile(avatarPath, []byte("fake-png-content"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	var name, filename, content string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name = r.FormValue("name")
+		file, header, err := r.FormFile("avatar")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		filename = header.Filename
+		data, _ := io.ReadAll(file)
+		content = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+
+	templateJSON, err := NewTemplateBuilder().
+		Method("POST").
+		Path("/upload").
+		Body(map[string]interface{}{"name": "{{ .name }}"}).
+		File("avatar", "{{ .avatarPath }}").
+		Build()
+	if err != nil {
+		t.Fatalf("构建模板失败: %v", err)
+	}
+
+	data := map[string]interface{}{"name": "张三", "avatarPath": avatarPath}
+	resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("上传失败，状态码: %d", resp.StatusCode)
+	}
+	if name != "张三" {
+		t.Errorf("表单字段错误: %s", name)
+	}
+	if filename != "avatar.png" || content != "fake-png-content" {
+		t.Errorf("上传文件错误，文件名: %s, 内容: %s", filename, content)
+	}
+
+	// 文件不存在时立即返回错误
+	data["avatarPath"] = filepath.Join(dir, "missing.png")
+	if _, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, data); err == nil {
+		t.Error("文件不存在时应该返回错误")
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// ClientOption 客户端配置选项
+type ClientOption func(*Client)
+
+// defaultMaxRedirects 默认最大重定向次数，与net/http保持一致
+const defaultMaxRedirects = 10
+
+// WithRedirectPolicy 设置重定向策略
+// follow为false时不跟随重定向，直接返回3xx响应；
+// follow为true时最多跟随maxRedirects次重定向（<=0时使用默认值10）
+func WithRedirectPolicy(maxRedirects int, follow bool) ClientOption {
+	return func(c *Client) {
+		if !follow {
+			c.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			}
+			return
+		}
+
+		if maxRedirects <= 0 {
+			maxRedirects = defaultMaxRedirects
+		}
+		c.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("超过最大重定向次数(%d)", maxRedirects)
+			}
+			return nil
+		}
+	}
+}
+
+// WithCacheSweepInterval 启动后台协程，按指定间隔清理过期的缓存条目
+// 带有ETag或Last-Modified的过期条目会再保留一段时间（默认DefaultMaxStale），用于发起条件请求
+// 启用后应在不再使用客户端时调用Close停止该协程
+func WithCacheSweepInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.sweepInterval = interval
+	}
+}
+
+// FailOnErrorStatus 响应状态码不是2xx时返回*HTTPStatusError
+// 默认情况下任何状态码的响应都会原样返回，由调用方检查状态码
+func FailOnErrorStatus() ClientOption {
+	return func(c *Client) {
+		c.failOnErrorStatus = true
+	}
+}
+
+// WithTransport 设置底层HTTP传输层，例如RecordingTransport或ReplayTransport
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.client.Transport = transport
+	}
+}
+
+// WithMaxResponseBytes 限制响应体的最大字节数，防止异常的服务端耗尽内存
+// 超过上限时，ReadResponseBody以及读取响应体的钩子、校验器会返回ErrResponseTooLarge；
+// 响应后钩子替换的响应体和来自缓存的响应体同样受此上限约束
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithBaseURL 设置请求的基础URL，常与Clone配合派生访问其他服务的客户端
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithEmptyJSONBody 设置POST/PUT/PATCH请求的请求体为nil且Content-Type为JSON时是否发送{}
+// 默认开启；关闭后与其他请求一样发送空请求体
+func WithEmptyJSONBody(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.keepNilBody = !enabled
+	}
+}
+
+// hasJSONBodyMethod 判断请求方法是否通常带有请求体
+func hasJSONBodyMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// isJSONContentType 判断Content-Type是否为JSON类型，包括application/problem+json等结构化后缀
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && acceptsMediaType("application/json", mediaType)
+}
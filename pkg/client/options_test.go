@@ -0,0 +1,172 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// setupRedirectServer 创建一个重定向测试服务器，/redirect/n 会依次重定向到 /redirect/n-1，直到 /final
+func setupRedirectServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/redirect/") {
+			n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/redirect/"))
+			if n <= 1 {
+				http.Redirect(w, r, "/final", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/redirect/"+strconv.Itoa(n-1), http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "final"}`))
+	}))
+}
+
+// TestWithRedirectPolicy 测试重定向策略
+func TestWithRedirectPolicy(t *testing.T) {
+	server := setupRedirectServer()
+	defer server.Close()
+
+	t.Run("不跟随重定向", func(t *testing.T) {
+		c := NewClient(server.URL, 5*time.Second, WithRedirectPolicy(0, false))
+		resp, err := c.Get("/redirect/1")
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusFound {
+			t.Errorf("状态码错误，期望: %d, 实际: %d", http.StatusFound, resp.StatusCode)
+		}
+		if resp.Header.Get("Location") != "/final" {
+			t.Errorf("Location错误，期望: /final, 实际: %s", resp.Header.Get("Location"))
+		}
+	})
+
+	t.Run("限制重定向次数内", func(t *testing.T) {
+		c := NewClient(server.URL, 5*time.Second, WithRedirectPolicy(3, true))
+		resp, err := c.Get("/redirect/2")
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("状态码错误，期望: %d, 实际: %d", http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("超过重定向次数", func(t *testing.T) {
+		c := NewClient(server.URL, 5*time.Second, WithRedirectPolicy(2, true))
+		_, err := c.Get("/redirect/5")
+		if err == nil {
+			t.Fatal("超过最大重定向次数时应该返回错误")
+		}
+		if !strings.Contains(err.Error(), "超过最大重定向次数") {
+			t.Errorf("错误消息不正确: %v", err)
+		}
+	})
+}
+
+// TestMaxResponseBytes 测试响应体大小限制
+func TestMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := 100
+		if r.URL.Path == "/over" {
+			size = 101
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(strings.Repeat("a", size)))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second, WithMaxResponseBytes(100))
+
+	// 恰好等于上限
+	resp, err := c.Get("/under")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	body, err := ReadResponseBody(resp)
+	if err != nil || len(body) != 100 {
+		t.Errorf("未超限的响应应完整读取，长度: %d, 错误: %v", len(body), err)
+	}
+
+	// 超过上限一个字节
+	resp, err = c.Get("/over")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if _, err := ReadResponseBody(resp); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("超限的响应应返回ErrResponseTooLarge, 实际: %v", err)
+	}
+
+	// 读取响应体的校验器同样受限
+	c.AddResponseValidator(func(resp *http.Response) error { return nil })
+	if _, err := c.Get("/over"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("校验器读取超限响应应返回ErrResponseTooLarge, 实际: %v", err)
+	}
+
+	// 响应后钩子替换的响应体同样受限
+	replaced := NewClient(server.URL, 5*time.Second, WithMaxResponseBytes(100))
+	replaced.AddAfterHook(&hooks.CustomFunctionHook{
+		AfterFn: func(resp *http.Response) (*http.Response, error) {
+			resp.Body = io.NopCloser(strings.NewReader(strings.Repeat("b", 101)))
+			return resp, nil
+		},
+	})
+	resp, err = replaced.Get("/under")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if _, err := ReadResponseBody(resp); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("钩子替换的超限响应体应返回ErrResponseTooLarge, 实际: %v", err)
+	}
+}
+
+// TestEmptyJSONBody 测试JSON请求的请求体为nil时发送{}
+func TestEmptyJSONBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.Method+" "+string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetHeader("Content-Type", "application/json")
+	disabled := c.Clone(WithEmptyJSONBody(false))
+	plain := NewClient(server.URL, 5*time.Second)
+
+	requests := []func() (*http.Response, error){
+		func() (*http.Response, error) { return c.Post("/users", nil) },
+		func() (*http.Response, error) { return c.Patch("/users/1", nil) },
+		func() (*http.Response, error) { return c.Put("/users/1", []byte{}) },
+		func() (*http.Response, error) { return c.Delete("/users/1") },
+		func() (*http.Response, error) { return disabled.Post("/users", nil) },
+		func() (*http.Response, error) { return plain.Post("/users", nil) },
+	}
+	for _, send := range requests {
+		resp, err := send()
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		closeResponseBody(resp)
+	}
+
+	expected := []string{"POST {}", "PATCH {}", "PUT ", "DELETE ", "POST ", "POST "}
+	for i, want := range expected {
+		if bodies[i] != want {
+			t.Errorf("第%d个请求期望: %q，实际: %q", i+1, want, bodies[i])
+		}
+	}
+}
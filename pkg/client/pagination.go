@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// GetAllMerged 依次获取所有分页并将各页的数组合并为一个JSON数组
+// itemsPath和nextPath是响应体中的点分隔路径：itemsPath指向当前页的数据数组（为空表示响应体本身），
+// nextPath指向下一页的地址，可以是相对于baseURL的路径或完整URL，不存在、为null或空字符串时结束；
+// nextPath为空表示只有一页。服务端返回的完整URL必须与第一页同源（协议和主机相同），
+// 避免默认请求头中的凭证被发送到其他主机。
+// 任意一页返回非2xx状态码、数据不是数组、下一页地址不同源或重复出现时返回错误
+func (c *Client) GetAllMerged(ctx context.Context, path, itemsPath, nextPath string) ([]byte, error) {
+	items := make([]interface{}, 0)
+	visited := make(map[string]bool)
+	origin := urlOrigin(c.pageURL(path))
+
+	for page := 1; path != ""; page++ {
+		if visited[path] {
+			return nil, fmt.Errorf("第%d页的地址重复出现，分页陷入循环: %s", page, path)
+		}
+		visited[path] = true
+
+		if page > 1 && urlOrigin(c.pageURL(path)) != origin {
+			return nil, fmt.Errorf("第%d页的地址与第一页不同源，拒绝跟随: %s", page, path)
+		}
+
+		data, err := c.getPage(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("获取第%d页失败: %w", page, err)
+		}
+
+		value, ok := utils.GetPath(data, itemsPath)
+		if !ok {
+			return nil, fmt.Errorf("第%d页缺少数据字段: %s", page, itemsPath)
+		}
+		pageItems, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("第%d页的数据字段不是数组: %T", page, value)
+		}
+		items = append(items, pageItems...)
+
+		path = ""
+		if nextPath == "" {
+			break
+		}
+		if next, ok := utils.GetPath(data, nextPath); ok && next != nil {
+			path = fmt.Sprint(next)
+		}
+	}
+
+	merged, err := c.marshalJSON(items)
+	if err != nil {
+		return nil, fmt.Errorf("序列化合并结果失败: %w", err)
+	}
+	return merged, nil
+}
+
+// pageURL 返回分页地址对应的完整URL，path为完整URL时不拼接baseURL
+func (c *Client) pageURL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.baseURL + path
+}
+
+// urlOrigin 返回URL的协议和主机（小写），无法解析时返回空字符串
+func urlOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// getPage 获取单页数据并解码为JSON，path为完整URL时不拼接baseURL
+func (c *Client) getPage(ctx context.Context, path string) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.pageURL(path), nil)
+	if err != nil {
+		return nil, utils.WrapSentinel(ErrRequestFailed, fmt.Errorf("创建请求失败: %w", err))
+	}
+	if err := c.setDefaultHeaders(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ReadResponseBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+
+	// 使用UseNumber保留大整数的精度
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, &DecodeError{Format: "JSON", Err: utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("解析响应JSON失败: %w", err))}
+	}
+	return data, nil
+}
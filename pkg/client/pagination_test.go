@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestGetAllMerged 测试跟随分页并合并各页的数据数组
+func TestGetAllMerged(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Write([]byte(`{"data": {"items": [{"id": 1}, {"id": 2}]}, "links": {"next": "/api/users?page=2"}}`))
+		case "2":
+			// 下一页地址也可以是完整URL
+			w.Write([]byte(`{"data": {"items": [{"id": 3}]}, "links": {"next": "` + server.URL + `/api/users?page=3"}}`))
+		case "3":
+			w.Write([]byte(`{"data": {"items": [{"id": 4}, {"id": 9007199254740993}, {"name": "a<b&c"}]}, "links": {"next": null}}`))
+		default:
+			t.Errorf("不应请求的页: %s", r.URL)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	merged, err := c.GetAllMerged(context.Background(), "/api/users", "data.items", "links.next")
+	if err != nil {
+		t.Fatalf("获取分页失败: %v", err)
+	}
+
+	// 合并结果不转义HTML字符
+	expected := `[{"id":1},{"id":2},{"id":3},{"id":4},{"id":9007199254740993},{"name":"a<b&c"}]`
+	if string(merged) != expected {
+		t.Errorf("合并结果错误，期望: %s, 实际: %s", expected, merged)
+	}
+	if !json.Valid(merged) {
+		t.Error("合并结果应是有效的JSON")
+	}
+}
+
+// TestGetAllMergedErrors 测试分页失败、数据格式错误和分页循环
+func TestGetAllMergedErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			w.Write([]byte(`{"items": [1], "next": "/loop"}`))
+		case "/object":
+			w.Write([]byte(`{"items": {"id": 1}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	ctx := context.Background()
+
+	if _, err := c.GetAllMerged(ctx, "/loop", "items", "next"); err == nil {
+		t.Error("分页地址重复时应返回错误")
+	}
+	if _, err := c.GetAllMerged(ctx, "/object", "items", "next"); err == nil {
+		t.Error("数据字段不是数组时应返回错误")
+	}
+
+	_, err := c.GetAllMerged(ctx, "/broken", "items", "next")
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("非2xx响应应返回*HTTPStatusError，实际: %v", err)
+	}
+}
+
+// TestGetAllMergedNextPage 测试nextPath为空时只获取一页，不同源的下一页地址不会被跟随
+func TestGetAllMergedNextPage(t *testing.T) {
+	var foreignRequests int
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreignRequests++
+		if r.Header.Get("Authorization") != "" {
+			t.Error("凭证不应发送到其他主机")
+		}
+		w.Write([]byte(`{"items": [2]}`))
+	}))
+	defer foreign.Close()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"items": [1], "next": "` + foreign.URL + `/steal"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetHeader("Authorization", "Bearer secret")
+	ctx := context.Background()
+
+	merged, err := c.GetAllMerged(ctx, "/api/users", "items", "")
+	if err != nil {
+		t.Fatalf("获取分页失败: %v", err)
+	}
+	if string(merged) != `[1]` || requests != 1 {
+		t.Errorf("nextPath为空时应只获取一页，结果: %s, 请求数: %d", merged, requests)
+	}
+
+	if _, err := c.GetAllMerged(ctx, "/api/users", "items", "next"); err == nil {
+		t.Error("下一页地址与第一页不同源时应返回错误")
+	}
+	if foreignRequests != 0 {
+		t.Errorf("不应请求不同源的下一页地址，请求数: %d", foreignRequests)
+	}
+}
@@ -0,0 +1,399 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// WithAsyncHooks 启用异步钩子流水线
+// 启用后，相邻的声明了独立性（实现hooks.IndependentHook且返回true）的钩子会通过
+// BeforeAsync/AfterAsync并发执行，各自作用于请求/响应的副本，完成后按注册顺序合并头部修改，
+// 因此结果与同步执行一致。未声明独立性的钩子（可能读写请求体或响应体）始终按顺序同步执行，
+// 并作为并发分组之间的屏障
+func WithAsyncHooks() ClientOption {
+	return func(c *Client) {
+		c.asyncHooks = true
+	}
+}
+
+// isIndependentHook 判断钩子是否声明了独立性
+func isIndependentHook(hook interface{}) bool {
+	independent, ok := hook.(hooks.IndependentHook)
+	return ok && independent.IsIndependent()
+}
+
+// applyBeforeHooks 依次执行请求前钩子，启用异步流水线时并发执行相邻的独立钩子
+func (c *Client) applyBeforeHooks(req *http.Request, beforeHooks []hooks.BeforeRequestHook) (*http.Request, error) {
+	for i := 0; i < len(beforeHooks); {
+		// 找出从i开始的连续独立钩子
+		j := i
+		if c.asyncHooks {
+			for j < len(beforeHooks) && isIndependentHook(beforeHooks[j]) {
+				j++
+			}
+		}
+
+		if j-i < 2 {
+			var err error
+			hook := beforeHooks[i]
+			req, err = callBeforeHook(req, hook)
+			if err != nil {
+				return nil, err
+			}
+			if req == nil {
+				return nil, fmt.Errorf("钩子返回了空请求: %T", hook)
+			}
+			i++
+			continue
+		}
+
+		var err error
+		req, err = runBeforeHooksConcurrently(req, beforeHooks[i:j])
+		if err != nil {
+			return nil, err
+		}
+		i = j
+	}
+	return req, nil
+}
+
+// getHookConfig 返回钩子通过hooks.Hook接口提供的配置，未实现该接口时返回零值配置
+func getHookConfig(hook interface{}) hooks.HookConfig {
+	if configured, ok := hook.(hooks.Hook); ok {
+		if config := configured.GetConfig(); config != nil {
+			return *config
+		}
+	}
+	return hooks.HookConfig{}
+}
+
+// wrapHookError 为钩子返回的错误加上钩子名称，超时错误还会加上超时时间，便于定位是哪个钩子
+// 没有配置名称的钩子使用钩子类型表示
+func wrapHookError(hook interface{}, config hooks.HookConfig, err error) error {
+	if errors.Is(err, hooks.ErrHookTimeout) {
+		label := config.Name
+		if label == "" {
+			label = fmt.Sprintf("%T", hook)
+		}
+		return fmt.Errorf("钩子%s执行超过%d秒: %w", label, config.TimeoutSeconds, err)
+	}
+	if config.Name != "" {
+		return fmt.Errorf("钩子%s: %w", config.Name, err)
+	}
+	return err
+}
+
+// callBeforeHook 按钩子的配置执行单个请求前钩子
+func callBeforeHook(req *http.Request, hook hooks.BeforeRequestHook) (*http.Request, error) {
+	return runBeforeHook(req, hook, getHookConfig(hook))
+}
+
+// runBeforeHook 执行单个请求前钩子：config.Async为true时通过BeforeAsync执行并等待结果，
+// config.TimeoutSeconds大于0时超时后中止请求
+func runBeforeHook(req *http.Request, hook hooks.BeforeRequestHook, config hooks.HookConfig) (*http.Request, error) {
+	run := func() (*http.Request, error) {
+		if config.Async {
+			return awaitRequest(hook.BeforeAsync(req))
+		}
+		return hook.Before(req)
+	}
+
+	if config.TimeoutSeconds <= 0 {
+		result, err := run()
+		if err != nil {
+			return nil, wrapHookError(hook, config, err)
+		}
+		return result, nil
+	}
+
+	var result *http.Request
+	err := hooks.ExecuteHookWithTimeout(req.Context(), func() error {
+		var err error
+		result, err = run()
+		return err
+	}, config.TimeoutSeconds)
+	if err != nil {
+		return nil, wrapHookError(hook, config, err)
+	}
+	return result, nil
+}
+
+// callAfterHook 按钩子的配置执行单个响应后钩子
+func callAfterHook(resp *http.Response, hook hooks.AfterResponseHook) (*http.Response, error) {
+	return runAfterHookWithConfig(resp, hook, getHookConfig(hook))
+}
+
+// runAfterHookWithConfig 执行单个响应后钩子：config.Async为true时通过AfterAsync执行并等待结果，
+// config.TimeoutSeconds大于0时超时后中止请求。未超时时即使出错也返回钩子给出的响应，由调用方关闭
+func runAfterHookWithConfig(resp *http.Response, hook hooks.AfterResponseHook, config hooks.HookConfig) (*http.Response, error) {
+	run := func() (*http.Response, error) {
+		if config.Async {
+			return awaitResponse(hook.AfterAsync(resp))
+		}
+		return hook.After(resp)
+	}
+
+	if config.TimeoutSeconds <= 0 {
+		result, err := run()
+		if err != nil {
+			return result, wrapHookError(hook, config, err)
+		}
+		return result, nil
+	}
+
+	ctx := context.Background()
+	if resp.Request != nil {
+		ctx = resp.Request.Context()
+	}
+	var result *http.Response
+	err := hooks.ExecuteHookWithTimeout(ctx, func() error {
+		var err error
+		result, err = run()
+		return err
+	}, config.TimeoutSeconds)
+	if err != nil {
+		// 超时后钩子仍可能在后台写入result，不能再使用
+		return nil, wrapHookError(hook, config, err)
+	}
+	return result, nil
+}
+
+// runBeforeHooksConcurrently 并发执行一组独立的请求前钩子并按注册顺序合并头部修改
+func runBeforeHooksConcurrently(req *http.Request, group []hooks.BeforeRequestHook) (*http.Request, error) {
+	results := make([]*http.Request, len(group))
+	errs := make([]error, len(group))
+
+	var wg sync.WaitGroup
+	for i, hook := range group {
+		wg.Add(1)
+		go func(i int, hook hooks.BeforeRequestHook) {
+			defer wg.Done()
+			// 每个钩子作用于独立的副本，头部互不干扰；独立钩子不会读取请求体
+			config := getHookConfig(hook)
+			config.Async = true
+			results[i], errs[i] = runBeforeHook(req.Clone(req.Context()), hook, config)
+		}(i, hook)
+	}
+	wg.Wait()
+
+	// 按注册顺序合并，后注册的钩子覆盖先注册的钩子，与同步执行的语义一致
+	original := req.Header.Clone()
+	for i, result := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if result == nil {
+			return nil, fmt.Errorf("钩子返回了空请求: %T", group[i])
+		}
+		mergeHeaderChanges(req.Header, original, result.Header)
+	}
+	return req, nil
+}
+
+// applyAfterHooks 依次执行响应后钩子
+// 钩子可以原地修改响应，也可以返回一个全新的*http.Response（例如缓存或Mock钩子）。
+// 当钩子返回的响应使用了不同的响应体时，被替换的原响应体会被关闭，后续钩子和调用方只会看到新响应；
+// 任一钩子失败时，当前持有的响应体都会被关闭。启用异步流水线时相邻的独立钩子会并发执行。
+// 返回的响应体（包括钩子替换的和缓存的响应体）受WithMaxResponseBytes设置的上限约束
+func (c *Client) applyAfterHooks(resp *http.Response, afterHooks []hooks.AfterResponseHook) (*http.Response, error) {
+	resp, err := c.applyAfterHooksUnlimited(resp, afterHooks)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = hooks.LimitBody(resp.Body, c.maxResponseBytes)
+	return resp, nil
+}
+
+// applyAfterHooksUnlimited 执行响应后钩子，按需保留原始响应体
+func (c *Client) applyAfterHooksUnlimited(resp *http.Response, afterHooks []hooks.AfterResponseHook) (*http.Response, error) {
+	if !c.keepOriginalResponse {
+		return c.runAfterHooks(resp, afterHooks)
+	}
+
+	// 保留钩子处理前的原始响应体
+	raw, err := captureRawBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	req := resp.Request
+
+	resp, err = c.runAfterHooks(resp, afterHooks)
+	if err != nil {
+		return nil, err
+	}
+	attachRawBody(resp, req, raw)
+	return resp, nil
+}
+
+// runAfterHooks 按顺序（或按分组并发）执行响应后钩子
+func (c *Client) runAfterHooks(resp *http.Response, afterHooks []hooks.AfterResponseHook) (*http.Response, error) {
+	for i := 0; i < len(afterHooks); {
+		j := i
+		if c.asyncHooks {
+			for j < len(afterHooks) && isIndependentHook(afterHooks[j]) {
+				j++
+			}
+		}
+
+		if j-i < 2 {
+			var err error
+			resp, err = runAfterHook(resp, afterHooks[i])
+			if err != nil {
+				return nil, err
+			}
+			i++
+			continue
+		}
+
+		if err := runAfterHooksConcurrently(resp, afterHooks[i:j]); err != nil {
+			closeResponseBody(resp)
+			return nil, err
+		}
+		i = j
+	}
+	return resp, nil
+}
+
+// runAfterHook 执行单个响应后钩子并处理响应替换
+func runAfterHook(resp *http.Response, hook hooks.AfterResponseHook) (*http.Response, error) {
+	newResp, err := callAfterHook(resp, hook)
+	if err != nil {
+		closeResponseBody(resp)
+		if newResp != nil && newResp.Body != resp.Body {
+			closeResponseBody(newResp)
+		}
+		return nil, err
+	}
+
+	if newResp == nil {
+		closeResponseBody(resp)
+		return nil, fmt.Errorf("钩子返回了空响应: %T", hook)
+	}
+
+	// 钩子返回了使用不同响应体的新响应，关闭被替换的原响应体
+	if newResp.Body != resp.Body {
+		closeResponseBody(resp)
+	}
+	return newResp, nil
+}
+
+// runAfterHooksConcurrently 并发执行一组独立的响应后钩子并按注册顺序合并头部修改
+// 独立钩子不应替换响应或读取响应体，因此只合并其对响应头的修改
+func runAfterHooksConcurrently(resp *http.Response, group []hooks.AfterResponseHook) error {
+	results := make([]*http.Response, len(group))
+	errs := make([]error, len(group))
+
+	var wg sync.WaitGroup
+	for i, hook := range group {
+		wg.Add(1)
+		go func(i int, hook hooks.AfterResponseHook) {
+			defer wg.Done()
+			respCopy := *resp
+			respCopy.Header = resp.Header.Clone()
+			config := getHookConfig(hook)
+			config.Async = true
+			results[i], errs[i] = runAfterHookWithConfig(&respCopy, hook, config)
+		}(i, hook)
+	}
+	wg.Wait()
+
+	original := resp.Header.Clone()
+	for i, result := range results {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		if result == nil {
+			return fmt.Errorf("钩子返回了空响应: %T", group[i])
+		}
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+		mergeHeaderChanges(resp.Header, original, result.Header)
+	}
+	return nil
+}
+
+// mergeHeaderChanges 将updated相对original的修改（新增、变更、删除）应用到target
+func mergeHeaderChanges(target, original, updated http.Header) {
+	for key, values := range updated {
+		if !equalValues(original[key], values) {
+			target[key] = append([]string(nil), values...)
+		}
+	}
+	for key := range original {
+		if _, ok := updated[key]; !ok {
+			target.Del(key)
+		}
+	}
+}
+
+// equalValues 比较两组头部值是否相同
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// errNoHookResult 异步钩子既没有返回结果也没有返回错误
+var errNoHookResult = errors.New("异步钩子未返回结果")
+
+// awaitRequest 等待异步请求前钩子的结果
+// 兼容成功后关闭通道和不关闭通道两种异步实现
+func awaitRequest(reqCh chan *http.Request, errCh chan error) (*http.Request, error) {
+	for reqCh != nil || errCh != nil {
+		select {
+		case req, ok := <-reqCh:
+			if ok {
+				return req, nil
+			}
+			reqCh = nil
+		case err, ok := <-errCh:
+			if ok && err != nil {
+				return nil, err
+			}
+			if !ok {
+				errCh = nil
+			}
+		}
+	}
+	return nil, errNoHookResult
+}
+
+// awaitResponse 等待异步响应后钩子的结果
+func awaitResponse(respCh chan *http.Response, errCh chan error) (*http.Response, error) {
+	for respCh != nil || errCh != nil {
+		select {
+		case resp, ok := <-respCh:
+			if ok {
+				return resp, nil
+			}
+			respCh = nil
+		case err, ok := <-errCh:
+			if ok && err != nil {
+				return nil, err
+			}
+			if !ok {
+				errCh = nil
+			}
+		}
+	}
+	return nil, errNoHookResult
+}
+
+// closeResponseBody 安全地关闭响应体
+func closeResponseBody(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// independentHeaderHook 创建一个设置请求头的独立钩子，可选地模拟耗时
+func independentHeaderHook(key, value string, delay time.Duration) *hooks.CustomFunctionHook {
+	return &hooks.CustomFunctionHook{
+		Independent: true,
+		BeforeFn: func(req *http.Request) (*http.Request, error) {
+			time.Sleep(delay)
+			req.Header.Set(key, value)
+			return req, nil
+		},
+	}
+}
+
+// runPipeline 使用指定的钩子发送请求，返回服务器收到的请求头和请求体
+func runPipeline(t *testing.T, async bool, beforeHooks ...hooks.BeforeRequestHook) (http.Header, map[string]interface{}) {
+	t.Helper()
+
+	var header http.Header
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var opts []ClientOption
+	if async {
+		opts = append(opts, WithAsyncHooks())
+	}
+	c := NewClient(server.URL, 5*time.Second, opts...)
+	defer c.Close()
+	for _, hook := range beforeHooks {
+		c.AddBeforeHook(hook)
+	}
+
+	resp, err := c.Post("/", []byte(`{"old":"value"}`))
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	return header, body
+}
+
+// TestAsyncHooksMatchSync 测试异步流水线与同步执行结果一致
+func TestAsyncHooksMatchSync(t *testing.T) {
+	newHooks := func() []hooks.BeforeRequestHook {
+		return []hooks.BeforeRequestHook{
+			independentHeaderHook("X-A", "a", 0),
+			independentHeaderHook("X-Shared", "first", 20*time.Millisecond),
+			independentHeaderHook("X-Shared", "second", 0),
+			hooks.NewFieldTransformHook(map[string]string{"old": "new"}),
+			independentHeaderHook("X-B", "b", 0),
+			hooks.NewAuthHook("token"),
+		}
+	}
+
+	syncHeader, syncBody := runPipeline(t, false, newHooks()...)
+	asyncHeader, asyncBody := runPipeline(t, true, newHooks()...)
+
+	for _, key := range []string{"X-A", "X-B", "X-Shared", "Authorization"} {
+		if syncHeader.Get(key) != asyncHeader.Get(key) {
+			t.Errorf("头部%s不一致: 同步=%q, 异步=%q", key, syncHeader.Get(key), asyncHeader.Get(key))
+		}
+	}
+	if asyncHeader.Get("X-Shared") != "second" {
+		t.Errorf("后注册的钩子应覆盖先注册的钩子, 实际: %q", asyncHeader.Get("X-Shared"))
+	}
+	if syncBody["new"] != "value" || asyncBody["new"] != "value" {
+		t.Errorf("修改请求体的钩子应在两种模式下生效: 同步=%v, 异步=%v", syncBody, asyncBody)
+	}
+}
+
+// TestAsyncHooksConcurrent 测试独立钩子并发执行
+func TestAsyncHooksConcurrent(t *testing.T) {
+	delay := 100 * time.Millisecond
+	start := time.Now()
+	header, _ := runPipeline(t, true,
+		independentHeaderHook("X-1", "1", delay),
+		independentHeaderHook("X-2", "2", delay),
+		independentHeaderHook("X-3", "3", delay),
+	)
+	elapsed := time.Since(start)
+
+	if elapsed > 250*time.Millisecond {
+		t.Errorf("独立钩子应并发执行, 实际耗时: %v", elapsed)
+	}
+	for _, key := range []string{"X-1", "X-2", "X-3"} {
+		if header.Get(key) == "" {
+			t.Errorf("缺少头部: %s", key)
+		}
+	}
+}
+
+// TestAsyncAfterHooks 测试并发执行的响应后钩子合并响应头
+func TestAsyncAfterHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Remove", "yes")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second, WithAsyncHooks())
+	defer c.Close()
+	c.AddAfterHook(&hooks.CustomFunctionHook{
+		Independent: true,
+		AfterFn: func(resp *http.Response) (*http.Response, error) {
+			resp.Header.Set("X-One", "1")
+			return resp, nil
+		},
+	})
+	c.AddAfterHook(&hooks.CustomFunctionHook{
+		Independent: true,
+		AfterFn: func(resp *http.Response) (*http.Response, error) {
+			resp.Header.Del("X-Remove")
+			return resp, nil
+		},
+	})
+
+	resp, err := c.Get("/")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("X-One") != "1" {
+		t.Errorf("应合并新增的响应头")
+	}
+	if resp.Header.Get("X-Remove") != "" {
+		t.Errorf("应合并删除的响应头")
+	}
+}
+
+// TestHooksReturningNil 测试钩子返回空请求或空响应时返回错误，同步和并发执行时一致
+func TestHooksReturningNil(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	nilBefore := &hooks.CustomFunctionHook{
+		Independent: true,
+		BeforeFn: func(req *http.Request) (*http.Request, error) {
+			return nil, nil
+		},
+	}
+	nilAfter := &hooks.CustomFunctionHook{
+		Independent: true,
+		AfterFn: func(resp *http.Response) (*http.Response, error) {
+			return nil, nil
+		},
+	}
+
+	for _, async := range []bool{false, true} {
+		var opts []ClientOption
+		if async {
+			opts = append(opts, WithAsyncHooks())
+		}
+
+		c := NewClient(server.URL, 5*time.Second, opts...)
+		c.AddBeforeHook(independentHeaderHook("X-1", "1", 0))
+		c.AddBeforeHook(nilBefore)
+		if _, err := c.Get("/"); err == nil || !strings.Contains(err.Error(), "空请求") {
+			t.Errorf("异步=%v 钩子返回空请求时应返回错误，实际: %v", async, err)
+		}
+		c.Close()
+
+		c = NewClient(server.URL, 5*time.Second, opts...)
+		c.AddAfterHook(&hooks.CustomFunctionHook{
+			Independent: true,
+			AfterFn: func(resp *http.Response) (*http.Response, error) {
+				return resp, nil
+			},
+		})
+		c.AddAfterHook(nilAfter)
+		if _, err := c.Get("/"); err == nil || !strings.Contains(err.Error(), "空响应") {
+			t.Errorf("异步=%v 钩子返回空响应时应返回错误，实际: %v", async, err)
+		}
+		c.Close()
+	}
+}
+
+// timeoutHook 配置了超时时间的钩子，执行时阻塞到release关闭
+type timeoutHook struct {
+	*hooks.CustomFunctionHook
+	timeoutSeconds int
+}
+
+// GetConfig 实现hooks.Hook
+func (h *timeoutHook) GetConfig() *hooks.HookConfig {
+	return &hooks.HookConfig{Type: "test", TimeoutSeconds: h.timeoutSeconds}
+}
+
+// TestHookTimeout 测试钩子执行超过其配置的超时时间时请求被中止
+func TestHookTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	slow := &timeoutHook{
+		CustomFunctionHook: hooks.NewCustomFunctionHook(
+			func(req *http.Request) (*http.Request, error) {
+				<-release
+				return req, nil
+			},
+			func(resp *http.Response) (*http.Response, error) {
+				<-release
+				return resp, nil
+			},
+		),
+		timeoutSeconds: 1,
+	}
+	fast := &timeoutHook{CustomFunctionHook: independentHeaderHook("X-Fast", "1", 0), timeoutSeconds: 1}
+
+	// 执行未超时的钩子正常返回
+	c := NewClient(server.URL, 10*time.Second)
+	c.AddBeforeHook(fast)
+	resp, err := c.Get("/fast")
+	if err != nil {
+		t.Fatalf("未超时的钩子不应中止请求: %v", err)
+	}
+	resp.Body.Close()
+
+	for name, setup := range map[string]func(c *Client){
+		"请求前钩子": func(c *Client) { c.AddBeforeHook(slow) },
+		"响应后钩子": func(c *Client) { c.AddAfterHook(slow) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := NewClient(server.URL, 10*time.Second)
+			setup(c)
+
+			start := time.Now()
+			_, err := c.Get("/slow")
+			if !errors.Is(err, hooks.ErrHookTimeout) || !errors.Is(err, ErrHookFailed) {
+				t.Fatalf("应返回钩子超时错误，实际: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("应在钩子超时后立即中止，实际耗时: %v", elapsed)
+			}
+		})
+	}
+}
+
+// configHook 通过GetConfig声明名称和异步标志的钩子，记录实际被调用的方法
+type configHook struct {
+	config hooks.HookConfig
+	err    error
+
+	mutex sync.Mutex
+	calls []string
+}
+
+// GetConfig 实现hooks.Hook
+func (h *configHook) GetConfig() *hooks.HookConfig {
+	return &h.config
+}
+
+// record 记录一次调用
+func (h *configHook) record(call string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.calls = append(h.calls, call)
+}
+
+// Before 同步执行
+func (h *configHook) Before(req *http.Request) (*http.Request, error) {
+	h.record("Before")
+	return req, h.err
+}
+
+// BeforeAsync 异步执行
+func (h *configHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
+	h.record("BeforeAsync")
+	reqChan := make(chan *http.Request, 1)
+	errChan := make(chan error, 1)
+	go func() {
+		if h.err != nil {
+			errChan <- h.err
+			return
+		}
+		reqChan <- req
+	}()
+	return reqChan, errChan
+}
+
+// After 同步执行
+func (h *configHook) After(resp *http.Response) (*http.Response, error) {
+	h.record("After")
+	return resp, h.err
+}
+
+// AfterAsync 异步执行
+func (h *configHook) AfterAsync(resp *http.Response) (chan *http.Response, chan error) {
+	h.record("AfterAsync")
+	respChan := make(chan *http.Response, 1)
+	errChan := make(chan error, 1)
+	go func() {
+		if h.err != nil {
+			errChan <- h.err
+			return
+		}
+		respChan <- resp
+	}()
+	return respChan, errChan
+}
+
+// TestHookConfig 测试客户端按钩子配置的异步标志和名称执行钩子
+func TestHookConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		async bool
+		calls string
+	}{
+		{false, "Before,After"},
+		{true, "BeforeAsync,AfterAsync"},
+	} {
+		hook := &configHook{config: hooks.HookConfig{Type: "test", Async: tt.async}}
+		c := NewClient(server.URL, 5*time.Second)
+		c.AddBeforeHook(hook)
+		c.AddAfterHook(hook)
+		resp, err := c.Get("/")
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		resp.Body.Close()
+		if calls := strings.Join(hook.calls, ","); calls != tt.calls {
+			t.Errorf("Async=%v时调用的方法错误: %s", tt.async, calls)
+		}
+	}
+
+	// 异步钩子返回的错误同样中止请求，错误信息中包含钩子名称
+	failing := &configHook{config: hooks.HookConfig{Name: "signer", Async: true}, err: errors.New("签名失败")}
+	c := NewClient(server.URL, 5*time.Second)
+	c.AddBeforeHook(failing)
+	_, err := c.Get("/")
+	if !errors.Is(err, ErrHookFailed) || !strings.Contains(err.Error(), "钩子signer: 签名失败") {
+		t.Errorf("错误信息应包含钩子名称，实际: %v", err)
+	}
+
+	// 模板定义中的name写入钩子配置
+	templateJSON := `{
+		"request": {"method": "GET", "path": "/"},
+		"beforeHooks": [{"type": "js", "name": "authHook", "script": "function processRequest(request) { throw new Error('缺少令牌'); }"}]
+	}`
+	_, err = NewClient(server.URL, 5*time.Second).ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+	if err == nil || !strings.Contains(err.Error(), "钩子authHook") {
+		t.Errorf("模板钩子的错误信息应包含钩子名称，实际: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// WithProxy 通过指定的HTTP代理发送请求
+// 代理设置在底层*http.Transport上，通过WithTransport设置了其他类型的传输层时不生效
+func WithProxy(proxy *url.URL) ClientOption {
+	return func(c *Client) {
+		c.proxyURL = proxy
+		c.applyProxy()
+	}
+}
+
+// WithProxyBasicAuth 设置代理的Basic认证，请求经过代理时发送Proxy-Authorization请求头
+// 未通过WithProxy指定代理时使用环境变量（HTTP_PROXY等）中的代理；
+// 认证信息只发送给代理，不会出现在发往目标服务器的请求中（HTTPS请求在CONNECT时发送）
+func WithProxyBasicAuth(username, password string) ClientOption {
+	return func(c *Client) {
+		c.proxyAuth = url.UserPassword(username, password)
+		c.applyProxy()
+	}
+}
+
+// cloneTransport 复制当前的*http.Transport（未设置时为http.DefaultTransport）用于修改，
+// 避免修改调用方传入的传输层；传输层为其他类型时返回false
+func (c *Client) cloneTransport() (*http.Transport, bool) {
+	switch t := c.client.Transport.(type) {
+	case nil:
+		return http.DefaultTransport.(*http.Transport).Clone(), true
+	case *http.Transport:
+		return t.Clone(), true
+	default:
+		return nil, false
+	}
+}
+
+// applyProxy 将代理地址和认证信息设置到传输层
+func (c *Client) applyProxy() {
+	transport, ok := c.cloneTransport()
+	if !ok {
+		return
+	}
+
+	proxyURL, proxyAuth := c.proxyURL, c.proxyAuth
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		proxy := proxyURL
+		if proxy == nil {
+			var err error
+			if proxy, err = http.ProxyFromEnvironment(req); err != nil || proxy == nil {
+				return proxy, err
+			}
+		}
+		if proxyAuth == nil {
+			return proxy, nil
+		}
+
+		// 传输层根据代理URL中的用户信息生成Proxy-Authorization
+		withAuth := *proxy
+		withAuth.User = proxyAuth
+		return &withAuth, nil
+	}
+	c.client.Transport = transport
+}
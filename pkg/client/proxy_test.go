@@ -0,0 +1,41 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestProxyBasicAuth 测试经过代理的请求携带编码后的Proxy-Authorization
+func TestProxyBasicAuth(t *testing.T) {
+	var gotAuth, gotURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 代理收到的请求行是完整URL
+		gotAuth = r.Header.Get("Proxy-Authorization")
+		gotURL = r.URL.String()
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("解析代理地址失败: %v", err)
+	}
+
+	c := NewClient("http://api.example.invalid", 5*time.Second,
+		WithProxy(proxyURL), WithProxyBasicAuth("user", "pass"))
+	resp, err := c.Get("/users")
+	if err != nil {
+		t.Fatalf("通过代理发送请求失败: %v", err)
+	}
+	closeResponseBody(resp)
+
+	if gotAuth != "Basic dXNlcjpwYXNz" {
+		t.Errorf("代理应收到编码后的认证头，实际: %q", gotAuth)
+	}
+	if gotURL != "http://api.example.invalid/users" {
+		t.Errorf("请求应发往代理并保留目标地址，实际: %q", gotURL)
+	}
+}
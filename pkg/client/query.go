@@ -0,0 +1,36 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// SetDefaultQueryParam 设置添加到每个请求URL中的默认查询参数，例如api_version=2
+// 请求URL中已有同名参数时保留请求自身的值
+func (c *Client) SetDefaultQueryParam(key, value string) {
+	if c.queryParams == nil {
+		c.queryParams = make(map[string]string)
+	}
+	c.queryParams[key] = value
+}
+
+// applyDefaultQuery 将请求URL中缺少的默认查询参数追加到已有参数之后，不改变已有参数的顺序
+func (c *Client) applyDefaultQuery(req *http.Request) {
+	if len(c.queryParams) == 0 {
+		return
+	}
+	existing := req.URL.Query()
+	missing := make(url.Values)
+	for key, value := range c.queryParams {
+		if _, ok := existing[key]; !ok {
+			missing.Set(key, value)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	if req.URL.RawQuery != "" {
+		req.URL.RawQuery += "&"
+	}
+	req.URL.RawQuery += missing.Encode()
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestDefaultQueryParams 测试默认查询参数添加到未设置该参数的请求中
+func TestDefaultQueryParams(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetDefaultQueryParam("api_version", "2")
+
+	for _, path := range []string{"/users", "/users?page=1", "/users?api_version=3"} {
+		resp, err := c.Get(path)
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		closeResponseBody(resp)
+	}
+	resp, err := c.ExecuteTemplateJSON(context.Background(), `{"request": {"method": "GET", "path": "/users?page=2"}}`, nil)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	closeResponseBody(resp)
+
+	expected := []string{
+		"api_version=2",
+		"page=1&api_version=2",
+		"api_version=3",
+		"page=2&api_version=2",
+	}
+	if len(queries) != len(expected) {
+		t.Fatalf("期望%d个请求，实际: %d", len(expected), len(queries))
+	}
+	for i, want := range expected {
+		if queries[i] != want {
+			t.Errorf("第%d个请求期望查询参数: %s，实际: %s", i+1, want, queries[i])
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// rawBodyKey 原始响应体在请求上下文中的键
+type rawBodyKey struct{}
+
+// SetKeepOriginalResponse 设置是否保留响应后钩子处理前的原始响应体
+// 启用后可通过RawBody或NewResponseFromHTTP返回的Response.RawBody获取原始响应体
+func (c *Client) SetKeepOriginalResponse(keep bool) {
+	c.keepOriginalResponse = keep
+}
+
+// RawBody 返回响应后钩子处理前的原始响应体
+// 仅在启用SetKeepOriginalResponse时可用，否则第二个返回值为false
+func RawBody(resp *http.Response) ([]byte, bool) {
+	if resp == nil || resp.Request == nil {
+		return nil, false
+	}
+	raw, ok := resp.Request.Context().Value(rawBodyKey{}).([]byte)
+	return raw, ok
+}
+
+// captureRawBody 读取并重置响应体，返回原始字节
+func captureRawBody(resp *http.Response) ([]byte, error) {
+	if resp.Body == nil {
+		return []byte{}, nil
+	}
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+	return raw, nil
+}
+
+// attachRawBody 将原始响应体保存到响应关联请求的上下文中
+// 钩子返回的新响应可能没有关联请求，此时使用原始请求
+func attachRawBody(resp *http.Response, req *http.Request, raw []byte) {
+	if resp.Request != nil {
+		req = resp.Request
+	}
+	if req == nil {
+		req = &http.Request{}
+	}
+	resp.Request = req.WithContext(context.WithValue(req.Context(), rawBodyKey{}, raw))
+}
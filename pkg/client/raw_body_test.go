@@ -0,0 +1,64 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// TestKeepOriginalResponse 测试保留钩子处理前的原始响应体
+func TestKeepOriginalResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"name": "zhangsan"}`))
+	}))
+	defer server.Close()
+
+	// 将响应体转换为大写的钩子
+	upperHook := &hooks.CustomFunctionHook{
+		AfterFn: func(resp *http.Response) (*http.Response, error) {
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(bytes.ToUpper(body)))
+			return resp, nil
+		},
+	}
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.AddAfterHook(upperHook)
+
+	// 默认不保留
+	resp, err := c.Get("/")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if _, ok := RawBody(resp); ok {
+		t.Error("未启用时不应保留原始响应体")
+	}
+	resp.Body.Close()
+
+	c.SetKeepOriginalResponse(true)
+	resp, err = c.Get("/")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	result, err := NewResponseFromHTTP(resp)
+	if err != nil {
+		t.Fatalf("读取响应失败: %v", err)
+	}
+	if string(result.Body) != `{"NAME": "ZHANGSAN"}` {
+		t.Errorf("转换后的响应体错误: %s", result.Body)
+	}
+	if string(result.RawBody) != `{"name": "zhangsan"}` {
+		t.Errorf("原始响应体错误: %s", result.RawBody)
+	}
+}
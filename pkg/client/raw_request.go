@@ -0,0 +1,40 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// defaultRawContentType 未指定Content-Type时原始字节请求体使用的类型
+const defaultRawContentType = "application/octet-stream"
+
+// PostRaw 以指定的Content-Type发送原始字节请求体，用于protobuf、图片、CSV等非JSON内容
+// contentType覆盖默认请求头中的Content-Type，为空时使用application/octet-stream；
+// 请求体按原样发送：nil不会被替换为{}，请求通过hooks.WithRawBody标记，字段转换等JSON相关的钩子会跳过
+func (c *Client) PostRaw(path string, body []byte, contentType string) (*http.Response, error) {
+	return c.requestRaw(http.MethodPost, path, body, contentType)
+}
+
+// PutRaw 以指定的Content-Type发送原始字节请求体的PUT请求，规则与PostRaw相同
+func (c *Client) PutRaw(path string, body []byte, contentType string) (*http.Response, error) {
+	return c.requestRaw(http.MethodPut, path, body, contentType)
+}
+
+// requestRaw 创建并发送原始字节请求体的请求
+func (c *Client) requestRaw(method, path string, body []byte, contentType string) (*http.Response, error) {
+	if body == nil {
+		// 显式的空请求体不会被替换为{}
+		body = []byte{}
+	}
+	if contentType == "" {
+		contentType = defaultRawContentType
+	}
+
+	req, err := c.newRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.do(req.WithContext(hooks.WithRawBody(req.Context())))
+}
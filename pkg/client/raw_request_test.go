@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// TestRawRequest 测试以指定的Content-Type原样发送字节请求体
+func TestRawRequest(t *testing.T) {
+	var gotMethod, gotContentType string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotContentType = r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetHeader("Content-Type", "application/json")
+	c.AddBeforeHook(hooks.NewFieldTransformHook(map[string]string{"name": "full_name"}))
+	c.AddBeforeHook(hooks.NewUnflattenBodyHook())
+	c.AddBeforeHook(hooks.NewNormalizeBodyHook())
+
+	cases := []struct {
+		name        string
+		send        func() (*http.Response, error)
+		method      string
+		contentType string
+		body        []byte
+	}{
+		{"二进制内容", func() (*http.Response, error) {
+			return c.PostRaw("/upload", []byte{0x00, 0xff, 0x1f, 0x8b, '\n'}, "application/octet-stream")
+		}, http.MethodPost, "application/octet-stream", []byte{0x00, 0xff, 0x1f, 0x8b, '\n'}},
+		{"看起来像JSON的内容不被转换", func() (*http.Response, error) {
+			return c.PutRaw("/files/1", []byte(`{"name": "a", "user.id": 1}`), "application/octet-stream")
+		}, http.MethodPut, "application/octet-stream", []byte(`{"name": "a", "user.id": 1}`)},
+		{"CSV", func() (*http.Response, error) {
+			return c.PostRaw("/import", []byte("id,name\n1,张三\n"), "text/csv; charset=utf-8")
+		}, http.MethodPost, "text/csv; charset=utf-8", []byte("id,name\n1,张三\n")},
+		{"未指定类型且请求体为nil", func() (*http.Response, error) {
+			return c.PostRaw("/empty", nil, "")
+		}, http.MethodPost, "application/octet-stream", []byte{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := tc.send()
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			resp.Body.Close()
+
+			if gotMethod != tc.method {
+				t.Errorf("请求方法错误: %s", gotMethod)
+			}
+			if gotContentType != tc.contentType {
+				t.Errorf("Content-Type错误: %s", gotContentType)
+			}
+			if !bytes.Equal(gotBody, tc.body) {
+				t.Errorf("请求体应原样发送, 实际: %q", gotBody)
+			}
+		})
+	}
+
+	// 普通的Post仍按JSON处理
+	resp, err := c.Post("/users", []byte(`{"name": "a"}`))
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if string(gotBody) != `{"full_name":"a"}` {
+		t.Errorf("Post的请求体应经过字段转换, 实际: %s", gotBody)
+	}
+}
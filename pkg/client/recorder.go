@@ -0,0 +1,174 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// recordedExchange 录制文件中的一次请求/响应
+type recordedExchange struct {
+	Key         string      `json:"key"`
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"requestBody,omitempty"`
+	StatusCode  int         `json:"statusCode"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        string      `json:"body"`
+}
+
+// exchangeKey 根据方法、URL和请求体哈希生成录制键
+func exchangeKey(method, url string, body []byte) string {
+	return fmt.Sprintf("%s %s %x", strings.ToUpper(method), url, sha256.Sum256(body))
+}
+
+// readRequestBodyForRecord 读取并重置请求体
+func readRequestBodyForRecord(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// RecordingTransport 录制传输层，将经过的请求/响应写入JSON文件，供ReplayTransport回放
+// 每次请求完成后都会重写整个文件，适合测试和离线演示
+type RecordingTransport struct {
+	path      string
+	next      http.RoundTripper
+	mutex     sync.Mutex
+	exchanges []recordedExchange
+}
+
+// NewRecordingTransport 创建录制传输层，next为nil时使用http.DefaultTransport
+func NewRecordingTransport(path string, next http.RoundTripper) *RecordingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RecordingTransport{path: path, next: next}
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBodyForRecord(req)
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ReadResponseBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	exchange := recordedExchange{
+		Key:         exchangeKey(req.Method, req.URL.String(), reqBody),
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: string(reqBody),
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header.Clone(),
+		Body:        string(respBody),
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.exchanges = append(t.exchanges, exchange)
+	if err := t.save(); err != nil {
+		closeResponseBody(resp)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save 将录制内容写入文件
+func (t *RecordingTransport) save() error {
+	data, err := json.MarshalIndent(t.exchanges, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化录制内容失败: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return fmt.Errorf("写入录制文件失败: %w", err)
+	}
+	return nil
+}
+
+// ReplayTransport 回放传输层，根据方法+URL+请求体哈希从录制文件中返回响应，不访问网络
+// 同一请求录制了多次时按录制顺序依次返回，用完后重复返回最后一次
+type ReplayTransport struct {
+	mutex     sync.Mutex
+	exchanges map[string][]recordedExchange
+}
+
+// NewReplayTransport 从录制文件创建回放传输层
+func NewReplayTransport(path string) (*ReplayTransport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取录制文件失败: %w", err)
+	}
+
+	var exchanges []recordedExchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, fmt.Errorf("解析录制文件失败: %w", err)
+	}
+
+	t := &ReplayTransport{exchanges: make(map[string][]recordedExchange)}
+	for _, exchange := range exchanges {
+		t.exchanges[exchange.Key] = append(t.exchanges[exchange.Key], exchange)
+	}
+	return t, nil
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBodyForRecord(req)
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	key := exchangeKey(req.Method, req.URL.String(), reqBody)
+
+	t.mutex.Lock()
+	queue := t.exchanges[key]
+	if len(queue) == 0 {
+		t.mutex.Unlock()
+		return nil, fmt.Errorf("回放记录中不存在请求: %s %s", req.Method, req.URL)
+	}
+	exchange := queue[0]
+	if len(queue) > 1 {
+		t.exchanges[key] = queue[1:]
+	}
+	t.mutex.Unlock()
+
+	header := exchange.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+		StatusCode:    exchange.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(exchange.Body)),
+		ContentLength: int64(len(exchange.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRecordReplay 测试录制后在服务器关闭的情况下回放
+func TestRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Server", "live")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"echo": ` + string(body) + `}`))
+	}))
+	baseURL := server.URL
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+
+	// 录制
+	recorder := NewClient(baseURL, 5*time.Second, WithTransport(NewRecordingTransport(fixture, nil)))
+	resp, err := recorder.Post("/api/users", []byte(`{"name":"张三"}`))
+	if err != nil {
+		t.Fatalf("录制请求失败: %v", err)
+	}
+	recorded, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	server.Close()
+
+	// 回放
+	replay, err := NewReplayTransport(fixture)
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	player := NewClient(baseURL, 5*time.Second, WithTransport(replay))
+
+	resp, err = player.Post("/api/users", []byte(`{"name":"张三"}`))
+	if err != nil {
+		t.Fatalf("回放请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	replayed, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("回放状态码错误: %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Server") != "live" {
+		t.Errorf("回放响应头错误: %v", resp.Header)
+	}
+	if string(replayed) != string(recorded) {
+		t.Errorf("回放响应体不一致，录制: %s, 回放: %s", recorded, replayed)
+	}
+
+	// 请求体不同，没有对应记录
+	if _, err := player.Post("/api/users", []byte(`{"name":"李四"}`)); err == nil {
+		t.Error("未录制的请求应返回错误")
+	}
+}
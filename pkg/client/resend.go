@@ -0,0 +1,58 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// captureRequest 记录产生响应的请求信息
+// 请求体通过GetBody重新获取，请求体已被读取且无法重新获取时RequestBody为空
+func captureRequest(r *Response, req *http.Request) {
+	if req == nil {
+		return
+	}
+
+	r.RequestMethod = req.Method
+	r.RequestURL = req.URL.String()
+	r.RequestHeaders = req.Header.Clone()
+	if req.GetBody == nil {
+		return
+	}
+	if body, err := req.GetBody(); err == nil {
+		r.RequestBody, _ = io.ReadAll(body)
+		body.Close()
+	}
+}
+
+// Resend 重新发送产生r的请求，常用于轮询
+// 使用r中记录的方法、完整URL、请求头和请求体，并像普通请求一样执行全局钩子；
+// r必须由NewResponseFromHTTP创建，缺少请求信息时返回错误
+func (c *Client) Resend(r *Response) (*http.Response, error) {
+	if r == nil || r.RequestMethod == "" || r.RequestURL == "" {
+		return nil, fmt.Errorf("响应中没有可重新发送的请求信息")
+	}
+
+	var body io.Reader
+	if len(r.RequestBody) > 0 {
+		body = bytes.NewReader(r.RequestBody)
+	}
+	req, err := http.NewRequest(r.RequestMethod, r.RequestURL, body)
+	if err != nil {
+		return nil, utils.WrapSentinel(ErrRequestFailed, fmt.Errorf("创建请求失败: %w", err))
+	}
+	for key, values := range r.RequestHeaders {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	// 重新渲染模板化的默认请求头，避免沿用上次请求的值
+	if c.templatedHeaders {
+		if err := c.setDefaultHeaders(req); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.do(req)
+}
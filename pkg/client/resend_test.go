@@ -0,0 +1,64 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestResend 测试通过Resend重新发送产生响应的请求进行轮询
+func TestResend(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s %s", r.Method, r.URL.RequestURI(), r.Header.Get("X-Job"), body))
+		fmt.Fprintf(w, `{"attempt": %d}`, len(requests))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetHeader("X-Job", "42")
+
+	resp, err := c.Post("/jobs/status?id=42", []byte(`{"wait": true}`))
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	result, err := NewResponseFromHTTP(resp)
+	if err != nil {
+		t.Fatalf("读取响应失败: %v", err)
+	}
+	if result.RequestMethod != http.MethodPost || result.RequestURL != server.URL+"/jobs/status?id=42" {
+		t.Errorf("请求信息错误: %s %s", result.RequestMethod, result.RequestURL)
+	}
+
+	// 轮询两次
+	for i := 0; i < 2; i++ {
+		resp, err := c.Resend(result)
+		if err != nil {
+			t.Fatalf("重新发送失败: %v", err)
+		}
+		polled, err := NewResponseFromHTTP(resp)
+		if err != nil {
+			t.Fatalf("读取响应失败: %v", err)
+		}
+		if expected := fmt.Sprintf(`{"attempt": %d}`, i+2); string(polled.Body) != expected {
+			t.Errorf("第%d次轮询响应错误: %s", i+1, polled.Body)
+		}
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("期望3次请求，实际: %d", len(requests))
+	}
+	for i, req := range requests {
+		if req != `POST /jobs/status?id=42 42 {"wait": true}` {
+			t.Errorf("第%d次请求与原请求不同: %s", i+1, req)
+		}
+	}
+
+	if _, err := c.Resend(&Response{Body: []byte("{}")}); err == nil {
+		t.Error("缺少请求信息时应返回错误")
+	}
+}
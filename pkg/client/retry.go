@@ -0,0 +1,279 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// RetryPolicy 重试策略，决定失败的请求是否可以重放
+// 是否重试由请求方法、错误类型和响应状态码共同决定：
+// 只有幂等请求（GET/HEAD/PUT/DELETE/OPTIONS/TRACE或带有Idempotency-Key头的请求）会被重试，
+// 且仅在发生可重试的网络错误、返回可重试的状态码或响应体满足RetryOnBody时重试
+type RetryPolicy struct {
+	// RetryNonIdempotent 为true时POST/PATCH等非幂等请求也会重试
+	RetryNonIdempotent bool
+	// RetryStatusCodes 触发重试的响应状态码，为空时使用默认值（429、502、503、504）
+	RetryStatusCodes []int
+	// RetryOnBody 不为nil时检查响应体，返回true时重试，用于状态码为200但响应体是错误信封的接口；
+	// 可以使用RetryOnJSONPath构造。模板中的retry.retryOnBody优先
+	RetryOnBody func(body []byte) bool
+}
+
+// RetryConfig 模板请求的重试配置，对应模板中的retry
+type RetryConfig struct {
+	Enabled       bool `json:"enabled"`
+	MaxAttempts   int  `json:"maxAttempts"`
+	InitialDelay  int  `json:"initialDelay"`         // 初始延迟（毫秒）
+	BackoffFactor int  `json:"backoffFactor"`        // 退避因子
+	MaxElapsed    int  `json:"maxElapsed,omitempty"` // 包括等待在内的总时间上限（毫秒），<=0表示不限制
+
+	RetryOnBody *BodyRetryCondition `json:"retryOnBody,omitempty"` // 响应体满足条件时重试，覆盖RetryPolicy.RetryOnBody
+}
+
+// BodyRetryCondition 按JSONPath检查响应体的重试条件，对应模板中的retry.retryOnBody
+type BodyRetryCondition struct {
+	Path   string        `json:"path"`             // JSONPath表达式，如$.status
+	Values []interface{} `json:"values,omitempty"` // 取到的值等于其中任意一个时重试；为空时值存在且不为null、false、""即重试
+}
+
+// Match 判断响应体是否满足重试条件，响应体不是JSON或路径不存在时不满足
+func (cond *BodyRetryCondition) Match(body []byte) bool {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return false
+	}
+	value, err := utils.JSONPath(data, cond.Path)
+	if err != nil {
+		return false
+	}
+
+	if len(cond.Values) == 0 {
+		return value != nil && value != false && value != ""
+	}
+	// 按JSON编码比较，使Go中的1与响应体中的1.0等写法视为相同
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	for _, expected := range cond.Values {
+		if want, err := json.Marshal(expected); err == nil && bytes.Equal(encoded, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryOnJSONPath 返回用于RetryPolicy.RetryOnBody的条件：响应体中path处的值等于values中任意一个时重试，
+// values为空时值存在且不为null、false、""即重试。例如RetryOnJSONPath("$.status", "error")
+func RetryOnJSONPath(path string, values ...interface{}) func(body []byte) bool {
+	cond := &BodyRetryCondition{Path: path, Values: values}
+	return cond.Match
+}
+
+// defaultRetryStatusCodes 默认触发重试的状态码
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// WithRetryPolicy 设置模板请求的重试策略
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithDefaultRetry 设置模板请求的默认重试配置，模板没有retry配置时使用
+// 模板中写了retry（包括"enabled": false）时以模板为准；是否重试仍由RetryPolicy决定
+func WithDefaultRetry(cfg RetryConfig) ClientOption {
+	return func(c *Client) {
+		c.defaultRetry = cfg
+	}
+}
+
+// isIdempotent 判断请求是否可以安全地重放
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	// 带有幂等键的请求由服务端保证只执行一次；IdempotencyHook会在上下文中记录使用的请求头名称，
+	// 因此使用自定义请求头名称时同样视为幂等。只看实际发送的请求头，上下文中仅有幂等键时不视为幂等
+	if header, ok := hooks.IdempotencyHeaderFromContext(req.Context()); ok && req.Header.Get(header) != "" {
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// shouldRetry 根据请求方法、错误、响应状态码和响应体判断是否需要重试
+// retryOnBody不为nil时会读取响应体检查，之后响应体仍可以从头读取
+func (c *Client) shouldRetry(req *http.Request, resp *http.Response, err error, retryOnBody func([]byte) bool) bool {
+	if !c.retryPolicy.RetryNonIdempotent && !isIdempotent(req) {
+		return false
+	}
+
+	if err != nil {
+		return isRetryableError(err)
+	}
+
+	codes := c.retryPolicy.RetryStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryStatusCodes
+	}
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return retryOnBody != nil && responseBodyMatches(resp, retryOnBody)
+}
+
+// responseBodyMatches 读取响应体并检查是否满足条件，读取的内容放回响应体供后续读取
+// 读取失败时不重试，读取错误在调用方再次读取响应体时返回
+func responseBodyMatches(resp *http.Response, match func([]byte) bool) bool {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errorReader{err}))
+		return false
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return match(body)
+}
+
+// isRetryableError 根据错误类型判断网络错误是否可重试
+// 连接被拒绝或重置、连接中途断开（EOF）、超时和DNS临时故障可以重试；
+// 请求被取消以及钩子、模板等非网络错误不重试
+func isRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	for _, errno := range retryableErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryableErrnos 可以重试的系统调用错误
+var retryableErrnos = []error{
+	syscall.ECONNREFUSED,
+	syscall.ECONNRESET,
+	syscall.ECONNABORTED,
+	syscall.EPIPE,
+	syscall.ETIMEDOUT,
+	syscall.EMFILE,
+	syscall.ENFILE,
+}
+
+// sleepContext 等待d，ctx结束时提前返回ctx的错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryBudgetExceeded 判断等待delay后再重试是否会超过时间上限或上下文的截止时间，超过时返回原因
+func retryBudgetExceeded(ctx context.Context, start time.Time, maxElapsed, delay time.Duration) string {
+	next := time.Now().Add(delay)
+	if maxElapsed > 0 && next.Sub(start) >= maxElapsed {
+		return fmt.Sprintf("重试时间上限(%v)已用尽", maxElapsed)
+	}
+	if deadline, ok := ctx.Deadline(); ok && !next.Before(deadline) {
+		return "上下文截止时间已到"
+	}
+	return ""
+}
+
+// WithBodyReadRetry 响应体读取中断时重新发送幂等请求
+// 启用后幂等请求的响应体会在返回前完整读入内存，读取失败或长度小于Content-Length
+// （例如连接在传输中途被重置）时重新发送请求，最多尝试maxAttempts次
+func WithBodyReadRetry(maxAttempts int) ClientOption {
+	return func(c *Client) {
+		c.bodyReadAttempts = maxAttempts
+	}
+}
+
+// send 发送请求，设置了WithTokenRefresher时在401后刷新令牌并重放
+func (c *Client) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	if c.tokenRefresher != nil {
+		return c.sendWithTokenRefresh(client, req)
+	}
+	return c.transmit(client, req)
+}
+
+// transmit 发送请求并应用响应体大小限制，启用WithBodyReadRetry时确保幂等请求的响应体完整
+func (c *Client) transmit(client *http.Client, req *http.Request) (*http.Response, error) {
+	if c.bodyReadAttempts <= 1 || !isIdempotent(req) {
+		resp, err := c.roundTrip(client, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = hooks.LimitBody(resp.Body, c.maxResponseBytes)
+		return resp, nil
+	}
+
+	// 保存请求体用于重新发送
+	reqBody, err := hooks.ReadRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.Clone(req.Context())
+		attemptReq.Body = io.NopCloser(bytes.NewReader(reqBody))
+		attemptReq.ContentLength = int64(len(reqBody))
+
+		resp, err := c.roundTrip(client, attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		body, readErr := io.ReadAll(hooks.LimitBody(resp.Body, c.maxResponseBytes))
+		resp.Body.Close()
+		if readErr == nil && resp.ContentLength >= 0 && int64(len(body)) < resp.ContentLength {
+			readErr = io.ErrUnexpectedEOF
+		}
+
+		switch {
+		case readErr == nil:
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return resp, nil
+		case errors.Is(readErr, hooks.ErrResponseTooLarge):
+			// 超过大小限制不是传输中断，重试没有意义
+			return nil, readErr
+		case attempt >= c.bodyReadAttempts:
+			return nil, fmt.Errorf("读取响应体不完整，已尝试%d次: %w", attempt, readErr)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// TestRetryPolicy 测试重试只作用于幂等请求
+func TestRetryPolicy(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	templateFor := func(method string) string {
+		return `{
+			"request": {"method": "` + method + `", "path": "/api/orders"},
+			"retry": {"enabled": true, "maxAttempts": 3, "initialDelay": 10, "backoffFactor": 1}
+		}`
+	}
+
+	cases := []struct {
+		name     string
+		policy   RetryPolicy
+		method   string
+		expected int32
+	}{
+		{"默认不重试POST", RetryPolicy{}, "POST", 1},
+		{"默认重试GET", RetryPolicy{}, "GET", 3},
+		{"强制重试POST", RetryPolicy{RetryNonIdempotent: true}, "POST", 3},
+		{"状态码不在重试列表中", RetryPolicy{RetryStatusCodes: []int{http.StatusBadGateway}}, "GET", 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&attempts, 0)
+			c := NewClient(server.URL, 5*time.Second, WithRetryPolicy(tc.policy))
+			defer c.Close()
+
+			resp, err := c.ExecuteTemplateJSON(context.Background(), templateFor(tc.method), nil)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("应返回最后一次的响应，状态码: %d", resp.StatusCode)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tc.expected {
+				t.Errorf("请求次数错误，期望: %d, 实际: %d", tc.expected, got)
+			}
+		})
+	}
+
+	// 带有幂等键的POST可以重试
+	atomic.StoreInt32(&attempts, 0)
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+	c.SetHeader("Idempotency-Key", "order-1")
+	resp, err := c.ExecuteTemplateJSON(context.Background(), templateFor("POST"), nil)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("带有幂等键的POST应重试，请求次数: %d", got)
+	}
+}
+
+// TestRetryIdempotencyKey 测试幂等键钩子生成的键在重试中保持不变
+func TestRetryIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+	c.AddBeforeHook(hooks.NewIdempotencyHook())
+
+	tmpl := `{
+		"request": {"method": "POST", "path": "/api/orders"},
+		"retry": {"enabled": true, "maxAttempts": 3, "initialDelay": 10, "backoffFactor": 1}
+	}`
+	for _, ctx := range []context.Context{context.Background(), hooks.WithIdempotencyKey(context.Background(), "order-1")} {
+		resp, err := c.ExecuteTemplateJSON(ctx, tmpl, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("重试后应成功，状态码: %d", resp.StatusCode)
+		}
+	}
+
+	if len(keys) != 6 {
+		t.Fatalf("期望6次请求，实际: %d", len(keys))
+	}
+	if keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("同一逻辑请求的重试应使用相同的幂等键: %v", keys[:3])
+	}
+	for _, key := range keys[3:] {
+		if key != "order-1" {
+			t.Errorf("应使用指定的幂等键，实际: %v", keys[3:])
+			break
+		}
+	}
+}
+
+// TestRetryCustomIdempotencyHeader 测试使用自定义请求头名称的幂等键钩子时POST按默认策略重试
+func TestRetryCustomIdempotencyHeader(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("X-Request-Key"))
+		if len(keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+	c.AddBeforeHook(&hooks.IdempotencyHook{Header: "X-Request-Key"})
+
+	tmpl := `{
+		"request": {"method": "POST", "path": "/api/orders"},
+		"retry": {"enabled": true, "maxAttempts": 3, "initialDelay": 10, "backoffFactor": 1}
+	}`
+	resp, err := c.ExecuteTemplateJSON(context.Background(), tmpl, nil)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("重试后应成功，状态码: %d", resp.StatusCode)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("带有自定义幂等键请求头的POST应重试，请求次数: %d", len(keys))
+	}
+	if keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("重试应使用相同的幂等键: %v", keys)
+	}
+}
+
+// TestRetryContextKeyWithoutHook 测试上下文中有幂等键但没有发送幂等键请求头时POST不重试
+func TestRetryContextKeyWithoutHook(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tmpl := `{
+		"request": {"method": "POST", "path": "/api/orders"},
+		"retry": {"enabled": true, "maxAttempts": 3, "initialDelay": 10, "backoffFactor": 1}
+	}`
+	ctx := hooks.WithIdempotencyKey(context.Background(), "order-1")
+
+	// 未添加幂等键钩子，以及钩子的Methods不包含POST时，请求都不带幂等键请求头
+	for _, hook := range []hooks.BeforeRequestHook{nil, &hooks.IdempotencyHook{Methods: []string{http.MethodPatch}}} {
+		atomic.StoreInt32(&attempts, 0)
+		c := NewClient(server.URL, 5*time.Second)
+		if hook != nil {
+			c.AddBeforeHook(hook)
+		}
+
+		resp, err := c.ExecuteTemplateJSON(ctx, tmpl, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		resp.Body.Close()
+		c.Close()
+
+		if got := atomic.LoadInt32(&attempts); got != 1 {
+			t.Errorf("没有发送幂等键请求头的POST不应重试，请求次数: %d", got)
+		}
+	}
+}
+
+// TestIsRetryableError 测试按错误类型判断是否可重试
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"连接被重置", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"连接被拒绝", &url.Error{Op: "Get", URL: "http://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}, true},
+		{"连接中途断开", &url.Error{Op: "Get", URL: "http://example.com", Err: io.ErrUnexpectedEOF}, true},
+		{"服务端关闭连接", &url.Error{Op: "Get", URL: "http://example.com", Err: io.EOF}, true},
+		{"超时", &url.Error{Op: "Get", URL: "http://example.com", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, true},
+		{"请求被取消", &url.Error{Op: "Get", URL: "http://example.com", Err: context.Canceled}, false},
+		{"域名不存在", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, false},
+		{"错误信息包含关键字", errors.New("钩子执行失败: connection reset timeout EOF"), false},
+		{"无错误", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, 期望 %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRetryCancelDuringBackoff 测试等待重试期间取消请求会立即返回
+func TestRetryCancelDuringBackoff(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+
+	tmpl := `{
+		"request": {"method": "GET", "path": "/api/slow"},
+		"retry": {"enabled": true, "maxAttempts": 3, "initialDelay": 5000, "backoffFactor": 1}
+	}`
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(100*time.Millisecond, cancel)
+	defer timer.Stop()
+
+	start := time.Now()
+	_, err := c.ExecuteTemplateJSON(ctx, tmpl, nil)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望返回context.Canceled，实际: %v", err)
+	}
+	if elapsed >= 2*time.Second {
+		t.Errorf("取消后仍在等待重试，耗时: %v", elapsed)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("期望只请求1次，实际: %d", got)
+	}
+}
+
+// TestRetryMaxElapsed 测试重试在时间上限内停止，即使未达到最大尝试次数
+func TestRetryMaxElapsed(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+
+	// 等待时间依次为50、100、200毫秒，第三次请求后再等待会超过200毫秒的上限
+	templateJSON := `{
+		"request": {"method": "GET", "path": "/api/orders"},
+		"retry": {"enabled": true, "maxAttempts": 10, "initialDelay": 50, "backoffFactor": 2, "maxElapsed": 200}
+	}`
+	start := time.Now()
+	resp, err := c.ExecuteTemplateJSON(context.Background(), templateJSON, nil)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("应返回最后一次的响应，状态码: %d", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("应在时间上限内停止重试，期望请求3次，实际: %d", got)
+	}
+	if elapsed >= 200*time.Millisecond {
+		t.Errorf("总耗时不应超过时间上限: %v", elapsed)
+	}
+
+	// 上下文的截止时间同样限制重试：等待100毫秒后会超过120毫秒的截止时间
+	atomic.StoreInt32(&attempts, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	templateJSON = `{
+		"request": {"method": "GET", "path": "/api/orders"},
+		"retry": {"enabled": true, "maxAttempts": 10, "initialDelay": 50, "backoffFactor": 2}
+	}`
+	resp, err = c.ExecuteTemplateJSON(ctx, templateJSON, nil)
+	if err != nil {
+		t.Fatalf("截止前停止重试时应返回最后一次的响应，实际错误: %v", err)
+	}
+	resp.Body.Close()
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("应在上下文截止前停止重试，期望请求2次，实际: %d", got)
+	}
+}
+
+// TestBodyReadRetry 测试响应体传输中断时重新发送请求
+func TestBodyReadRetry(t *testing.T) {
+	const fullBody = `{"status": "complete"}`
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			// 第一次只发送部分响应体后断开连接
+			w.Header().Set("Content-Length", strconv.Itoa(len(fullBody)))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(fullBody[:5]))
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fullBody))
+	}))
+	defer server.Close()
+
+	// 未启用时得到不完整的响应体
+	c := NewClient(server.URL, 5*time.Second)
+	resp, err := c.Get("/")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if _, err := ReadResponseBody(resp); err == nil {
+		t.Error("未启用重试时应读取到不完整的响应体")
+	}
+
+	// 启用后重新发送请求得到完整响应体
+	atomic.StoreInt32(&attempts, 0)
+	c = NewClient(server.URL, 5*time.Second, WithBodyReadRetry(3))
+	resp, err = c.Get("/")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	body, err := ReadResponseBody(resp)
+	if err != nil || string(body) != fullBody {
+		t.Errorf("应得到完整响应体: %s, %v", body, err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("请求次数错误，期望: 2, 实际: %d", got)
+	}
+
+	// 非幂等请求不重新发送
+	atomic.StoreInt32(&attempts, 0)
+	resp, err = c.Post("/", []byte(`{}`))
+	if err == nil {
+		_, err = ReadResponseBody(resp)
+	}
+	if err == nil {
+		t.Error("非幂等请求不应重新发送")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("非幂等请求次数错误: %d", got)
+	}
+}
+
+// TestRetryOnBody 测试状态码为200但响应体是错误信封时按响应体重试
+func TestRetryOnBody(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 前两次返回错误信封，之后成功
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			w.Write([]byte(`{"status": "error", "message": "busy"}`))
+			return
+		}
+		w.Write([]byte(`{"status": "ok", "data": {"id": 1}}`))
+	}))
+	defer server.Close()
+
+	retryTemplate := func(retryOnBody string) string {
+		return `{
+			"request": {"method": "GET", "path": "/api/jobs"},
+			"retry": {"enabled": true, "maxAttempts": 5, "initialDelay": 10, "backoffFactor": 1` + retryOnBody + `}
+		}`
+	}
+
+	cases := []struct {
+		name       string
+		policy     RetryPolicy
+		template   string
+		expected   int32
+		lastStatus string
+	}{
+		{"默认不检查响应体", RetryPolicy{}, retryTemplate(""), 1, "error"},
+		{"策略中的条件", RetryPolicy{RetryOnBody: RetryOnJSONPath("$.status", "error")}, retryTemplate(""), 3, "ok"},
+		{"模板中的条件", RetryPolicy{}, retryTemplate(`, "retryOnBody": {"path": "$.status", "values": ["error"]}`), 3, "ok"},
+		{"模板条件覆盖策略", RetryPolicy{RetryOnBody: RetryOnJSONPath("$.status", "error")},
+			retryTemplate(`, "retryOnBody": {"path": "$.status", "values": ["failed"]}`), 1, "error"},
+		{"只检查字段存在", RetryPolicy{RetryOnBody: RetryOnJSONPath("$.message")}, retryTemplate(""), 3, "ok"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&attempts, 0)
+			c := NewClient(server.URL, 5*time.Second, WithRetryPolicy(tc.policy))
+			defer c.Close()
+
+			resp, err := c.ExecuteTemplateJSON(context.Background(), tc.template, nil)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			result, err := NewResponseFromHTTP(resp)
+			if err != nil {
+				t.Fatalf("读取响应失败: %v", err)
+			}
+
+			if got := atomic.LoadInt32(&attempts); got != tc.expected {
+				t.Errorf("请求次数错误，期望: %d, 实际: %d", tc.expected, got)
+			}
+			// 检查过的响应体仍可以完整读取
+			if status, _ := result.Get("$.status"); status != tc.lastStatus {
+				t.Errorf("应返回最后一次的响应体，实际: %s", result.Body)
+			}
+		})
+	}
+}
+
+// TestDefaultRetryAndCaching 测试模板没有retry和caching配置时使用客户端的默认配置
+func TestDefaultRetryAndCaching(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次返回503，之后成功
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	const plain = `{"request": {"method": "GET", "path": "/api/jobs"}}`
+	cases := []struct {
+		name     string
+		template string
+		calls    int
+		expected int32
+		status   int
+	}{
+		{"模板没有配置时使用默认值", plain, 3, 2, http.StatusOK},
+		{"模板关闭重试和缓存", `{"request": {"method": "GET", "path": "/api/jobs"},
+			"retry": {"enabled": false}, "caching": {"enabled": false}}`, 1, 1, http.StatusServiceUnavailable},
+		{"模板的配置优先", `{"request": {"method": "GET", "path": "/api/jobs"},
+			"retry": {"enabled": true, "maxAttempts": 1}}`, 1, 1, http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&attempts, 0)
+			c := NewClient(server.URL, 5*time.Second,
+				WithDefaultRetry(RetryConfig{Enabled: true, MaxAttempts: 3, InitialDelay: 10, BackoffFactor: 1}),
+				WithDefaultCaching(CachingConfig{Enabled: true, TTL: 60}))
+			defer c.Close()
+
+			var resp *http.Response
+			for i := 0; i < tc.calls; i++ {
+				var err error
+				resp, err = c.ExecuteTemplateJSON(context.Background(), tc.template, nil)
+				if err != nil {
+					t.Fatalf("执行模板失败: %v", err)
+				}
+				closeResponseBody(resp)
+			}
+
+			// 默认配置下第一次请求重试一次，之后的请求命中缓存
+			if got := atomic.LoadInt32(&attempts); got != tc.expected {
+				t.Errorf("请求次数错误，期望: %d, 实际: %d", tc.expected, got)
+			}
+			if resp.StatusCode != tc.status {
+				t.Errorf("状态码错误，期望: %d, 实际: %d", tc.status, resp.StatusCode)
+			}
+		})
+	}
+
+	// 克隆的客户端继承默认配置
+	atomic.StoreInt32(&attempts, 0)
+	c := NewClient(server.URL, 5*time.Second,
+		WithDefaultRetry(RetryConfig{Enabled: true, MaxAttempts: 3, InitialDelay: 10, BackoffFactor: 1}))
+	clone := c.Clone()
+	defer c.Close()
+	defer clone.Close()
+	resp, err := clone.ExecuteTemplateJSON(context.Background(), plain, nil)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	closeResponseBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("克隆的客户端应使用默认的重试配置，状态码: %d", resp.StatusCode)
+	}
+}
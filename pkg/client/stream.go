@@ -0,0 +1,116 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxStreamLineSize 流式读取时单行的最大长度
+const maxStreamLineSize = 1024 * 1024
+
+// Event 表示一个Server-Sent Events事件
+type Event struct {
+	ID    string // 事件ID（id字段）
+	Event string // 事件类型（event字段），为空时等价于"message"
+	Data  string // 事件数据，多行data以换行符连接
+	Retry int    // 服务器建议的重连间隔（毫秒），未指定时为0
+}
+
+// StreamResponse 逐行读取响应体并对每一行调用fn
+// 适用于text/event-stream、NDJSON等流式响应；fn返回错误或ctx被取消时停止读取。
+// 函数返回时会关闭响应体
+func StreamResponse(ctx context.Context, resp *http.Response, fn func(line string) error) error {
+	defer resp.Body.Close()
+
+	// 上下文取消时关闭响应体，使阻塞的读取立即返回
+	stop := closeOnDone(ctx, resp.Body)
+	defer stop()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return scanner.Err()
+}
+
+// StreamEvents 按SSE协议解析响应体，每收到一个完整事件调用一次fn
+// 以冒号开头的注释行会被忽略；流结束时未以空行结尾的事件也会被派发
+func StreamEvents(ctx context.Context, resp *http.Response, fn func(event Event) error) error {
+	var current Event
+	var dataLines []string
+	hasData := false
+
+	dispatch := func() error {
+		if !hasData {
+			current = Event{}
+			return nil
+		}
+		current.Data = strings.Join(dataLines, "\n")
+		event := current
+		current = Event{}
+		dataLines = dataLines[:0]
+		hasData = false
+		return fn(event)
+	}
+
+	err := StreamResponse(ctx, resp, func(line string) error {
+		// 空行表示事件结束
+		if line == "" {
+			return dispatch()
+		}
+
+		// 注释行
+		if strings.HasPrefix(line, ":") {
+			return nil
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "id":
+			current.ID = value
+		case "event":
+			current.Event = value
+		case "data":
+			dataLines = append(dataLines, value)
+			hasData = true
+		case "retry":
+			if retry, err := strconv.Atoi(value); err == nil {
+				current.Retry = retry
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 派发最后一个未以空行结尾的事件
+	return dispatch()
+}
+
+// closeOnDone 在ctx结束时关闭body，返回的函数用于停止监听
+func closeOnDone(ctx context.Context, body io.Closer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			body.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// setupSSEServer 创建一个发送SSE事件的测试服务器，发送完count个事件后保持连接直到客户端断开
+func setupSSEServer(count int, hold bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+
+		fmt.Fprint(w, ": 注释行应被忽略\n\n")
+		for i := 1; i <= count; i++ {
+			fmt.Fprintf(w, "id: %d\nevent: update\ndata: {\"seq\": %d}\ndata: 第二行\n\n", i, i)
+			flusher.Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if hold {
+			<-r.Context().Done()
+		}
+	}))
+}
+
+// TestStreamResponse 测试逐行读取流式响应
+func TestStreamResponse(t *testing.T) {
+	server := setupSSEServer(2, false)
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	resp, err := c.Get("/events")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	var lines []string
+	err = StreamResponse(context.Background(), resp, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("流式读取失败: %v", err)
+	}
+
+	// 注释块2行 + 每个事件5行
+	if len(lines) != 12 {
+		t.Errorf("读取的行数错误，期望: 12, 实际: %d (%q)", len(lines), lines)
+	}
+}
+
+// TestStreamEvents 测试SSE事件解析
+func TestStreamEvents(t *testing.T) {
+	server := setupSSEServer(3, false)
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	resp, err := c.Get("/events")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	var events []Event
+	err = StreamEvents(context.Background(), resp, func(event Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("读取事件失败: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("事件数量错误，期望: 3, 实际: %d", len(events))
+	}
+	for i, event := range events {
+		expectedData := fmt.Sprintf("{\"seq\": %d}\n第二行", i+1)
+		if event.ID != fmt.Sprint(i+1) || event.Event != "update" || event.Data != expectedData {
+			t.Errorf("第%d个事件内容错误: %+v", i+1, event)
+		}
+	}
+}
+
+// TestStreamEventsCallbackError 测试回调返回错误时停止读取
+func TestStreamEventsCallbackError(t *testing.T) {
+	server := setupSSEServer(3, false)
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	resp, err := c.Get("/events")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	errStop := errors.New("停止读取")
+	received := 0
+	err = StreamEvents(context.Background(), resp, func(event Event) error {
+		received++
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("应该返回回调的错误，实际: %v", err)
+	}
+	if received != 1 {
+		t.Errorf("回调返回错误后不应继续读取，实际收到: %d", received)
+	}
+}
+
+// TestStreamEventsCancel 测试上下文取消时停止读取
+func TestStreamEventsCancel(t *testing.T) {
+	server := setupSSEServer(1, true)
+	defer server.Close()
+
+	c := NewClient(server.URL, 0)
+	resp, err := c.Get("/events")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- StreamEvents(ctx, resp, func(event Event) error {
+			// 收到第一个事件后取消，此时服务器仍保持连接
+			cancel()
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("应该返回context.Canceled，实际: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("上下文取消后读取未停止")
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+)
+
+// TemplateBuilder 以链式调用的方式构建请求模板定义
+// 生成的JSON字符串可直接传给ExecuteTemplateJSON，避免手写模板JSON出错
+//
+//	tmpl, err := client.NewTemplateBuilder().
+//		Method("POST").
+//		Path("/api/users").
+//		Header("X-Request-ID", "{{ .requestId }}").
+//		Body(map[string]interface{}{"name": "{{ .name }}"}).
+//		Retry(3, 500, 2).
+//		Build()
+type TemplateBuilder struct {
+	def templateDefinition
+}
+
+// NewTemplateBuilder 创建一个新的模板构建器
+func NewTemplateBuilder() *TemplateBuilder {
+	return &TemplateBuilder{}
+}
+
+// Method 设置HTTP方法
+func (b *TemplateBuilder) Method(method string) *TemplateBuilder {
+	b.def.Request.Method = method
+	return b
+}
+
+// BaseURL 设置模板专用的基础URL，覆盖客户端的基础URL
+func (b *TemplateBuilder) BaseURL(baseURL string) *TemplateBuilder {
+	b.def.Request.BaseURL = baseURL
+	return b
+}
+
+// Path 设置请求路径
+func (b *TemplateBuilder) Path(path string) *TemplateBuilder {
+	b.def.Request.Path = path
+	return b
+}
+
+// Header 添加请求头，值可以包含模板表达式
+func (b *TemplateBuilder) Header(key, value string) *TemplateBuilder {
+	if b.def.Request.Headers == nil {
+		b.def.Request.Headers = make(map[string]string)
+	}
+	b.def.Request.Headers[key] = value
+	return b
+}
+
+// HeadersExpr 设置渲染结果为JSON对象的请求头模板表达式，用于按条件添加请求头，
+// 例如 {{ jsonEncode (merge (headersIf .debug (dict "X-Debug" "1"))) }}
+func (b *TemplateBuilder) HeadersExpr(expr string) *TemplateBuilder {
+	b.def.Request.HeadersExpr = expr
+	return b
+}
+
+// Timeout 设置请求超时时间（秒）
+func (b *TemplateBuilder) Timeout(seconds int) *TemplateBuilder {
+	b.def.Request.Timeout = seconds
+	return b
+}
+
+// Body 设置请求体模板，可以是map[string]interface{}或[]interface{}（批量请求），字符串值可以包含模板表达式
+func (b *TemplateBuilder) Body(body interface{}) *TemplateBuilder {
+	b.def.Body = body
+	return b
+}
+
+// File 添加上传文件字段，路径可以包含模板表达式；存在文件字段时请求以multipart/form-data发送
+func (b *TemplateBuilder) File(field, pathTemplate string) *TemplateBuilder {
+	if b.def.Files == nil {
+		b.def.Files = make(map[string]string)
+	}
+	b.def.Files[field] = pathTemplate
+	return b
+}
+
+// BeforeHook 添加模板前置钩子定义
+func (b *TemplateBuilder) BeforeHook(def hooks.HookDefinition) *TemplateBuilder {
+	b.def.BeforeHooks = append(b.def.BeforeHooks, def)
+	return b
+}
+
+// AfterHook 添加模板后置钩子定义
+func (b *TemplateBuilder) AfterHook(def hooks.HookDefinition) *TemplateBuilder {
+	b.def.AfterHooks = append(b.def.AfterHooks, def)
+	return b
+}
+
+// Retry 启用重试
+// 参数:
+// - maxAttempts: 最大尝试次数
+// - initialDelay: 初始延迟（毫秒）
+// - backoffFactor: 退避因子
+func (b *TemplateBuilder) Retry(maxAttempts, initialDelay, backoffFactor int) *TemplateBuilder {
+	b.def.Retry = &RetryConfig{
+		Enabled:       true,
+		MaxAttempts:   maxAttempts,
+		InitialDelay:  initialDelay,
+		BackoffFactor: backoffFactor,
+	}
+	return b
+}
+
+// Cache 启用响应缓存
+// 参数:
+// - ttlSeconds: 缓存有效期（秒）
+// - keyPattern: 自定义缓存键模式，为空时使用URL和请求体生成
+func (b *TemplateBuilder) Cache(ttlSeconds int, keyPattern string) *TemplateBuilder {
+	b.def.Caching = &CachingConfig{
+		Enabled:    true,
+		TTL:        ttlSeconds,
+		KeyPattern: keyPattern,
+	}
+	return b
+}
+
+// Build 生成模板JSON字符串
+func (b *TemplateBuilder) Build() (string, error) {
+	if b.def.Request.Path == "" && b.def.Request.BaseURL == "" {
+		return "", fmt.Errorf("模板必须指定请求路径或基础URL")
+	}
+
+	data, err := json.Marshal(b.def)
+	if err != nil {
+		return "", fmt.Errorf("序列化模板定义失败: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestTemplateBuilder 测试使用构建器生成模板并执行
+func TestTemplateBuilder(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	tmpl, err := NewTemplateBuilder().
+		Method("POST").
+		Path("/api/users").
+		Header("X-Trace", "{{ .trace }}").
+		Body(map[string]interface{}{
+			"name":  "{{ .name }}",
+			"email": "{{ .email }}",
+		}).
+		Retry(2, 10, 2).
+		Build()
+	if err != nil {
+		t.Fatalf("构建模板失败: %v", err)
+	}
+
+	// 生成的模板应该是有效的JSON
+	var def map[string]interface{}
+	if err := json.Unmarshal([]byte(tmpl), &def); err != nil {
+		t.Fatalf("生成的模板不是有效的JSON: %v", err)
+	}
+
+	c := NewClient(server.URL, 5*time.Second)
+	data := map[string]interface{}{
+		"trace": "abc",
+		"name":  "王五",
+		"email": "wangwu@example.com",
+	}
+
+	resp, err := c.ExecuteTemplateJSON(context.Background(), tmpl, data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("状态码错误，期望: %d, 实际: %d", http.StatusCreated, resp.StatusCode)
+	}
+
+	body, err := ReadResponseBody(resp)
+	if err != nil {
+		t.Fatalf("读取响应失败: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+
+	sent, _ := result["json"].(map[string]interface{})
+	if sent["name"] != "王五" || sent["email"] != "wangwu@example.com" {
+		t.Errorf("发送的请求体错误: %v", sent)
+	}
+}
+
+// TestTemplateBuilderMissingPath 测试缺少路径时构建失败
+func TestTemplateBuilderMissingPath(t *testing.T) {
+	if _, err := NewTemplateBuilder().Method("GET").Build(); err == nil {
+		t.Error("缺少路径时应该返回错误")
+	}
+}
+
+// TestTemplateBuilderCacheKeyPattern 测试缓存键模式相同的请求共用缓存，即使请求体不同
+func TestTemplateBuilderCacheKeyPattern(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	tmpl, err := NewTemplateBuilder().
+		Method("POST").
+		Path("/api/users").
+		Body(map[string]interface{}{
+			"id":    "{{ .id }}",
+			"trace": "{{ .trace }}",
+		}).
+		Cache(60, "user-{{ .id }}").
+		Build()
+	if err != nil {
+		t.Fatalf("构建模板失败: %v", err)
+	}
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+
+	for i, data := range []map[string]interface{}{
+		{"id": 1, "trace": "a"},
+		{"id": 1, "trace": "b"},
+		{"id": 2, "trace": "a"},
+	} {
+		resp, err := c.ExecuteTemplateJSON(context.Background(), tmpl, data)
+		if err != nil {
+			t.Fatalf("第%d次请求执行模板失败: %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+
+	if requests != 2 {
+		t.Errorf("缓存键相同的请求应命中缓存，服务器收到请求数: %d", requests)
+	}
+	if _, ok := c.cache.Get("user-1"); !ok {
+		t.Error("缓存应使用渲染后的缓存键")
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"net"
+	"time"
+)
+
+// 以下超时设置在底层*http.Transport上，通过WithTransport设置了其他类型的传输层时不生效；
+// 与WithTransport同时使用时需放在其后。NewClient的timeout是包括读取响应体在内的总时间上限，
+// 流式读取响应时可以将其设为0，只用这些选项限制连接和等待响应头的时间
+
+// WithDialTimeout 设置建立TCP连接的超时时间
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		transport, ok := c.cloneTransport()
+		if !ok {
+			return
+		}
+		dialer := &net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+		c.client.Transport = transport
+	}
+}
+
+// WithTLSHandshakeTimeout 设置TLS握手的超时时间，0表示不限制
+func WithTLSHandshakeTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		transport, ok := c.cloneTransport()
+		if !ok {
+			return
+		}
+		transport.TLSHandshakeTimeout = d
+		c.client.Transport = transport
+	}
+}
+
+// WithResponseHeaderTimeout 设置发送完请求后等待响应头的超时时间，不包括读取响应体的时间，0表示不限制
+func WithResponseHeaderTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		transport, ok := c.cloneTransport()
+		if !ok {
+			return
+		}
+		transport.ResponseHeaderTimeout = d
+		c.client.Transport = transport
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTransportTimeouts 测试等待响应头超时与读取响应体的时间分开计算
+func TestTransportTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-header":
+			time.Sleep(300 * time.Millisecond)
+			w.Write([]byte(`{"ok": true}`))
+		case "/slow-body":
+			// 立即返回响应头，之后分段慢速写入响应体
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+			w.Write([]byte(`{"ok": true}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second, WithResponseHeaderTimeout(100*time.Millisecond))
+
+	// 响应头超过100毫秒才返回，等待响应头超时
+	if _, err := c.Get("/slow-header"); err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("应返回等待响应头超时的错误，实际: %v", err)
+	}
+
+	// 响应头及时返回时，读取较慢的响应体不受该超时限制
+	resp, err := c.Get("/slow-body")
+	if err != nil {
+		t.Fatalf("响应头及时返回时不应超时: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("读取响应体失败: %v", err)
+	}
+	if string(body) != `{"ok": true}` {
+		t.Errorf("响应体错误: %s", body)
+	}
+
+	// 总超时仍然包括读取响应体的时间
+	total := NewClient(server.URL, 100*time.Millisecond)
+	if resp, err := total.Get("/slow-body"); err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			t.Error("总超时应包括读取响应体的时间")
+		}
+	}
+}
+
+// TestTLSHandshakeTimeout 测试服务器不响应TLS握手时握手超时
+func TestTLSHandshakeTimeout(t *testing.T) {
+	// 只接受连接、不进行握手的服务器
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听端口失败: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	c := NewClient("https://"+listener.Addr().String(), 5*time.Second,
+		WithTLSHandshakeTimeout(100*time.Millisecond), WithDialTimeout(time.Second))
+	start := time.Now()
+	_, err = c.Get("/")
+	if err == nil || !strings.Contains(err.Error(), "TLS handshake timeout") {
+		t.Errorf("应返回TLS握手超时的错误，实际: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("握手超时应在总超时之前触发，实际耗时: %v", elapsed)
+	}
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// defaultTranscriptBodyLimit 记录中每个请求体或响应体默认保留的最大字节数
+const defaultTranscriptBodyLimit = 64 << 10
+
+// RedactedValue 记录中被隐藏的值，与日志钩子使用的相同
+const RedactedValue = utils.RedactedMask
+
+// TranscriptEntry 一次实际发出的请求及其响应的记录
+type TranscriptEntry struct {
+	Time                  time.Time
+	Method                string
+	URL                   string
+	RequestHeaders        http.Header
+	RequestBody           string
+	RequestBodyTruncated  bool // 请求体超过上限，只保留了开头部分
+	StatusCode            int  // 请求失败时为0
+	ResponseHeaders       http.Header
+	ResponseBody          string // 响应体在被读取时记录，尚未读取的部分不会出现
+	ResponseBodyTruncated bool
+	Error                 string // 请求失败时的错误信息
+}
+
+// transcriptLog 请求记录
+type transcriptLog struct {
+	mutex        sync.Mutex
+	maxBodyBytes int
+	redactKeys   []string // 敏感的请求头名、查询参数名和字段名，规则同日志钩子
+	records      []*transcriptRecord
+}
+
+// transcriptRecord 单条记录，响应体随读取逐步写入
+type transcriptRecord struct {
+	entry        TranscriptEntry
+	responseBody *cappedBuffer
+}
+
+// EnableTranscript 开始记录每个实际发出的请求（包括重试和令牌刷新后的重放）的最终方法、URL、
+// 请求头、请求体以及响应的状态码、响应头和响应体，便于排查问题时导出完整的交互过程。
+// 请求体和响应体各最多保留maxBodyBytes字节（<=0时为64KB）。敏感信息的隐藏规则与日志钩子相同：
+// 名称包含password、token、authorization、cookie等默认关键字或redactKeys中任意一项（忽略大小写、"-"和"_"）的
+// 请求头、URL查询参数以及JSON/表单请求体和响应体中的字段，其值会被替换为RedactedValue；
+// 被截断而无法解析的请求体和响应体原样保留。
+// 命中缓存的请求不会发出，因此不会被记录。再次调用会清空已有记录并使用新的设置
+func (c *Client) EnableTranscript(maxBodyBytes int, redactKeys ...string) {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultTranscriptBodyLimit
+	}
+	c.transcript.Store(&transcriptLog{
+		maxBodyBytes: maxBodyBytes,
+		redactKeys:   utils.RedactKeys(redactKeys...),
+	})
+}
+
+// Transcript 按发送顺序返回记录的副本，未调用EnableTranscript时返回nil
+func (c *Client) Transcript() []TranscriptEntry {
+	t := c.transcript.Load()
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	entries := make([]TranscriptEntry, 0, len(t.records))
+	for _, record := range t.records {
+		entry := record.entry
+		entry.RequestHeaders = entry.RequestHeaders.Clone()
+		entry.ResponseHeaders = entry.ResponseHeaders.Clone()
+		if record.responseBody != nil {
+			entry.ResponseBody, entry.ResponseBodyTruncated = record.responseBody.snapshot()
+			entry.ResponseBody = t.redactBody(entry.ResponseBody, entry.ResponseHeaders.Get("Content-Type"))
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ResetTranscript 清空已有的记录，之后的请求继续记录
+func (c *Client) ResetTranscript() {
+	if t := c.transcript.Load(); t != nil {
+		t.mutex.Lock()
+		t.records = nil
+		t.mutex.Unlock()
+	}
+}
+
+// roundTrip 发送请求，启用记录时记录请求和响应
+func (c *Client) roundTrip(client *http.Client, req *http.Request) (*http.Response, error) {
+	t := c.transcript.Load()
+	if t == nil {
+		return client.Do(req)
+	}
+
+	record := &transcriptRecord{entry: TranscriptEntry{
+		Time:           time.Now(),
+		Method:         req.Method,
+		URL:            utils.RedactURL(req.URL, t.redactKeys),
+		RequestHeaders: t.redactHeader(req.Header),
+	}}
+	record.entry.RequestBody, record.entry.RequestBodyTruncated = t.captureRequestBody(req)
+	record.entry.RequestBody = t.redactBody(record.entry.RequestBody, req.Header.Get("Content-Type"))
+
+	t.mutex.Lock()
+	t.records = append(t.records, record)
+	t.mutex.Unlock()
+
+	resp, err := client.Do(req)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err != nil {
+		record.entry.Error = err.Error()
+		return resp, err
+	}
+	record.entry.StatusCode = resp.StatusCode
+	record.entry.ResponseHeaders = t.redactHeader(resp.Header)
+	record.responseBody = &cappedBuffer{limit: t.maxBodyBytes}
+	resp.Body = &transcriptBody{ReadCloser: resp.Body, capture: record.responseBody}
+	return resp, nil
+}
+
+// redactHeader 复制请求头并隐藏敏感的值，记录不与原请求头共用切片
+func (t *transcriptLog) redactHeader(header http.Header) http.Header {
+	return utils.RedactHeader(header.Clone(), t.redactKeys)
+}
+
+// redactBody 隐藏请求体或响应体中敏感字段的值
+func (t *transcriptLog) redactBody(body, contentType string) string {
+	if body == "" {
+		return body
+	}
+	return string(utils.RedactBody([]byte(body), contentType, t.redactKeys))
+}
+
+// captureRequestBody 记录请求体的开头部分，不影响实际发送的内容
+func (t *transcriptLog) captureRequestBody(req *http.Request) (string, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", false
+	}
+
+	// 优先使用GetBody，避免读取原请求体
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			defer body.Close()
+			prefix, _ := io.ReadAll(io.LimitReader(body, int64(t.maxBodyBytes)+1))
+			return truncateCapture(prefix, t.maxBodyBytes)
+		}
+	}
+
+	// 只读取开头部分，其余内容仍从原请求体流式发送
+	original := req.Body
+	prefix, err := io.ReadAll(io.LimitReader(original, int64(t.maxBodyBytes)+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), errorReader{err}, original), original}
+	return truncateCapture(prefix, t.maxBodyBytes)
+}
+
+// truncateCapture 将最多limit+1字节的前缀截断为limit字节，并返回是否发生截断
+func truncateCapture(prefix []byte, limit int) (string, bool) {
+	if len(prefix) > limit {
+		return string(prefix[:limit]), true
+	}
+	return string(prefix), false
+}
+
+// errorReader 在err不为nil时返回该错误，用于在读取前缀失败后把错误传递给发送方
+type errorReader struct {
+	err error
+}
+
+// Read 实现io.Reader
+func (r errorReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
+
+// transcriptBody 在调用方读取响应体时同时记录内容
+type transcriptBody struct {
+	io.ReadCloser
+	capture *cappedBuffer
+}
+
+// Read 读取响应体并记录
+func (b *transcriptBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.capture.write(p[:n])
+	return n, err
+}
+
+// cappedBuffer 只保留前limit个字节的并发安全缓冲区
+type cappedBuffer struct {
+	mutex     sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// write 写入数据，超过上限的部分被丢弃
+func (b *cappedBuffer) write(p []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	remaining := b.limit - b.buf.Len()
+	if len(p) > remaining {
+		p = p[:remaining]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+}
+
+// snapshot 返回已记录的内容以及是否发生截断
+func (b *cappedBuffer) snapshot() (string, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String(), b.truncated
+}
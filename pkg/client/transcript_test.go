@@ -0,0 +1,132 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTranscript 测试记录实际发出的请求和响应，并隐藏敏感请求头
+func TestTranscript(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+			return
+		}
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetHeader("Authorization", "Bearer secret")
+	c.SetHeader("X-Api-Key", "key-123")
+	c.SetHeader("X-Trace", "trace-1")
+
+	if c.Transcript() != nil {
+		t.Error("未启用时应返回nil")
+	}
+	c.EnableTranscript(16, "x-api-key")
+
+	resp, err := c.Get("/users")
+	if err != nil {
+		t.Fatalf("GET请求失败: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	postBody := `{"name": "张三", "email": "zhangsan@example.com"}`
+	resp, err = c.Post("/users", []byte(postBody))
+	if err != nil {
+		t.Fatalf("POST请求失败: %v", err)
+	}
+	echoed, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(echoed) != postBody {
+		t.Errorf("记录不应影响发送的请求体，服务器收到: %s", echoed)
+	}
+
+	entries := c.Transcript()
+	if len(entries) != 2 {
+		t.Fatalf("期望2条记录，实际: %d", len(entries))
+	}
+
+	get, post := entries[0], entries[1]
+	if get.Method != http.MethodGet || get.URL != server.URL+"/users" || get.StatusCode != http.StatusOK {
+		t.Errorf("GET记录错误: %s %s %d", get.Method, get.URL, get.StatusCode)
+	}
+	if get.ResponseBody != `[{"id": 1}]` || get.ResponseBodyTruncated {
+		t.Errorf("GET响应体记录错误: %q", get.ResponseBody)
+	}
+	if post.Method != http.MethodPost || post.StatusCode != http.StatusCreated {
+		t.Errorf("POST记录错误: %s %d", post.Method, post.StatusCode)
+	}
+	if post.RequestBody != postBody[:16] || !post.RequestBodyTruncated {
+		t.Errorf("请求体应截断为16字节，实际: %q", post.RequestBody)
+	}
+	if post.ResponseBody != postBody[:16] || !post.ResponseBodyTruncated {
+		t.Errorf("响应体应截断为16字节，实际: %q", post.ResponseBody)
+	}
+
+	for _, entry := range entries {
+		if got := entry.RequestHeaders.Get("Authorization"); got != RedactedValue {
+			t.Errorf("Authorization应被隐藏，实际: %s", got)
+		}
+		if got := entry.RequestHeaders.Get("X-Api-Key"); got != RedactedValue {
+			t.Errorf("配置的X-Api-Key应被隐藏，实际: %s", got)
+		}
+		if got := entry.RequestHeaders.Get("X-Trace"); got != "trace-1" {
+			t.Errorf("普通请求头应原样记录，实际: %s", got)
+		}
+		if got := entry.ResponseHeaders.Get("Set-Cookie"); got != RedactedValue {
+			t.Errorf("Set-Cookie应被隐藏，实际: %s", got)
+		}
+	}
+
+	// 记录是副本，修改不影响客户端中的记录
+	entries[0].RequestHeaders.Set("X-Trace", "changed")
+	if c.Transcript()[0].RequestHeaders.Get("X-Trace") != "trace-1" {
+		t.Error("Transcript应返回副本")
+	}
+
+	c.ResetTranscript()
+	if len(c.Transcript()) != 0 {
+		t.Error("ResetTranscript后应没有记录")
+	}
+}
+
+// TestTranscriptRedactsBody 测试记录中请求体、响应体和查询参数的敏感字段被隐藏，规则与日志钩子相同
+func TestTranscriptRedactsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "tok-123", "user": {"name": "张三"}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.EnableTranscript(0, "pin")
+
+	resp, err := c.Post("/login?api_key=k-1&page=2", []byte(`{"name": "张三", "password": "p@ss", "card": {"pin": "1234"}}`))
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	ReadResponseBody(resp)
+
+	entry := c.Transcript()[0]
+	for _, secret := range []string{"p@ss", "1234", "tok-123", "k-1"} {
+		if strings.Contains(entry.RequestBody+entry.ResponseBody+entry.URL, secret) {
+			t.Errorf("记录中不应出现敏感值 %s: %s | %s | %s", secret, entry.URL, entry.RequestBody, entry.ResponseBody)
+		}
+	}
+	if !strings.Contains(entry.RequestBody, `"name":"张三"`) || !strings.Contains(entry.ResponseBody, `"name":"张三"`) {
+		t.Errorf("普通字段应原样记录: %s | %s", entry.RequestBody, entry.ResponseBody)
+	}
+	if !strings.Contains(entry.URL, "page=2") {
+		t.Errorf("普通查询参数应原样记录: %s", entry.URL)
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ResponseValidator 响应校验函数，返回非nil错误时请求失败
+type ResponseValidator func(resp *http.Response) error
+
+// AddResponseValidator 添加全局响应校验器
+// 校验器在所有响应后钩子执行完成后按注册顺序运行，对每个请求（包括缓存命中）生效。
+// 校验器可以读取响应体，每个校验器及最终调用方都会看到完整的响应体
+func (c *Client) AddResponseValidator(validator ResponseValidator) {
+	c.validators = append(c.validators, validator)
+}
+
+// validateResponse 依次执行响应校验器，校验失败时关闭响应体
+func (c *Client) validateResponse(resp *http.Response) error {
+	if len(c.validators) == 0 {
+		return nil
+	}
+
+	body, err := ReadResponseBody(resp)
+	if err != nil {
+		return fmt.Errorf("读取响应体失败: %w", err)
+	}
+
+	for _, validator := range c.validators {
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err := validator(resp); err != nil {
+			closeResponseBody(resp)
+			return err
+		}
+	}
+
+	// 重置响应体供调用方读取
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// requireRequestID 要求响应体包含requestId字段
+func requireRequestID(resp *http.Response) error {
+	var data map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return err
+	}
+	if _, ok := data["requestId"]; !ok {
+		return errors.New("响应缺少requestId字段")
+	}
+	return nil
+}
+
+// TestResponseValidator 测试全局响应校验器
+func TestResponseValidator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Path == "/ok" {
+			w.Write([]byte(`{"requestId": "abc", "data": 1}`))
+			return
+		}
+		w.Write([]byte(`{"data": 1}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	defer c.Close()
+	c.AddResponseValidator(requireRequestID)
+
+	// 缺少字段的响应应被拒绝
+	if _, err := c.Get("/missing"); err == nil {
+		t.Error("缺少requestId的响应应该校验失败")
+	}
+
+	// 通过校验的响应体仍可被调用方完整读取
+	resp, err := c.Get("/ok")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"requestId": "abc", "data": 1}` {
+		t.Errorf("校验后的响应体不完整: %s", body)
+	}
+}
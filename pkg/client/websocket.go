@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+	"github.com/gorilla/websocket"
+)
+
+// websocketManagedHeaders 由WebSocket握手自动生成的请求头，不能从钩子设置的请求头中传入
+var websocketManagedHeaders = []string{
+	"Upgrade",
+	"Connection",
+	"Sec-Websocket-Key",
+	"Sec-Websocket-Version",
+	"Sec-Websocket-Extensions",
+}
+
+// DialWebSocket 连接baseURL+path上的WebSocket
+// 握手请求会设置客户端的默认请求头并执行全局前置钩子（如认证、API Key、签名），
+// 因此与普通请求使用相同的认证方式；http/https分别对应ws/wss。握手超时使用客户端的超时时间
+func (c *Client) DialWebSocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, utils.WrapSentinel(ErrRequestFailed, fmt.Errorf("创建WebSocket握手请求失败: %w", err))
+	}
+	if err := c.setDefaultHeaders(req); err != nil {
+		return nil, err
+	}
+
+	req, err = c.applyBeforeHooks(req, c.beforeHook)
+	if err != nil {
+		return nil, hookError(fmt.Errorf("前置钩子执行失败: %w", err))
+	}
+
+	// 钩子可能修改了URL（如在查询参数中添加签名），以钩子处理后的请求为准
+	wsURL := *req.URL
+	switch strings.ToLower(wsURL.Scheme) {
+	case "http":
+		wsURL.Scheme = "ws"
+	case "https":
+		wsURL.Scheme = "wss"
+	}
+
+	header := req.Header.Clone()
+	for _, name := range websocketManagedHeaders {
+		header.Del(name)
+	}
+	// Dialer通过请求头中的Host设置握手请求的Host
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: c.client.Timeout,
+	}
+	if transport, ok := c.client.Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.TLSClientConfig = transport.TLSClientConfig
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, wsURL.String(), header)
+	if err != nil {
+		if resp != nil {
+			return nil, transportError(req, fmt.Errorf("WebSocket握手失败，状态码: %d: %w", resp.StatusCode, err))
+		}
+		return nil, transportError(req, fmt.Errorf("WebSocket握手失败: %w", err))
+	}
+	return conn, nil
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/hooks"
+	"github.com/gorilla/websocket"
+)
+
+// TestDialWebSocket 测试连接WebSocket时握手请求执行前置钩子
+func TestDialWebSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Client") != "renderapi" {
+			http.Error(w, "未授权", http.StatusUnauthorized)
+			return
+		}
+
+		// 回显收到的消息
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, message); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	c.SetHeader("X-Client", "renderapi")
+	c.AddBeforeHook(hooks.NewAuthHook("secret"))
+
+	conn, err := c.DialWebSocket(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("连接WebSocket失败: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("你好")); err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("读取消息失败: %v", err)
+	}
+	if string(message) != "你好" {
+		t.Errorf("回显消息错误: %s", message)
+	}
+
+	// 没有认证信息时握手失败
+	plain := NewClient(server.URL, 5*time.Second)
+	_, err = plain.DialWebSocket(context.Background(), "/ws")
+	if !errors.Is(err, ErrRequestFailed) {
+		t.Errorf("握手被拒绝时应返回ErrRequestFailed，实际: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// xmlTemplateDefinition XML请求模板的定义结构
+//
+//	<request method="POST" path="/soap/users">
+//	    <header name="SOAPAction">urn:CreateUser</header>
+//	    <body><soap:Envelope ...>...</soap:Envelope></body>
+//	</request>
+type xmlTemplateDefinition struct {
+	XMLName xml.Name    `xml:"request"`
+	Method  string      `xml:"method,attr"`
+	BaseURL string      `xml:"baseURL,attr"`
+	Path    string      `xml:"path,attr"`
+	Timeout int         `xml:"timeout,attr"`
+	Headers []xmlHeader `xml:"header"`
+	Body    struct {
+		Content []byte `xml:",innerxml"`
+	} `xml:"body"`
+}
+
+// xmlHeader XML模板中的请求头
+type xmlHeader struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ExecuteXMLTemplate 渲染XML模板并发送请求
+// 整个模板先按数据渲染，再解析<request>的method、path等属性、<header>请求头和<body>中的XML文档。
+// 请求体必须是格式良好的XML，默认Content-Type为application/xml，可通过<header>覆盖（如SOAP 1.1的text/xml）。
+// 插入模板的数据不会自动转义，可以使用xmlEscape函数
+func (c *Client) ExecuteXMLTemplate(ctx context.Context, templateXML string, data interface{}) (*http.Response, error) {
+	templateID := fmt.Sprintf("xml_template_%d", time.Now().UnixNano())
+	if err := c.templateEngine.AddTemplate(templateID, templateXML); err != nil {
+		return nil, &TemplateError{Err: fmt.Errorf("添加XML模板失败: %w", err)}
+	}
+	defer c.templateEngine.RemoveTemplate(templateID)
+
+	rendered, err := c.templateEngine.Execute(templateID, data)
+	if err != nil {
+		return nil, &TemplateError{Err: fmt.Errorf("渲染XML模板失败: %w", err)}
+	}
+
+	var tmplDef xmlTemplateDefinition
+	if err := xml.Unmarshal([]byte(rendered), &tmplDef); err != nil {
+		return nil, &TemplateError{Err: fmt.Errorf("解析XML模板定义失败: %w", err)}
+	}
+
+	body := bytes.TrimSpace(tmplDef.Body.Content)
+	if len(body) > 0 {
+		if err := utils.CheckXML(body); err != nil {
+			return nil, &TemplateError{Err: fmt.Errorf("请求体不是有效的XML: %w", err)}
+		}
+	}
+
+	method := strings.ToUpper(tmplDef.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	baseURL := c.baseURL
+	if tmplDef.BaseURL != "" {
+		baseURL = tmplDef.BaseURL
+	}
+
+	req, err := http.NewRequest(method, baseURL+tmplDef.Path, bytes.NewReader(body))
+	if err != nil {
+		return nil, utils.WrapSentinel(ErrRequestFailed, fmt.Errorf("创建HTTP请求失败: %w", err))
+	}
+	if err := c.setDefaultHeaders(req); err != nil {
+		return nil, err
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+	for _, header := range tmplDef.Headers {
+		req.Header.Set(header.Name, strings.TrimSpace(header.Value))
+	}
+
+	if tmplDef.Timeout <= 0 {
+		return c.do(req.WithContext(ctx))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(tmplDef.Timeout)*time.Second)
+	resp, err := c.do(req.WithContext(ctx))
+	if err != nil || resp.Body == nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// DecodeXML 读取XML响应体并转换为Map，读取后关闭响应体
+// 结果形如{根元素名: 值}，元素名去掉命名空间前缀；只有文本的元素转换为字符串，
+// 其余元素转换为Map：属性以"@"开头，文本内容存入"#text"，同名子元素合并为数组
+func DecodeXML(resp *http.Response) (map[string]interface{}, error) {
+	body, err := ReadResponseBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+
+	result, err := utils.XMLToMap(body)
+	if err != nil {
+		return nil, &DecodeError{Format: "XML", Err: fmt.Errorf("解析响应XML失败: %w", err)}
+	}
+	return result, nil
+}
+
+// ToXML 将数据转换为以root为根元素的XML，规则与DecodeXML相反，可用于构造XML请求体
+func ToXML(root string, v interface{}) ([]byte, error) {
+	return utils.MapToXML(root, v)
+}
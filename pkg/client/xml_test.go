@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExecuteXMLTemplate 测试发送XML模板请求并解析XML响应
+func TestExecuteXMLTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.URL.Path != "/soap/users" {
+			t.Errorf("请求错误: %s %s", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/xml" {
+			t.Errorf("Content-Type错误: %s", ct)
+		}
+		if action := r.Header.Get("SOAPAction"); action != "urn:CreateUser" {
+			t.Errorf("SOAPAction错误: %s", action)
+		}
+		expected := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><CreateUser><name>张三 &amp; 李四</name><age>30</age></CreateUser></soap:Body></soap:Envelope>`
+		if string(body) != expected {
+			t.Errorf("请求体错误，期望: %s, 实际: %s", expected, body)
+		}
+
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+	<soap:Body>
+		<CreateUserResponse status="ok">
+			<id>42</id>
+			<role>admin</role>
+			<role>user</role>
+		</CreateUserResponse>
+	</soap:Body>
+</soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 5*time.Second)
+	tmpl := `<request method="POST" path="/soap/users">
+	<header name="SOAPAction">urn:CreateUser</header>
+	<body><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><CreateUser><name>{{ xmlEscape .name }}</name><age>{{ .age }}</age></CreateUser></soap:Body></soap:Envelope></body>
+</request>`
+
+	resp, err := c.ExecuteXMLTemplate(context.Background(), tmpl, map[string]interface{}{"name": "张三 & 李四", "age": 30})
+	if err != nil {
+		t.Fatalf("执行XML模板失败: %v", err)
+	}
+
+	result, err := DecodeXML(resp)
+	if err != nil {
+		t.Fatalf("解析XML响应失败: %v", err)
+	}
+	user := result["Envelope"].(map[string]interface{})["Body"].(map[string]interface{})["CreateUserResponse"].(map[string]interface{})
+	if user["@status"] != "ok" || user["id"] != "42" {
+		t.Errorf("解析结果错误: %v", user)
+	}
+	if roles, ok := user["role"].([]interface{}); !ok || len(roles) != 2 || roles[1] != "user" {
+		t.Errorf("同名元素应合并为数组: %v", user["role"])
+	}
+
+	t.Run("请求体格式错误", func(t *testing.T) {
+		_, err := c.ExecuteXMLTemplate(context.Background(), `<request path="/soap/users"><body>{{ .raw }}</body></request>`,
+			map[string]interface{}{"raw": "<a><b></a>"})
+		if err == nil {
+			t.Fatal("格式错误的XML应返回错误")
+		}
+	})
+
+	t.Run("请求体包含多个根元素", func(t *testing.T) {
+		_, err := c.ExecuteXMLTemplate(context.Background(), `<request path="/soap/users"><body><a/><b/></body></request>`, nil)
+		if err == nil || !strings.Contains(err.Error(), "根元素") {
+			t.Errorf("多个根元素应返回错误，实际: %v", err)
+		}
+	})
+}
+
+// TestToXML 测试将Map转换为XML并解析回来
+func TestToXML(t *testing.T) {
+	data := map[string]interface{}{
+		"@id":   "7",
+		"name":  "a<b",
+		"tags":  []interface{}{"x", "y"},
+		"empty": nil,
+	}
+	encoded, err := ToXML("item", data)
+	if err != nil {
+		t.Fatalf("转换XML失败: %v", err)
+	}
+
+	expected := `<item id="7"><empty/><name>a&lt;b</name><tags>x</tags><tags>y</tags></item>`
+	if string(encoded) != expected {
+		t.Errorf("期望: %s, 实际: %s", expected, encoded)
+	}
+
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader(string(encoded)))}
+	decoded, err := DecodeXML(resp)
+	if err != nil {
+		t.Fatalf("解析XML失败: %v", err)
+	}
+	item := decoded["item"].(map[string]interface{})
+	if item["@id"] != "7" || item["name"] != "a<b" || len(item["tags"].([]interface{})) != 2 {
+		t.Errorf("往返转换结果错误: %v", item)
+	}
+}
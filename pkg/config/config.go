@@ -12,6 +12,8 @@ import (
 type Config struct {
 	BaseURL             string            `json:"base_url"`
 	DefaultHeaders      map[string]string `json:"default_headers"`
+	TemplatedHeaders    bool              `json:"templated_headers"`    // 每次请求时渲染DefaultHeaders中的模板
+	DefaultQueryParams  map[string]string `json:"default_query_params"` // 添加到每个请求URL中的查询参数
 	Timeout             int               `json:"timeout"`
 	EnableLogging       bool              `json:"enable_logging"`
 	AuthToken           string            `json:"auth_token"`
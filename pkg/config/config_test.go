@@ -65,7 +65,10 @@ func TestSaveAndLoadConfig(t *testing.T) {
 			"Content-Type": "application/json",
 			"User-Agent":   "RenderAPI-Test",
 			"X-Custom":     "custom-value",
+			"X-Date":       "{{ now | formatDate }}",
 		},
+		TemplatedHeaders:    true,
+		DefaultQueryParams:  map[string]string{"api_version": "2"},
 		Timeout:             60,
 		EnableLogging:       true,
 		AuthToken:           "test-token-123",
@@ -96,6 +99,11 @@ func TestSaveAndLoadConfig(t *testing.T) {
 			originalCfg.Timeout, loadedCfg.Timeout)
 	}
 
+	if loadedCfg.TemplatedHeaders != originalCfg.TemplatedHeaders {
+		t.Errorf("TemplatedHeaders不匹配，期望: %t, 实际: %t",
+			originalCfg.TemplatedHeaders, loadedCfg.TemplatedHeaders)
+	}
+
 	if loadedCfg.EnableLogging != originalCfg.EnableLogging {
 		t.Errorf("EnableLogging不匹配，期望: %t, 实际: %t",
 			originalCfg.EnableLogging, loadedCfg.EnableLogging)
@@ -111,6 +119,11 @@ func TestSaveAndLoadConfig(t *testing.T) {
 			originalCfg.TemplatesFolderPath, loadedCfg.TemplatesFolderPath)
 	}
 
+	if loadedCfg.DefaultQueryParams["api_version"] != "2" {
+		t.Errorf("DefaultQueryParams不匹配，期望: %v, 实际: %v",
+			originalCfg.DefaultQueryParams, loadedCfg.DefaultQueryParams)
+	}
+
 	// 检查头部
 	if len(loadedCfg.DefaultHeaders) != len(originalCfg.DefaultHeaders) {
 		t.Errorf("DefaultHeaders长度不匹配，期望: %d, 实际: %d",
@@ -0,0 +1,137 @@
+package hooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultTimestampHeader 默认的时间戳请求头
+	DefaultTimestampHeader = "X-Timestamp"
+	// DefaultSignatureHeader 默认的签名请求头
+	DefaultSignatureHeader = "X-Signature"
+	// DefaultMaxSkew 默认允许的时间偏差
+	DefaultMaxSkew = 5 * time.Minute
+)
+
+// AntiReplayHook 防重放签名钩子
+// 在请求头中写入当前Unix时间戳（秒），以及对以下内容计算的HMAC-SHA256签名（十六进制）：
+//
+//	METHOD + "\n" + 路径（含查询参数）+ "\n" + 时间戳 + "\n" + 请求体
+//
+// 服务端应使用相同的密钥重新计算签名并用常量时间比较，
+// 同时拒绝时间戳与服务器时间偏差超过MaxSkew的请求；
+// 如需完全防止窗口期内的重放，服务端还应在窗口期内记录已使用的签名。
+// 服务端可直接使用VerifyRequest完成签名和时间校验
+type AntiReplayHook struct {
+	Secret          string
+	TimestampHeader string        // 为空时使用X-Timestamp
+	SignatureHeader string        // 为空时使用X-Signature
+	MaxSkew         time.Duration // 服务端校验允许的时间偏差，为0时使用5分钟
+}
+
+// NewAntiReplayHook 使用默认请求头创建防重放签名钩子
+func NewAntiReplayHook(secret string) *AntiReplayHook {
+	return &AntiReplayHook{
+		Secret:          secret,
+		TimestampHeader: DefaultTimestampHeader,
+		SignatureHeader: DefaultSignatureHeader,
+		MaxSkew:         DefaultMaxSkew,
+	}
+}
+
+// Before 为请求添加时间戳和签名
+func (h *AntiReplayHook) Before(req *http.Request) (*http.Request, error) {
+	body, err := ReadRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(h.timestampHeader(), timestamp)
+	req.Header.Set(h.signatureHeader(), h.sign(req, timestamp, body))
+	return req, nil
+}
+
+// BeforeAsync 异步为请求添加时间戳和签名
+func (h *AntiReplayHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
+	reqChan := make(chan *http.Request, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedReq, err := h.Before(req)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		reqChan <- modifiedReq
+	}()
+
+	return reqChan, errChan
+}
+
+// VerifyRequest 服务端校验请求的时间戳和签名，请求体读取后会被重置
+func (h *AntiReplayHook) VerifyRequest(req *http.Request, now time.Time) error {
+	timestamp := req.Header.Get(h.timestampHeader())
+	signature := req.Header.Get(h.signatureHeader())
+	if timestamp == "" || signature == "" {
+		return errors.New("缺少时间戳或签名")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("时间戳格式错误: %s", timestamp)
+	}
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > h.maxSkew() {
+		return fmt.Errorf("时间戳超出允许的偏差范围: %v", skew)
+	}
+
+	body, err := ReadRequestBody(req)
+	if err != nil {
+		return fmt.Errorf("读取请求体失败: %w", err)
+	}
+	expected := h.sign(req, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errors.New("签名不匹配")
+	}
+	return nil
+}
+
+// sign 计算签名
+func (h *AntiReplayHook) sign(req *http.Request, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write([]byte(req.Method + "\n" + req.URL.RequestURI() + "\n" + timestamp + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *AntiReplayHook) timestampHeader() string {
+	if h.TimestampHeader == "" {
+		return DefaultTimestampHeader
+	}
+	return h.TimestampHeader
+}
+
+func (h *AntiReplayHook) signatureHeader() string {
+	if h.SignatureHeader == "" {
+		return DefaultSignatureHeader
+	}
+	return h.SignatureHeader
+}
+
+func (h *AntiReplayHook) maxSkew() time.Duration {
+	if h.MaxSkew <= 0 {
+		return DefaultMaxSkew
+	}
+	return h.MaxSkew
+}
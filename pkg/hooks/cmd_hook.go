@@ -3,15 +3,18 @@ package hooks
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os/exec"
+	"sync"
 	"time"
 )
 
 // CommandHook 命令行执行钩子
 type CommandHook struct {
+	Name    string // 钩子名称，用于错误信息，模板定义中的name会写入此字段
 	Command string
 	Timeout time.Duration
 	IsAsync bool
@@ -44,6 +47,11 @@ func (h *CommandHook) Before(req *http.Request) (*http.Request, error) {
 	return h.executeCommand(req)
 }
 
+// GetConfig 返回钩子配置，客户端按其中的名称、异步标志和超时时间执行钩子
+func (h *CommandHook) GetConfig() *HookConfig {
+	return newHookConfig("command", h.Name, h.IsAsync, h.Timeout)
+}
+
 // BeforeAsync 异步执行命令行命令处理请求
 func (h *CommandHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
 	reqChan := make(chan *http.Request, 1)
@@ -105,9 +113,12 @@ func (h *CommandHook) executeCommand(req *http.Request) (*http.Request, error) {
 
 // CommandResponseHook 命令行执行响应钩子
 type CommandResponseHook struct {
+	Name    string // 钩子名称，用于错误信息
 	Command string
 	Timeout time.Duration
 	IsAsync bool
+
+	MaxResponseBytes int64 // 读取响应体的最大字节数，<=0表示不限制
 }
 
 // NewCommandResponseHook 创建一个新的命令行执行响应钩子
@@ -137,6 +148,11 @@ func (h *CommandResponseHook) After(resp *http.Response) (*http.Response, error)
 	return h.executeCommand(resp)
 }
 
+// GetConfig 返回钩子配置，客户端按其中的名称、异步标志和超时时间执行钩子
+func (h *CommandResponseHook) GetConfig() *HookConfig {
+	return newHookConfig("command", h.Name, h.IsAsync, h.Timeout)
+}
+
 // AfterAsync 异步执行命令行命令处理响应
 func (h *CommandResponseHook) AfterAsync(resp *http.Response) (chan *http.Response, chan error) {
 	respChan := make(chan *http.Response, 1)
@@ -164,7 +180,7 @@ func (h *CommandResponseHook) executeCommand(resp *http.Response) (*http.Respons
 	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
 
 	// 读取响应体
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, err := ReadLimited(resp.Body, h.MaxResponseBytes)
 	if err != nil {
 		return resp, fmt.Errorf("读取响应体失败: %w", err)
 	}
@@ -199,3 +215,147 @@ func (h *CommandResponseHook) executeCommand(resp *http.Response) (*http.Respons
 	resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 	return resp, nil
 }
+
+// commandStreamWaitDelay 命令退出后等待标准输入复制结束的最长时间
+const commandStreamWaitDelay = time.Second
+
+// CommandStreamResponseHook 流式命令行响应钩子
+// 与CommandResponseHook不同，响应体不会被完整读入内存：原响应体直接作为命令的标准输入，
+// 命令的标准输出作为新的响应体边产生边返回，适合逐行转换较大的流式响应。
+// 命令以非零状态退出时，读取新响应体到末尾时返回错误；提前关闭响应体会终止命令
+type CommandStreamResponseHook struct {
+	Command string
+	Timeout time.Duration // 从命令启动到响应体读取完毕的时间上限，<=0表示不限制
+}
+
+// NewCommandStreamResponseHook 创建一个新的流式命令行响应钩子
+func NewCommandStreamResponseHook(command string, timeoutSeconds int) *CommandStreamResponseHook {
+	return &CommandStreamResponseHook{
+		Command: command,
+		Timeout: time.Duration(timeoutSeconds) * time.Second,
+	}
+}
+
+// After 启动命令并将响应体替换为命令的标准输出
+func (h *CommandStreamResponseHook) After(resp *http.Response) (*http.Response, error) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if h.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = resp.Body
+	// 命令未读完标准输入就退出时（如head），不等待原响应体传输完毕
+	cmd.WaitDelay = commandStreamWaitDelay
+	stderr := &limitedBuffer{limit: 4096}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return resp, fmt.Errorf("创建命令输出管道失败: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return resp, fmt.Errorf("启动命令失败: %w", err)
+	}
+
+	resp.Body = &commandStreamBody{
+		stdout:   stdout,
+		original: resp.Body,
+		cmd:      cmd,
+		cancel:   cancel,
+		stderr:   stderr,
+	}
+	// 输出长度未知
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// AfterAsync 异步启动命令，命令启动后立即返回，响应体仍以流式读取
+func (h *CommandStreamResponseHook) AfterAsync(resp *http.Response) (chan *http.Response, chan error) {
+	respChan := make(chan *http.Response, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedResp, err := h.After(resp)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		respChan <- modifiedResp
+	}()
+
+	return respChan, errChan
+}
+
+// commandStreamBody 以命令的标准输出作为响应体，读到末尾或关闭时等待命令退出
+type commandStreamBody struct {
+	stdout   io.ReadCloser
+	original io.ReadCloser
+	cmd      *exec.Cmd
+	cancel   context.CancelFunc
+	stderr   *limitedBuffer
+
+	once    sync.Once
+	waitErr error
+}
+
+// Read 读取命令输出，读到末尾时检查命令的退出状态
+func (b *commandStreamBody) Read(p []byte) (int, error) {
+	n, err := b.stdout.Read(p)
+	if err == io.EOF {
+		if waitErr := b.wait(); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// Close 关闭原响应体并终止尚未结束的命令
+func (b *commandStreamBody) Close() error {
+	// 先关闭原响应体，解除向命令标准输入复制数据时的阻塞
+	err := b.original.Close()
+	b.cancel()
+	b.wait()
+	return err
+}
+
+// wait 等待命令退出，只执行一次
+func (b *commandStreamBody) wait() error {
+	b.once.Do(func() {
+		if err := b.cmd.Wait(); err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+			b.waitErr = fmt.Errorf("命令执行失败: %v, stderr: %s", err, b.stderr.String())
+		}
+		b.cancel()
+	})
+	return b.waitErr
+}
+
+// limitedBuffer 只保留前limit个字节的缓冲区，超出部分直接丢弃
+type limitedBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write 写入数据，超过上限的部分被丢弃但仍报告写入成功，避免命令因stderr写入失败而退出
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// String 返回已保留的内容
+func (b *limitedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
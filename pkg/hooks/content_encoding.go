@@ -0,0 +1,54 @@
+package hooks
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decodeResponseBody 按Content-Encoding解压已读取的响应体，解压后删除Content-Encoding和Content-Length响应头
+// 支持gzip和deflate；没有Content-Encoding、为identity或是不支持的编码（如br）时原样返回。
+// limit>0时解压后的内容超过limit字节返回ErrResponseTooLarge
+func decodeResponseBody(resp *http.Response, body []byte, limit int64) ([]byte, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	var reader io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("解压gzip响应体失败: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	case "deflate":
+		// deflate按规范是zlib格式，部分服务器发送不带zlib头的原始deflate数据
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			fr := flate.NewReader(bytes.NewReader(body))
+			defer fr.Close()
+			reader = fr
+		} else {
+			defer zr.Close()
+			reader = zr
+		}
+	default:
+		return body, nil
+	}
+
+	decoded, err := ReadLimited(reader, limit)
+	if err != nil {
+		return nil, fmt.Errorf("解压%s响应体失败: %w", encoding, err)
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(decoded))
+	resp.Uncompressed = true
+	return decoded, nil
+}
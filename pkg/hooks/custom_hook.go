@@ -6,12 +6,24 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+	"github.com/birdmichael/RenderAPI/pkg/logger"
 )
 
 // CustomFunctionHook 自定义钩子实现
 type CustomFunctionHook struct {
 	BeforeFn func(req *http.Request) (*http.Request, error)
 	AfterFn  func(resp *http.Response) (*http.Response, error)
+
+	// Independent 声明自定义函数只操作头部等元数据，不读写请求体/响应体，
+	// 启用异步钩子流水线时可与其他独立钩子并发执行
+	Independent bool
+}
+
+// IsIndependent 返回钩子是否与其他钩子相互独立
+func (h *CustomFunctionHook) IsIndependent() bool {
+	return h.Independent
 }
 
 // Before 执行自定义前置操作
@@ -76,11 +88,34 @@ func NewCustomFunctionHook(
 }
 
 // LoggingHook 日志记录钩子
-type LoggingHook struct{}
+// URL中password、token、authorization等敏感查询参数的值会被替换为***；
+// Verbose为true时同时输出请求头和请求体，其中的敏感值同样被替换，实际发送的请求不受影响
+type LoggingHook struct {
+	Logger     logger.Logger // 日志记录器，为nil时使用logger.Default()
+	Verbose    bool          // 是否输出请求头和请求体
+	RedactKeys []string      // 除默认的敏感键外需要隐藏的字段名、请求头名或查询参数名
+}
+
+// SetLogger 设置日志记录器
+func (h *LoggingHook) SetLogger(l logger.Logger) {
+	h.Logger = l
+}
 
 // Before 记录请求信息
 func (h *LoggingHook) Before(req *http.Request) (*http.Request, error) {
-	fmt.Printf("正在发送 %s 请求到 %s\n", req.Method, req.URL.String())
+	log := logger.OrDefault(h.Logger)
+	keys := utils.RedactKeys(h.RedactKeys...)
+	log.Infof("正在发送 %s 请求到 %s", req.Method, utils.RedactURL(req.URL, keys))
+	if !h.Verbose {
+		return req, nil
+	}
+
+	body, err := ReadRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	utils.LogHeader(log, "请求头", req.Header, keys)
+	utils.LogBody(log, "请求体", body, req.Header.Get("Content-Type"), keys)
 	return req, nil
 }
 
@@ -101,17 +136,58 @@ func (h *LoggingHook) BeforeAsync(req *http.Request) (chan *http.Request, chan e
 	return reqChan, errChan
 }
 
+// IsIndependent 日志钩子只读取请求信息，可与其他独立钩子并发执行；Verbose时需要读取请求体，不能并发
+func (h *LoggingHook) IsIndependent() bool {
+	return !h.Verbose
+}
+
 // NewLoggingHook 创建新的日志钩子
 func NewLoggingHook() *LoggingHook {
 	return &LoggingHook{}
 }
 
 // ResponseLogHook 响应日志钩子
-type ResponseLogHook struct{}
+// Verbose为true时同时输出响应头和响应体，其中的敏感值被替换为***，返回给调用方的响应不受影响
+type ResponseLogHook struct {
+	Logger     logger.Logger // 日志记录器，为nil时使用logger.Default()
+	Verbose    bool          // 是否输出响应头和响应体
+	RedactKeys []string      // 除默认的敏感键外需要隐藏的字段名或响应头名
+}
+
+// SetLogger 设置日志记录器
+func (h *ResponseLogHook) SetLogger(l logger.Logger) {
+	h.Logger = l
+}
 
-// After 记录响应信息
+// After 记录响应信息，请求经过TraceIDHook时同时输出请求ID
 func (h *ResponseLogHook) After(resp *http.Response) (*http.Response, error) {
-	fmt.Printf("收到响应: 状态码 %d\n", resp.StatusCode)
+	log := logger.OrDefault(h.Logger)
+	id, hasID := "", false
+	if resp.Request != nil {
+		id, hasID = TraceIDFromContext(resp.Request.Context())
+	}
+	if hasID {
+		log.Infof("收到响应: 状态码 %d, 请求ID %s", resp.StatusCode, id)
+	} else {
+		log.Infof("收到响应: 状态码 %d", resp.StatusCode)
+	}
+	if !h.Verbose {
+		return resp, nil
+	}
+
+	var body []byte
+	if resp.Body != nil {
+		var err error
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取响应体失败: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	keys := utils.RedactKeys(h.RedactKeys...)
+	utils.LogHeader(log, "响应头", resp.Header, keys)
+	utils.LogBody(log, "响应体", body, resp.Header.Get("Content-Type"), keys)
 	return resp, nil
 }
 
@@ -132,6 +208,11 @@ func (h *ResponseLogHook) AfterAsync(resp *http.Response) (chan *http.Response,
 	return respChan, errChan
 }
 
+// IsIndependent 响应日志钩子只读取状态码，可与其他独立钩子并发执行；Verbose时需要读取响应体，不能并发
+func (h *ResponseLogHook) IsIndependent() bool {
+	return !h.Verbose
+}
+
 // NewResponseLogHook 创建新的响应日志钩子
 func NewResponseLogHook() *ResponseLogHook {
 	return &ResponseLogHook{}
@@ -165,6 +246,11 @@ func (h *AuthHook) BeforeAsync(req *http.Request) (chan *http.Request, chan erro
 	return reqChan, errChan
 }
 
+// IsIndependent 认证钩子只设置请求头，可与其他独立钩子并发执行
+func (h *AuthHook) IsIndependent() bool {
+	return true
+}
+
 // NewAuthHook 创建新的认证钩子
 func NewAuthHook(token string) *AuthHook {
 	return &AuthHook{
@@ -172,15 +258,17 @@ func NewAuthHook(token string) *AuthHook {
 	}
 }
 
-// FieldTransformHook 字段转换钩子
+// FieldTransformHook 字段转换钩子，依次重命名字段、补充缺少的字段、删除字段
 type FieldTransformHook struct {
-	TransformMap map[string]string // 源字段到目标字段的映射
+	TransformMap map[string]string      // 源字段到目标字段的映射
+	AddIfMissing map[string]interface{} // 顶层缺少该字段时添加的默认值，字段存在（包括值为null）时不覆盖
+	Remove       []string               // 需要删除的字段，在重命名和补充之后执行
 }
 
 // Before 在请求前转换JSON字段
 func (h *FieldTransformHook) Before(req *http.Request) (*http.Request, error) {
-	// 只处理POST和PUT请求
-	if req.Method != http.MethodPost && req.Method != http.MethodPut {
+	// 只处理POST和PUT请求，原始字节请求体不做转换
+	if req.Method != http.MethodPost && req.Method != http.MethodPut || IsRawBody(req) {
 		return req, nil
 	}
 
@@ -212,6 +300,20 @@ func (h *FieldTransformHook) Before(req *http.Request) (*http.Request, error) {
 		}
 	}
 
+	for field, val := range h.AddIfMissing {
+		if _, ok := data[field]; !ok {
+			data[field] = val
+			transformed = true
+		}
+	}
+
+	for _, field := range h.Remove {
+		if _, ok := data[field]; ok {
+			delete(data, field)
+			transformed = true
+		}
+	}
+
 	// 只在有转换时重新编码
 	if transformed {
 		newBody, err := encodeJSONBody(data)
@@ -9,6 +9,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/birdmichael/RenderAPI/pkg/logger"
 )
 
 // 定义错误类型
@@ -17,6 +19,10 @@ var (
 	ErrCmdHookMissingSourceOrContent = errors.New("命令钩子必须指定source或content")
 	ErrCustomHookNotSupported        = errors.New("自定义钩子不能通过模板创建，需要在代码中注册")
 	ErrUnsupportedHookType           = errors.New("不支持的钩子类型")
+	ErrResponseTooLarge              = errors.New("响应体超过大小限制")
+	ErrScriptTimeout                 = errors.New("脚本执行超时")
+	ErrInvalidResponseSignature      = errors.New("响应签名校验失败")
+	ErrHookTimeout                   = errors.New("钩子执行超时")
 )
 
 // BeforeRequestHookFunc 请求前钩子函数
@@ -32,12 +38,28 @@ type BeforeRequestHook interface {
 }
 
 // AfterResponseHook 响应后钩子接口
+// After可以原地修改并返回传入的响应，也可以返回一个全新的*http.Response来整体替换它
+// （例如缓存或Mock钩子）。返回新响应时客户端会负责关闭被替换的原响应体，钩子无需自行关闭
 type AfterResponseHook interface {
 	After(resp *http.Response) (*http.Response, error)
 	AfterAsync(resp *http.Response) (chan *http.Response, chan error)
 }
 
+// IndependentHook 可选接口，声明钩子与其他钩子相互独立
+// 独立钩子只读取或修改请求/响应头等元数据，不读取也不修改请求体或响应体，
+// 因此客户端启用异步钩子流水线时可以将相邻的独立钩子并发执行
+type IndependentHook interface {
+	IsIndependent() bool
+}
+
+// LoggerAware 可选接口，声明钩子会输出日志
+// 客户端通过WithLogger设置了日志记录器时，会在添加钩子时注入该记录器
+type LoggerAware interface {
+	SetLogger(l logger.Logger)
+}
+
 // Hook 通用钩子接口
+// 客户端执行实现了该接口的请求前钩子或响应后钩子时按GetConfig返回的配置执行
 type Hook interface {
 	GetConfig() *HookConfig
 }
@@ -45,9 +67,9 @@ type Hook interface {
 // HookConfig 钩子配置
 type HookConfig struct {
 	Type           string
-	Name           string
-	Async          bool
-	TimeoutSeconds int
+	Name           string // 钩子名称，不为空时出现在钩子的错误信息中
+	Async          bool   // 为true时客户端通过BeforeAsync/AfterAsync执行钩子并等待结果
+	TimeoutSeconds int    // 大于0时钩子超过该时间未完成则中止请求
 }
 
 // HookDefinition 钩子定义
@@ -62,6 +84,59 @@ type HookDefinition struct {
 	Timeout  int               `json:"timeout,omitempty"`
 }
 
+// ReadLimited 读取全部内容，limit>0时超过limit字节返回ErrResponseTooLarge
+func ReadLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	// 多读一个字节用于判断是否超限
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w(%d字节)", ErrResponseTooLarge, limit)
+	}
+	return data, nil
+}
+
+// LimitBody 包装响应体，读取超过limit字节时返回ErrResponseTooLarge；limit<=0时原样返回
+// 与io.LimitReader不同，超限时返回错误而不是静默截断
+func LimitBody(body io.ReadCloser, limit int64) io.ReadCloser {
+	if limit <= 0 || body == nil {
+		return body
+	}
+	return &limitedBody{ReadCloser: body, limit: limit, remaining: limit}
+}
+
+// limitedBody 限制读取字节数的响应体
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+// Read 实现io.Reader
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// 已达到上限，探测是否还有剩余数据
+		var probe [1]byte
+		n, err := b.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w(%d字节)", ErrResponseTooLarge, b.limit)
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
 // ReadRequestBody 读取请求体内容并重置Body
 func ReadRequestBody(req *http.Request) ([]byte, error) {
 	if req == nil || req.Body == nil {
@@ -92,14 +167,31 @@ func IsBodyJSON(req *http.Request) bool {
 	return contentType == "application/json" || contentType == "application/json; charset=utf-8"
 }
 
+// rawBodyKey 原始字节请求体标记在上下文中的键
+type rawBodyKey struct{}
+
+// WithRawBody 返回标记请求体为原始字节（如protobuf、图片、CSV）的上下文
+// 使用该上下文的请求会被FieldTransformHook、UnflattenBodyHook、RequestSchemaHook等JSON相关的钩子跳过，
+// NormalizeBodyHook也不会修改其Content-Type
+func WithRawBody(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawBodyKey{}, true)
+}
+
+// IsRawBody 判断请求是否通过WithRawBody标记为原始字节请求体
+func IsRawBody(req *http.Request) bool {
+	raw, _ := req.Context().Value(rawBodyKey{}).(bool)
+	return raw
+}
+
 // ExecuteHookWithTimeout 带超时执行钩子
+// 超时后立即返回ErrHookTimeout，ctx先被取消时返回ctx.Err()；hook仍在后台运行直到结束，其结果被丢弃
 func ExecuteHookWithTimeout(ctx context.Context, hook func() error, timeoutSeconds int) error {
 	if timeoutSeconds <= 0 {
 		// 默认超时10秒
 		timeoutSeconds = 10
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
 	defer cancel()
 
 	errCh := make(chan error, 1)
@@ -110,8 +202,21 @@ func ExecuteHookWithTimeout(ctx context.Context, hook func() error, timeoutSecon
 	select {
 	case err := <-errCh:
 		return err
-	case <-ctx.Done():
-		return errors.New("hook execution timed out")
+	case <-timeoutCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return ErrHookTimeout
+	}
+}
+
+// newHookConfig 根据钩子自身的字段生成配置，超时时间不足1秒的部分向上取整
+func newHookConfig(hookType, name string, async bool, timeout time.Duration) *HookConfig {
+	return &HookConfig{
+		Type:           hookType,
+		Name:           name,
+		Async:          async,
+		TimeoutSeconds: int((timeout + time.Second - 1) / time.Second),
 	}
 }
 
@@ -119,9 +224,16 @@ func ExecuteHookWithTimeout(ctx context.Context, hook func() error, timeoutSecon
 func CreateHookFromDefinition(def *HookDefinition) (interface{}, error) {
 	switch def.Type {
 	case "js":
-		return NewJSHookFromString(def.Script, def.Async, def.Timeout)
+		hook, err := NewJSHookFromString(def.Script, def.Async, def.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		hook.Name = def.Name
+		return hook, nil
 	case "command":
-		return NewCommandHook(def.Command, def.Timeout, def.Async), nil
+		hook := NewCommandHook(def.Command, def.Timeout, def.Async)
+		hook.Name = def.Name
+		return hook, nil
 	case "function":
 		return nil, fmt.Errorf("未实现的钩子类型: %s", def.Type)
 	default:
@@ -1,14 +1,25 @@
 package hooks
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -46,6 +57,59 @@ func TestLoggingHook(t *testing.T) {
 	}
 }
 
+// TestLoggingHookRedaction 测试日志钩子隐藏敏感值且不修改实际请求和响应
+func TestLoggingHookRedaction(t *testing.T) {
+	log := &recordLogger{}
+	hook := &LoggingHook{Logger: log, Verbose: true, RedactKeys: []string{"idCard"}}
+
+	body := `{"username":"alice","password":"p@ss","profile":{"id_card":"110101","city":"上海"},"items":[{"refresh_token":"r-1"}]}`
+	req, _ := http.NewRequest("POST", "https://example.com/login?access_token=abc&page=2", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Api-Key", "key-123")
+	req.Header.Set("X-Tenant", "acme")
+
+	req, err := hook.Before(req)
+	if err != nil {
+		t.Fatalf("执行钩子失败: %v", err)
+	}
+
+	for _, secret := range []string{"p@ss", "110101", "r-1", "secret-token", "key-123", "abc"} {
+		if log.contains(secret) {
+			t.Errorf("日志中不应出现敏感值: %s", secret)
+		}
+	}
+	for _, visible := range []string{"alice", "上海", "acme", "page=2", "***"} {
+		if !log.contains(visible) {
+			t.Errorf("日志中应出现: %s", visible)
+		}
+	}
+
+	sent, _ := ReadRequestBody(req)
+	if string(sent) != body || req.Header.Get("Authorization") != "Bearer secret-token" {
+		t.Errorf("实际请求不应被修改，请求体: %s", sent)
+	}
+
+	// 响应日志
+	log = &recordLogger{}
+	respHook := &ResponseLogHook{Logger: log, Verbose: true}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Set-Cookie": {"session=s-1"}, "Content-Type": {"application/x-www-form-urlencoded"}},
+		Body:       io.NopCloser(strings.NewReader("token=t-1&user=bob")),
+	}
+	resp, err = respHook.After(resp)
+	if err != nil {
+		t.Fatalf("执行钩子失败: %v", err)
+	}
+	if log.contains("s-1") || log.contains("t-1") || !log.contains("user=bob") {
+		t.Errorf("响应日志未正确隐藏敏感值: %v", log.lines)
+	}
+	if received, _ := io.ReadAll(resp.Body); string(received) != "token=t-1&user=bob" {
+		t.Errorf("响应体不应被修改: %s", received)
+	}
+}
+
 // TestAuthHook 测试认证钩子
 func TestAuthHook(t *testing.T) {
 	// 创建带有令牌的钩子
@@ -306,6 +370,81 @@ func TestFieldTransformHook(t *testing.T) {
 	}
 }
 
+// TestFieldTransformHookDefaultsAndRemove 测试同一个钩子重命名、补充默认值和删除字段
+func TestFieldTransformHookDefaultsAndRemove(t *testing.T) {
+	hook := &FieldTransformHook{
+		TransformMap: map[string]string{"user": "phone"},
+		AddIfMissing: map[string]interface{}{"source": "api", "retry": 0, "phone": "默认号码"},
+		Remove:       []string{"debug", "phone_backup"},
+	}
+
+	testCases := []struct {
+		name         string
+		method       string
+		body         string
+		expectedBody string
+	}{
+		{
+			name:         "重命名补充并删除",
+			method:       http.MethodPost,
+			body:         `{"user": "13800138000", "debug": true, "phone_backup": "1"}`,
+			expectedBody: `{"phone":"13800138000","retry":0,"source":"api"}`,
+		},
+		{
+			name:         "已存在的字段不覆盖",
+			method:       http.MethodPut,
+			body:         `{"source": "web", "retry": null}`,
+			expectedBody: `{"phone":"默认号码","retry":null,"source":"web"}`,
+		},
+		{
+			name:         "补充所有缺少的字段",
+			method:       http.MethodPost,
+			body:         `{"name": "张三"}`,
+			expectedBody: `{"name":"张三","phone":"默认号码","retry":0,"source":"api"}`,
+		},
+		{
+			name:         "GET请求不处理",
+			method:       http.MethodGet,
+			body:         `{"debug": true}`,
+			expectedBody: `{"debug": true}`,
+		},
+		{
+			name:         "非JSON请求体不处理",
+			method:       http.MethodPost,
+			body:         `debug=true`,
+			expectedBody: `debug=true`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, "https://example.com", bytes.NewBufferString(tc.body))
+			modifiedReq, err := hook.Before(req)
+			if err != nil {
+				t.Fatalf("执行字段转换钩子失败: %v", err)
+			}
+			body, _ := io.ReadAll(modifiedReq.Body)
+			if string(body) != tc.expectedBody {
+				t.Errorf("请求体错误，\n期望: %s\n实际: %s", tc.expectedBody, body)
+			}
+		})
+	}
+
+	// 补充后又在Remove中的字段最终被删除
+	removeAdded := &FieldTransformHook{
+		AddIfMissing: map[string]interface{}{"source": "api"},
+		Remove:       []string{"source"},
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com", bytes.NewBufferString(`{"name": "张三"}`))
+	modifiedReq, err := removeAdded.Before(req)
+	if err != nil {
+		t.Fatalf("执行字段转换钩子失败: %v", err)
+	}
+	if body, _ := io.ReadAll(modifiedReq.Body); string(body) != `{"name":"张三"}` {
+		t.Errorf("Remove应在AddIfMissing之后执行，实际: %s", body)
+	}
+}
+
 // TestJSHook 测试从文件创建JavaScript钩子
 func TestJSHook(t *testing.T) {
 	// 创建临时脚本文件
@@ -1043,3 +1182,1015 @@ func TestAsyncHookChaining(t *testing.T) {
 		t.Fatal("异步执行自定义钩子超时")
 	}
 }
+
+// TestResponseSignatureStripHook 测试响应签名校验钩子
+func TestResponseSignatureStripHook(t *testing.T) {
+	const body = `{"order":"42","amount":100}`
+	mac := hmac.New(sha256.New, []byte("server-secret"))
+	mac.Write([]byte(body))
+	valid := hex.EncodeToString(mac.Sum(nil))
+
+	newResp := func(signature string) *http.Response {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+		if signature != "" {
+			resp.Header.Set("X-Signature", signature)
+		}
+		return resp
+	}
+
+	hook := NewResponseSignatureStripHook("server-secret")
+	for _, signature := range []string{valid, "sha256=" + strings.ToUpper(valid)} {
+		resp, err := hook.After(newResp(signature))
+		if err != nil {
+			t.Fatalf("签名正确时不应返回错误: %v", err)
+		}
+		if resp.Header.Get("X-Signature") != "" {
+			t.Error("校验通过后应删除签名响应头")
+		}
+		if received, _ := io.ReadAll(resp.Body); string(received) != body {
+			t.Errorf("响应体应保持不变: %s", received)
+		}
+	}
+
+	tampered := valid[:len(valid)-1] + "0"
+	if tampered == valid {
+		tampered = valid[:len(valid)-1] + "1"
+	}
+	for name, signature := range map[string]string{
+		"签名不匹配":  tampered,
+		"签名格式错误": "not-hex",
+		"缺少签名":   "",
+	} {
+		if _, err := hook.After(newResp(signature)); !errors.Is(err, ErrInvalidResponseSignature) {
+			t.Errorf("%s时应返回ErrInvalidResponseSignature，实际: %v", name, err)
+		}
+	}
+
+	wrongKey := &ResponseSignatureStripHook{Secret: "other-secret", Header: "X-Body-Signature"}
+	resp := newResp("")
+	resp.Header.Set("X-Body-Signature", valid)
+	if _, err := wrongKey.After(resp); !errors.Is(err, ErrInvalidResponseSignature) {
+		t.Errorf("密钥不同时应返回ErrInvalidResponseSignature，实际: %v", err)
+	}
+}
+
+// TestAntiReplayHook 测试防重放签名钩子
+func TestAntiReplayHook(t *testing.T) {
+	hook := NewAntiReplayHook("secret")
+
+	body := []byte(`{"amount": 100}`)
+	req, _ := http.NewRequest("POST", "https://api.example.com/orders?id=1", bytes.NewReader(body))
+
+	signedReq, err := hook.Before(req)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	if signedReq.Header.Get("X-Timestamp") == "" || signedReq.Header.Get("X-Signature") == "" {
+		t.Fatal("应设置时间戳和签名请求头")
+	}
+
+	// 请求体应保持可读
+	data, _ := io.ReadAll(signedReq.Body)
+	if !bytes.Equal(data, body) {
+		t.Errorf("签名后请求体被修改: %s", data)
+	}
+	signedReq.Body = io.NopCloser(bytes.NewReader(data))
+
+	// 服务端校验通过
+	if err := hook.VerifyRequest(signedReq, time.Now()); err != nil {
+		t.Errorf("签名校验失败: %v", err)
+	}
+
+	// 超出时间窗口
+	if err := hook.VerifyRequest(signedReq, time.Now().Add(10*time.Minute)); err == nil {
+		t.Error("超出时间偏差的请求应校验失败")
+	}
+
+	// 篡改请求体
+	signedReq.Body = io.NopCloser(strings.NewReader(`{"amount": 1000}`))
+	if err := hook.VerifyRequest(signedReq, time.Now()); err == nil {
+		t.Error("篡改请求体后应校验失败")
+	}
+
+	// 密钥不同
+	other := NewAntiReplayHook("other")
+	signedReq.Body = io.NopCloser(bytes.NewReader(body))
+	if err := other.VerifyRequest(signedReq, time.Now()); err == nil {
+		t.Error("使用不同密钥应校验失败")
+	}
+}
+
+// TestTraceIDHook 测试请求ID钩子
+func TestTraceIDHook(t *testing.T) {
+	hook := NewTraceIDHook()
+	hook.Traceparent = true
+
+	// 生成新的请求ID
+	req, _ := http.NewRequest("GET", "https://api.example.com/users", nil)
+	req, err := hook.Before(req)
+	if err != nil {
+		t.Fatalf("执行钩子失败: %v", err)
+	}
+
+	id := req.Header.Get("X-Request-ID")
+	if len(id) != 36 || id[14] != '4' {
+		t.Errorf("生成的请求ID不是有效的UUIDv4: %s", id)
+	}
+	if ctxID, ok := TraceIDFromContext(req.Context()); !ok || ctxID != id {
+		t.Errorf("上下文中的请求ID错误: %s", ctxID)
+	}
+	if tp := req.Header.Get("traceparent"); len(tp) != 55 || !strings.HasPrefix(tp, "00-") {
+		t.Errorf("traceparent格式错误: %s", tp)
+	}
+
+	// 保留已有的请求ID
+	custom := &TraceIDHook{Header: "X-Correlation-ID"}
+	req, _ = http.NewRequest("GET", "https://api.example.com/users", nil)
+	req.Header.Set("X-Correlation-ID", "existing-id")
+	req, err = custom.Before(req)
+	if err != nil {
+		t.Fatalf("执行钩子失败: %v", err)
+	}
+	if got := req.Header.Get("X-Correlation-ID"); got != "existing-id" {
+		t.Errorf("应保留已有的请求ID，实际: %s", got)
+	}
+	if ctxID, _ := TraceIDFromContext(req.Context()); ctxID != "existing-id" {
+		t.Errorf("上下文中的请求ID错误: %s", ctxID)
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Error("未启用时不应设置traceparent")
+	}
+}
+
+// TestIdempotencyHook 测试幂等键钩子
+func TestIdempotencyHook(t *testing.T) {
+	hook := NewIdempotencyHook()
+
+	// 生成新的幂等键，再次经过钩子时保持不变
+	req, _ := http.NewRequest("POST", "https://api.example.com/orders", nil)
+	req, err := hook.Before(req)
+	if err != nil {
+		t.Fatalf("执行钩子失败: %v", err)
+	}
+	key := req.Header.Get("Idempotency-Key")
+	if len(key) != 36 {
+		t.Errorf("生成的幂等键不是有效的UUID: %s", key)
+	}
+	retry := req.Clone(req.Context())
+	retry.Header.Del("Idempotency-Key")
+	if retry, _ = hook.Before(retry); retry.Header.Get("Idempotency-Key") != key {
+		t.Errorf("同一请求应沿用幂等键，期望: %s, 实际: %s", key, retry.Header.Get("Idempotency-Key"))
+	}
+
+	// 使用上下文中指定的幂等键
+	req, _ = http.NewRequestWithContext(WithIdempotencyKey(context.Background(), "order-42"), "PATCH", "https://api.example.com/orders/42", nil)
+	if req, _ = hook.Before(req); req.Header.Get("Idempotency-Key") != "order-42" {
+		t.Errorf("应使用指定的幂等键，实际: %s", req.Header.Get("Idempotency-Key"))
+	}
+
+	// 幂等的请求方法不设置
+	req, _ = http.NewRequest("GET", "https://api.example.com/orders", nil)
+	if req, _ = hook.Before(req); req.Header.Get("Idempotency-Key") != "" {
+		t.Error("GET请求不应设置幂等键")
+	}
+}
+
+// TestResponseHookMaxBytes 测试响应钩子的响应体大小限制
+func TestResponseHookMaxBytes(t *testing.T) {
+	newResp := func(size int) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(strings.Repeat("a", size))),
+		}
+	}
+
+	// ReadLimited边界
+	if data, err := ReadLimited(strings.NewReader("abc"), 3); err != nil || string(data) != "abc" {
+		t.Errorf("未超限时应完整读取: %s, %v", data, err)
+	}
+	if _, err := ReadLimited(strings.NewReader("abcd"), 3); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("超限时应返回ErrResponseTooLarge, 实际: %v", err)
+	}
+
+	// 命令响应钩子
+	cmdHook := NewCommandResponseHook("cat", 5, false)
+	cmdHook.MaxResponseBytes = 10
+	if _, err := cmdHook.After(newResp(11)); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("命令响应钩子应拒绝超限响应, 实际: %v", err)
+	}
+
+	// JS响应钩子
+	jsHook, err := NewJSResponseHookFromString(`function processResponse(response) { return response; }`, false, 5)
+	if err != nil {
+		t.Fatalf("创建JS钩子失败: %v", err)
+	}
+	jsHook.MaxResponseBytes = 10
+	if _, err := jsHook.After(newResp(11)); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("JS响应钩子应拒绝超限响应, 实际: %v", err)
+	}
+	if _, err := jsHook.After(newResp(10)); err != nil {
+		t.Errorf("JS响应钩子不应拒绝未超限响应: %v", err)
+	}
+}
+
+// recordLogger 记录日志内容的测试日志记录器
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) record(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordLogger) Debugf(format string, args ...interface{}) { l.record("DEBUG", format, args...) }
+func (l *recordLogger) Infof(format string, args ...interface{})  { l.record("INFO", format, args...) }
+func (l *recordLogger) Errorf(format string, args ...interface{}) { l.record("ERROR", format, args...) }
+
+// contains 判断是否记录了包含substr的日志
+func (l *recordLogger) contains(substr string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestHookLogger 测试钩子通过注入的日志记录器输出日志
+func TestHookLogger(t *testing.T) {
+	t.Run("请求与响应日志钩子", func(t *testing.T) {
+		log := &recordLogger{}
+		req, _ := http.NewRequest("GET", "https://example.com/users", nil)
+
+		var before BeforeRequestHook = &LoggingHook{}
+		before.(LoggerAware).SetLogger(log)
+		if _, err := before.Before(req); err != nil {
+			t.Fatalf("执行钩子失败: %v", err)
+		}
+
+		after := &ResponseLogHook{Logger: log}
+		if _, err := after.After(&http.Response{StatusCode: 201, Request: req}); err != nil {
+			t.Fatalf("执行钩子失败: %v", err)
+		}
+
+		if !log.contains("INFO 正在发送 GET 请求到 https://example.com/users") {
+			t.Errorf("缺少请求日志: %v", log.lines)
+		}
+		if !log.contains("INFO 收到响应: 状态码 201") {
+			t.Errorf("缺少响应日志: %v", log.lines)
+		}
+	})
+
+	t.Run("JS钩子console.log", func(t *testing.T) {
+		log := &recordLogger{}
+		hook, _ := NewJSHookFromString(`
+function processRequest(request) {
+	console.log("处理请求", request.body.name);
+	return request;
+}`, false, 5)
+		hook.SetLogger(log)
+
+		req, _ := http.NewRequest("POST", "https://example.com/api", bytes.NewBufferString(`{"name":"test"}`))
+		if _, err := hook.Before(req); err != nil {
+			t.Fatalf("执行钩子失败: %v", err)
+		}
+
+		if !log.contains("INFO [JS] [处理请求 test]") {
+			t.Errorf("缺少console.log输出: %v", log.lines)
+		}
+		if !log.contains("DEBUG JS处理后的所有请求头") {
+			t.Errorf("缺少调试日志: %v", log.lines)
+		}
+	})
+
+	t.Run("JS响应钩子调试信息", func(t *testing.T) {
+		log := &recordLogger{}
+		hook, _ := NewJSResponseHookFromString(`
+function processResponse(response) {
+	response.status = 202;
+	return response;
+}`, false, 5)
+		hook.SetLogger(log)
+
+		resp := &http.Response{
+			StatusCode: 200,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"ok":true}`)),
+		}
+		if _, err := hook.After(resp); err != nil {
+			t.Fatalf("执行钩子失败: %v", err)
+		}
+
+		if !log.contains("DEBUG 原始状态码: 200") || !log.contains("DEBUG 设置状态码为 202") {
+			t.Errorf("缺少调试日志: %v", log.lines)
+		}
+	})
+}
+
+// TestJSHookTimeout 测试同步模式下脚本超时会被中断
+func TestJSHookTimeout(t *testing.T) {
+	scripts := map[string]string{
+		"顶层死循环":             `while(true){}`,
+		"processRequest死循环": `function processRequest(request) { while(true){} }`,
+	}
+
+	for name, script := range scripts {
+		t.Run(name, func(t *testing.T) {
+			hook, _ := NewJSHookFromString(script, false, 0)
+			hook.Timeout = 200 * time.Millisecond
+
+			done := make(chan error, 1)
+			go func() {
+				req, _ := http.NewRequest("POST", "https://example.com/api", bytes.NewBufferString(`{}`))
+				_, err := hook.Before(req)
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, ErrScriptTimeout) {
+					t.Errorf("应返回ErrScriptTimeout，实际: %v", err)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatal("脚本超时未被中断")
+			}
+		})
+	}
+
+	t.Run("响应钩子", func(t *testing.T) {
+		hook, _ := NewJSResponseHookFromString(`function processResponse(response) { for(;;){} }`, false, 0)
+		hook.Timeout = 200 * time.Millisecond
+
+		resp := &http.Response{
+			StatusCode: 200,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+		}
+		if _, err := hook.After(resp); !errors.Is(err, ErrScriptTimeout) {
+			t.Errorf("应返回ErrScriptTimeout，实际: %v", err)
+		}
+	})
+
+	// Timeout<=0表示不限制，异步模式下同样等待脚本执行完成
+	t.Run("异步模式不限制超时", func(t *testing.T) {
+		reqHook, _ := NewJSHookFromString(`function processRequest(request) { request.body.done = true; return request; }`, true, 0)
+		respHook, _ := NewJSResponseHookFromString(`function processResponse(response) { response.status = 202; return response; }`, true, 0)
+
+		for i := 0; i < 20; i++ {
+			req, _ := http.NewRequest("POST", "https://example.com/api", bytes.NewBufferString(`{}`))
+			modifiedReq, err := reqHook.Before(req)
+			if err != nil {
+				t.Fatalf("异步请求钩子应等待脚本完成，错误: %v", err)
+			}
+			if body, _ := io.ReadAll(modifiedReq.Body); !strings.Contains(string(body), `"done":true`) {
+				t.Fatalf("异步请求钩子的修改未生效: %s", body)
+			}
+
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(`{}`)),
+			}
+			modifiedResp, err := respHook.After(resp)
+			if err != nil || modifiedResp.StatusCode != 202 {
+				t.Fatalf("异步响应钩子应等待脚本完成，错误: %v", err)
+			}
+		}
+	})
+}
+
+// TestJSHookSandbox 测试沙箱模式移除动态执行代码的全局对象
+func TestJSHookSandbox(t *testing.T) {
+	script := `
+function processRequest(request) {
+	request.body.value = eval("1 + 1");
+	return request;
+}`
+
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest("POST", "https://example.com/api", bytes.NewBufferString(`{}`))
+		return req
+	}
+
+	hook, _ := NewJSHookFromString(script, false, 5)
+	if _, err := hook.Before(newRequest()); err != nil {
+		t.Fatalf("未启用沙箱时应允许eval: %v", err)
+	}
+
+	hook.Sandbox = true
+	_, err := hook.Before(newRequest())
+	if err == nil || !strings.Contains(err.Error(), "eval") {
+		t.Errorf("启用沙箱后eval应不可用，实际: %v", err)
+	}
+
+	// 通过函数原型的constructor同样无法构造函数
+	escapes := map[string]string{
+		"Function":        `Function("return 41 + 1")()`,
+		"函数constructor":   `(function(){}).constructor("return 41 + 1")()`,
+		"生成器constructor":  `(function*(){}).constructor("yield 41 + 1")().next().value`,
+		"异步函数constructor": `typeof (async function(){}).constructor("return 41 + 1")`,
+	}
+	for name, expr := range escapes {
+		t.Run(name, func(t *testing.T) {
+			hook, _ := NewJSHookFromString(`
+function processRequest(request) {
+	request.body.value = `+expr+`;
+	return request;
+}`, false, 5)
+			hook.Sandbox = true
+			if _, err := hook.Before(newRequest()); err == nil {
+				t.Errorf("启用沙箱后不应能通过 %s 构造函数", expr)
+			}
+		})
+	}
+}
+
+// TestRequestSchemaHook 测试请求体JSON Schema校验
+func TestRequestSchemaHook(t *testing.T) {
+	hook, err := NewRequestSchemaHook([]byte(`{
+		"type": "object",
+		"required": ["name", "age"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"age": {"type": "integer", "minimum": 0},
+			"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+			"role": {"enum": ["admin", "user"]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("创建钩子失败: %v", err)
+	}
+
+	t.Run("有效请求体", func(t *testing.T) {
+		body := `{"name": "张三", "age": 30, "email": "a@b.com", "role": "admin", "tags": ["x"]}`
+		req, _ := http.NewRequest("POST", "https://example.com/users", strings.NewReader(body))
+
+		modifiedReq, err := hook.Before(req)
+		if err != nil {
+			t.Fatalf("有效请求体不应返回错误: %v", err)
+		}
+
+		// 读取后请求体应被恢复
+		restored, _ := io.ReadAll(modifiedReq.Body)
+		if string(restored) != body {
+			t.Errorf("请求体未恢复，实际: %s", restored)
+		}
+	})
+
+	t.Run("无效请求体", func(t *testing.T) {
+		body := `{"name": "", "age": 1.5, "email": "invalid", "role": "root", "tags": ["x", 2, "z"], "extra": true}`
+		req, _ := http.NewRequest("POST", "https://example.com/users", strings.NewReader(body))
+
+		_, err := hook.Before(req)
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Fatalf("应返回*SchemaError，实际: %v", err)
+		}
+
+		expected := map[string]string{
+			"$.name":    "长度不能小于1",
+			"$.age":     "类型应为integer，实际为number",
+			"$.email":   "不匹配格式",
+			"$.role":    "值不在允许的范围内",
+			"$.tags":    "元素个数不能多于2",
+			"$.tags[1]": "类型应为string",
+			"$.extra":   "不允许的字段",
+		}
+		for path, reason := range expected {
+			found := false
+			for _, v := range schemaErr.Violations {
+				if v.Path == path && strings.Contains(v.Reason, reason) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("缺少字段%s的错误(%s)，实际: %v", path, reason, schemaErr.Violations)
+			}
+		}
+		if len(schemaErr.Violations) != len(expected) {
+			t.Errorf("错误数量不正确，期望: %d, 实际: %v", len(expected), schemaErr.Violations)
+		}
+	})
+
+	t.Run("缺少必填字段", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "https://example.com/users", strings.NewReader(`{"name": "张三"}`))
+		_, err := hook.Before(req)
+		if err == nil || !strings.Contains(err.Error(), "$.age: 缺少必填字段") {
+			t.Errorf("应提示缺少age字段，实际: %v", err)
+		}
+	})
+
+	t.Run("请求体不是JSON", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "https://example.com/users", strings.NewReader(`name=张三`))
+		if _, err := hook.Before(req); err == nil {
+			t.Error("非JSON请求体应返回错误")
+		}
+	})
+
+	t.Run("无效的Schema", func(t *testing.T) {
+		if _, err := NewRequestSchemaHook([]byte(`{"properties": {"a": {"pattern": "("}}}`)); err == nil {
+			t.Error("无效的pattern应返回错误")
+		}
+	})
+}
+
+// TestJSConsoleOutput 测试将console.log输出到指定的Writer
+func TestJSConsoleOutput(t *testing.T) {
+	script := `
+function processRequest(request) {
+	console.log("处理请求", request.body.name, {id: 1}, [1, 2], 3, true, null);
+	return request;
+}`
+
+	t.Run("钩子配置的输出", func(t *testing.T) {
+		log := &recordLogger{}
+		var buf bytes.Buffer
+		hook, _ := NewJSHookFromString(script, false, 5)
+		hook.SetLogger(log)
+		hook.ConsoleOutput = &buf
+
+		req, _ := http.NewRequest("POST", "https://example.com/api", bytes.NewBufferString(`{"name":"test"}`))
+		if _, err := hook.Before(req); err != nil {
+			t.Fatalf("执行钩子失败: %v", err)
+		}
+
+		expected := `处理请求 test {"id":1} [1,2] 3 true null` + "\n"
+		if buf.String() != expected {
+			t.Errorf("console.log输出错误，期望: %q, 实际: %q", expected, buf.String())
+		}
+		if log.contains("[JS]") {
+			t.Errorf("设置输出后不应再写入日志: %v", log.lines)
+		}
+	})
+
+	t.Run("请求上下文中的输出优先", func(t *testing.T) {
+		var hookBuf, requestBuf bytes.Buffer
+		hook, _ := NewJSHookFromString(script, false, 5)
+		hook.ConsoleOutput = &hookBuf
+
+		req, _ := http.NewRequest("POST", "https://example.com/api", bytes.NewBufferString(`{"name":"ctx"}`))
+		req = req.WithContext(WithConsoleOutput(req.Context(), &requestBuf))
+		if _, err := hook.Before(req); err != nil {
+			t.Fatalf("执行钩子失败: %v", err)
+		}
+
+		if !strings.HasPrefix(requestBuf.String(), "处理请求 ctx ") || hookBuf.Len() != 0 {
+			t.Errorf("应写入请求上下文中的输出，请求: %q, 钩子: %q", requestBuf.String(), hookBuf.String())
+		}
+	})
+
+	t.Run("响应钩子", func(t *testing.T) {
+		var buf bytes.Buffer
+		hook, _ := NewJSResponseHookFromString(`
+function processResponse(response) {
+	console.log("状态码", response.status);
+	return response;
+}`, false, 5)
+		hook.ConsoleOutput = &buf
+
+		resp := &http.Response{
+			StatusCode: 200,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"ok":true}`)),
+		}
+		if _, err := hook.After(resp); err != nil {
+			t.Fatalf("执行钩子失败: %v", err)
+		}
+		if buf.String() != "状态码 200\n" {
+			t.Errorf("console.log输出错误: %q", buf.String())
+		}
+	})
+}
+
+// TestRequireHeadersHook 测试必需请求头钩子
+func TestRequireHeadersHook(t *testing.T) {
+	hook := NewRequireHeadersHook("Content-Type", "x-tenant-id")
+
+	// 请求头齐全时原样放行
+	req, _ := http.NewRequest("POST", "https://api.example.com/users", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", "acme")
+	got, err := hook.Before(req)
+	if err != nil {
+		t.Fatalf("请求头齐全时不应返回错误: %v", err)
+	}
+	if got != req {
+		t.Error("检查通过时应返回原请求")
+	}
+
+	// 缺少或为空的请求头都应在错误中列出
+	req, _ = http.NewRequest("POST", "https://api.example.com/users", nil)
+	req.Header.Set("X-Tenant-ID", "  ")
+	_, err = hook.Before(req)
+	if err == nil {
+		t.Fatal("缺少必需请求头时应返回错误")
+	}
+	if !strings.Contains(err.Error(), "Content-Type") || !strings.Contains(err.Error(), "x-tenant-id") {
+		t.Errorf("错误信息应列出所有缺少的请求头，实际: %v", err)
+	}
+
+	// 异步执行
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", "acme")
+	reqChan, errChan := hook.BeforeAsync(req)
+	select {
+	case <-reqChan:
+	case err := <-errChan:
+		t.Fatalf("异步检查失败: %v", err)
+	}
+}
+
+// TestUnflattenBodyHook 测试展开请求体中的点分隔键
+func TestUnflattenBodyHook(t *testing.T) {
+	hook := NewUnflattenBodyHook()
+
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"单个点分隔键", `{"a.b": 1}`, `{"a":{"b":1}}`},
+		{"与已有对象合并", `{"address.city": "上海", "address": {"zip": "200000"}, "id": 12345678901234567890}`, `{"address":{"city":"上海","zip":"200000"},"id":12345678901234567890}`},
+		{"嵌套对象和数组", `{"items": [{"x.y": true}]}`, `{"items":[{"x":{"y":true}}]}`},
+		{"没有点分隔键", `{"name": "test"}`, `{"name": "test"}`},
+		{"空段不展开", `{"a..b": 1}`, `{"a..b": 1}`},
+		{"非JSON请求体", `a.b=1`, `a.b=1`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "https://api.example.com/users", strings.NewReader(tc.body))
+			req, err := hook.Before(req)
+			if err != nil {
+				t.Fatalf("执行钩子失败: %v", err)
+			}
+			body, _ := ReadRequestBody(req)
+			if string(body) != tc.expected {
+				t.Errorf("期望: %s, 实际: %s", tc.expected, body)
+			}
+		})
+	}
+
+	// 与已有的非对象字段冲突
+	req, _ := http.NewRequest("POST", "https://api.example.com/users", strings.NewReader(`{"a": 1, "a.b": 2}`))
+	if _, err := hook.Before(req); err == nil || !strings.Contains(err.Error(), "a.b") {
+		t.Errorf("展开路径冲突时应返回包含键名的错误，实际: %v", err)
+	}
+}
+
+// TestCommandStreamResponseHook 测试流式命令行响应钩子不缓冲整个响应体
+func TestCommandStreamResponseHook(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("跳过测试: 无法找到sh命令")
+	}
+
+	// 第一行写出后，直到收到信号才写出剩余的大量数据
+	const lines = 100000
+	line := strings.Repeat("x", 79) + "\n"
+	release := make(chan struct{})
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("first\n"))
+		<-release
+		for i := 0; i < lines; i++ {
+			if _, err := pw.Write([]byte(line)); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	resp := &http.Response{
+		StatusCode:    http.StatusOK,
+		Body:          pr,
+		Header:        http.Header{"Content-Length": []string{"8000006"}},
+		ContentLength: 8000006,
+	}
+	resp, err := NewCommandStreamResponseHook("cat", 10).After(resp)
+	if err != nil {
+		t.Fatalf("执行流式命令行钩子失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.ContentLength != -1 || resp.Header.Get("Content-Length") != "" {
+		t.Error("流式输出的长度未知，应清除Content-Length")
+	}
+
+	// 响应体尚未写完时就能读到第一行
+	reader := bufio.NewReader(resp.Body)
+	firstLine := make(chan string, 1)
+	go func() {
+		s, _ := reader.ReadString('\n')
+		firstLine <- s
+	}()
+	select {
+	case s := <-firstLine:
+		if s != "first\n" {
+			t.Fatalf("第一行错误: %q", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("未能在响应体写完之前读到输出，钩子没有流式处理")
+	}
+
+	close(release)
+	n, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		t.Fatalf("读取流式输出失败: %v", err)
+	}
+	if n != int64(lines*len(line)) {
+		t.Errorf("输出字节数错误，期望: %d, 实际: %d", lines*len(line), n)
+	}
+
+	// 命令失败时在读到末尾时返回错误
+	resp = &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("data")), Header: make(http.Header)}
+	resp, err = NewCommandStreamResponseHook("cat >/dev/null; echo 出错了 >&2; exit 3", 10).After(resp)
+	if err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	_, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil || !strings.Contains(err.Error(), "出错了") {
+		t.Errorf("命令失败时应返回包含stderr的错误，实际: %v", err)
+	}
+}
+
+// TestNormalizeBodyHook 测试请求体被改写后修正过期的请求头
+func TestNormalizeBodyHook(t *testing.T) {
+	// 前一个钩子把压缩的表单改写为JSON，但保留了原来的请求头和长度
+	rewrite := &CustomFunctionHook{
+		BeforeFn: func(req *http.Request) (*http.Request, error) {
+			req.Body = io.NopCloser(strings.NewReader(`{"user": "张三", "phone": "123"}`))
+			return req, nil
+		},
+	}
+	normalize := NewNormalizeBodyHook()
+
+	req, _ := http.NewRequest("POST", "https://api.example.com/users", strings.NewReader("user=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Content-Length", "6")
+
+	req, err := rewrite.Before(req)
+	if err != nil {
+		t.Fatalf("执行改写钩子失败: %v", err)
+	}
+	req, err = normalize.Before(req)
+	if err != nil {
+		t.Fatalf("执行规范化钩子失败: %v", err)
+	}
+
+	body, _ := ReadRequestBody(req)
+	expectedLength := int64(len(body))
+	if req.ContentLength != expectedLength || req.Header.Get("Content-Length") != fmt.Sprint(expectedLength) {
+		t.Errorf("Content-Length应为%d，实际: %d / %s", expectedLength, req.ContentLength, req.Header.Get("Content-Length"))
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("JSON请求体的Content-Type应为application/json，实际: %s", got)
+	}
+	if got := req.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("请求体未压缩时应删除Content-Encoding，实际: %s", got)
+	}
+	if req.GetBody == nil {
+		t.Fatal("应设置GetBody以便重发请求")
+	}
+	if again, _ := req.GetBody(); again != nil {
+		if replay, _ := io.ReadAll(again); !bytes.Equal(replay, body) {
+			t.Errorf("GetBody返回的内容错误: %s", replay)
+		}
+	}
+
+	// 仍是gzip数据、已有JSON类型的Content-Type时保持不变
+	req, _ = http.NewRequest("POST", "https://api.example.com/users", bytes.NewReader([]byte{0x1f, 0x8b, 0x08}))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.ContentLength = 100
+	req, _ = normalize.Before(req)
+	if req.Header.Get("Content-Encoding") != "gzip" || req.ContentLength != 3 {
+		t.Errorf("压缩的请求体应保留Content-Encoding并修正长度，实际: %s / %d", req.Header.Get("Content-Encoding"), req.ContentLength)
+	}
+
+	req, _ = http.NewRequest("POST", "https://api.example.com/users", strings.NewReader(`{"title": "x"}`))
+	req.Header.Set("Content-Type", "application/problem+json")
+	req, _ = normalize.Before(req)
+	if got := req.Header.Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("JSON类型的Content-Type应保持不变，实际: %s", got)
+	}
+}
+
+// TestXMLToJSONHook 测试将XML响应体转换为JSON
+func TestXMLToJSONHook(t *testing.T) {
+	newResp := func(contentType, body string) *http.Response {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        http.Header{"Content-Type": {contentType}, "Content-Length": {strconv.Itoa(len(body))}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+		}
+	}
+
+	hook := NewXMLToJSONHook()
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<order id="42"><customer>张三</customer><item>书</item><item>笔</item><note lang="zh">加急</note></order>`
+	resp, err := hook.After(newResp("application/xml; charset=utf-8", doc))
+	if err != nil {
+		t.Fatalf("转换XML响应失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	expected := `{"order":{"@id":"42","customer":"张三","item":["书","笔"],"note":{"#text":"加急","@lang":"zh"}}}`
+	if string(body) != expected {
+		t.Errorf("转换结果错误，\n期望: %s\n实际: %s", expected, body)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type应改为application/json，实际: %s", resp.Header.Get("Content-Type"))
+	}
+	if resp.ContentLength != int64(len(body)) || resp.Header.Get("Content-Length") != "" {
+		t.Errorf("内容长度应与转换后的响应体一致，实际: %d", resp.ContentLength)
+	}
+
+	// 结构化后缀的XML类型同样转换
+	resp, err = hook.After(newResp("application/soap+xml", `<Envelope><Body>ok</Body></Envelope>`))
+	if err != nil {
+		t.Fatalf("转换XML响应失败: %v", err)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != `{"Envelope":{"Body":"ok"}}` {
+		t.Errorf("转换结果错误: %s", body)
+	}
+
+	// 解析失败或不是XML时保留原响应
+	for name, tc := range map[string]struct{ contentType, body string }{
+		"XML格式错误": {"text/xml", `<order><id>1</order>`},
+		"JSON响应":  {"application/json", `{"id": 1}`},
+	} {
+		resp, err := hook.After(newResp(tc.contentType, tc.body))
+		if err != nil {
+			t.Fatalf("%s: 不应返回错误: %v", name, err)
+		}
+		if body, _ := io.ReadAll(resp.Body); string(body) != tc.body {
+			t.Errorf("%s: 应保留原响应体，实际: %s", name, body)
+		}
+		if resp.Header.Get("Content-Type") != tc.contentType {
+			t.Errorf("%s: 应保留原Content-Type，实际: %s", name, resp.Header.Get("Content-Type"))
+		}
+	}
+}
+
+// TestSnapshotHook 测试响应快照钩子保存响应体且不影响后续读取
+func TestSnapshotHook(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	hook := NewSnapshotHook(dir)
+	hook.now = func() time.Time {
+		return time.Date(2024, 5, 1, 8, 30, 0, 123, time.UTC)
+	}
+
+	newResp := func(ctx context.Context, contentType, body string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com/users", nil)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {contentType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}
+	}
+
+	cases := []struct {
+		name     string
+		ctx      context.Context
+		body     string
+		file     string
+		expected string
+	}{
+		{"按名称保存并缩进JSON", WithSnapshotName(context.Background(), "get_user"),
+			`{"id":1,"name":"张三","tags":["a"]}`, "get_user.json",
+			"{\n  \"id\": 1,\n  \"name\": \"张三\",\n  \"tags\": [\n    \"a\"\n  ]\n}\n"},
+		{"没有名称时使用时间戳", context.Background(), `[1, 2]`, "20240501-083000.000000123.json", "[\n  1,\n  2\n]\n"},
+		{"非JSON原样保存", WithSnapshotName(context.Background(), "plain"), "not json", "plain.json", "not json"},
+		{"名称中的路径分隔符被替换", WithSnapshotName(context.Background(), "../users/list"), `{}`, ".._users_list.json", "{}\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := hook.After(newResp(tc.ctx, "application/json", tc.body))
+			if err != nil {
+				t.Fatalf("保存快照失败: %v", err)
+			}
+			if body, _ := io.ReadAll(resp.Body); string(body) != tc.body {
+				t.Errorf("响应体应保持不变，实际: %s", body)
+			}
+
+			saved, err := os.ReadFile(filepath.Join(dir, tc.file))
+			if err != nil {
+				t.Fatalf("读取快照文件失败: %v", err)
+			}
+			if string(saved) != tc.expected {
+				t.Errorf("快照内容错误，\n期望: %q\n实际: %q", tc.expected, saved)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("读取快照目录失败: %v", err)
+	}
+	if len(entries) != len(cases) {
+		t.Errorf("快照文件数量错误，期望: %d, 实际: %d", len(cases), len(entries))
+	}
+}
+
+// TestJSResponseHookCompressedBody 测试压缩的响应体解压后交给脚本，并以明文返回
+func TestJSResponseHookCompressedBody(t *testing.T) {
+	hook, err := NewJSResponseHookFromString(`
+function processResponse(response) {
+	response.body.seen = response.body.status;
+	response.body.encoding = response.headers["Content-Encoding"] || "";
+	return response;
+}`, false, 5)
+	if err != nil {
+		t.Fatalf("创建JS响应钩子失败: %v", err)
+	}
+
+	const original = `{"status":"ok","items":[1,2,3]}`
+	compress := map[string]func() []byte{
+		"gzip": func() []byte {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write([]byte(original))
+			gz.Close()
+			return buf.Bytes()
+		},
+		"deflate": func() []byte {
+			var buf bytes.Buffer
+			zw := zlib.NewWriter(&buf)
+			zw.Write([]byte(original))
+			zw.Close()
+			return buf.Bytes()
+		},
+		"identity": func() []byte { return []byte(original) },
+	}
+
+	for encoding, encode := range compress {
+		t.Run(encoding, func(t *testing.T) {
+			compressed := encode()
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header: http.Header{
+					"Content-Type":     {"application/json"},
+					"Content-Encoding": {encoding},
+					"Content-Length":   {strconv.Itoa(len(compressed))},
+				},
+				Body:          io.NopCloser(bytes.NewReader(compressed)),
+				ContentLength: int64(len(compressed)),
+			}
+
+			modifiedResp, err := hook.After(resp)
+			if err != nil {
+				t.Fatalf("执行JS响应钩子失败: %v", err)
+			}
+			body, _ := io.ReadAll(modifiedResp.Body)
+
+			var result map[string]interface{}
+			if err := json.Unmarshal(body, &result); err != nil {
+				t.Fatalf("返回的响应体应为明文JSON: %v, 实际: %q", err, body)
+			}
+			if result["seen"] != "ok" {
+				t.Errorf("脚本应读取到解压后的响应体，实际: %v", result["seen"])
+			}
+			if encoding != "identity" {
+				if result["encoding"] != "" {
+					t.Errorf("脚本不应看到Content-Encoding，实际: %v", result["encoding"])
+				}
+				if modifiedResp.Header.Get("Content-Encoding") != "" {
+					t.Error("返回明文响应体时应删除Content-Encoding")
+				}
+			}
+			if modifiedResp.ContentLength != int64(len(body)) {
+				t.Errorf("内容长度应与明文响应体一致，期望: %d, 实际: %d", len(body), modifiedResp.ContentLength)
+			}
+		})
+	}
+
+	// 压缩数据损坏时返回错误并保留原响应体
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": {"gzip"}},
+		Body:       io.NopCloser(strings.NewReader("not gzip")),
+	}
+	modifiedResp, err := hook.After(resp)
+	if err == nil {
+		t.Fatal("压缩数据损坏时应返回错误")
+	}
+	if modifiedResp != nil {
+		if body, _ := io.ReadAll(modifiedResp.Body); string(body) != "not gzip" {
+			t.Errorf("应保留原响应体，实际: %q", body)
+		}
+	}
+}
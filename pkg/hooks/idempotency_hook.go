@@ -0,0 +1,120 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// DefaultIdempotencyHeader 默认的幂等键请求头
+const DefaultIdempotencyHeader = "Idempotency-Key"
+
+// idempotencyKey 幂等键在上下文中的键
+type idempotencyKey struct{}
+
+// WithIdempotencyKey 返回携带指定幂等键的上下文，使用该上下文发送的请求由IdempotencyHook写入此键而不是生成新键
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKeyFromContext 获取上下文中的幂等键
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// idempotencyHeaderKey IdempotencyHook写入幂等键的请求头名称在上下文中的键
+type idempotencyHeaderKey struct{}
+
+// IdempotencyHeaderFromContext 获取IdempotencyHook写入幂等键时使用的请求头名称
+// 只有钩子实际设置了请求头时才会记录，调用方应再检查请求中确实带有该请求头
+func IdempotencyHeaderFromContext(ctx context.Context) (string, bool) {
+	header, ok := ctx.Value(idempotencyHeaderKey{}).(string)
+	return header, ok && header != ""
+}
+
+// IdempotencyHook 幂等键钩子，为每个逻辑请求设置一个在重试中保持不变的幂等键
+// 请求头已存在时保留原值；否则优先使用WithIdempotencyKey写入上下文的键，都没有时生成UUID。
+// 钩子在重试之前执行一次，重试时复制的请求沿用同一个请求头；幂等键同时写入请求上下文，
+// 同一请求再次经过钩子时不会生成新键。钩子还会在上下文中记录使用的请求头名称，
+// 带有该请求头的POST/PATCH请求会按默认重试策略重试
+type IdempotencyHook struct {
+	Header  string   // 为空时使用Idempotency-Key
+	Methods []string // 需要设置幂等键的请求方法，为空时为POST和PATCH
+}
+
+// NewIdempotencyHook 创建为POST和PATCH请求设置Idempotency-Key的钩子
+func NewIdempotencyHook() *IdempotencyHook {
+	return &IdempotencyHook{Header: DefaultIdempotencyHeader}
+}
+
+// Before 为请求设置幂等键
+func (h *IdempotencyHook) Before(req *http.Request) (*http.Request, error) {
+	if !h.appliesTo(req.Method) {
+		return req, nil
+	}
+
+	header := h.Header
+	if header == "" {
+		header = DefaultIdempotencyHeader
+	}
+
+	key := req.Header.Get(header)
+	if key == "" {
+		if explicit, ok := IdempotencyKeyFromContext(req.Context()); ok {
+			key = explicit
+		} else {
+			var err error
+			key, err = NewUUID()
+			if err != nil {
+				return nil, fmt.Errorf("生成幂等键失败: %w", err)
+			}
+		}
+		req.Header.Set(header, key)
+	}
+
+	ctx := req.Context()
+	current, _ := IdempotencyKeyFromContext(ctx)
+	recorded, _ := IdempotencyHeaderFromContext(ctx)
+	if current == key && recorded == header {
+		return req, nil
+	}
+	if current != key {
+		ctx = WithIdempotencyKey(ctx, key)
+	}
+	if recorded != header {
+		ctx = context.WithValue(ctx, idempotencyHeaderKey{}, header)
+	}
+	return req.WithContext(ctx), nil
+}
+
+// BeforeAsync 异步为请求设置幂等键
+func (h *IdempotencyHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
+	reqChan := make(chan *http.Request, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedReq, err := h.Before(req)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		reqChan <- modifiedReq
+	}()
+
+	return reqChan, errChan
+}
+
+// appliesTo 判断请求方法是否需要设置幂等键
+func (h *IdempotencyHook) appliesTo(method string) bool {
+	methods := h.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
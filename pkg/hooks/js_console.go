@@ -0,0 +1,78 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/birdmichael/RenderAPI/pkg/logger"
+	"github.com/dop251/goja"
+)
+
+// consoleOutputKey 请求上下文中console输出目标的键
+type consoleOutputKey struct{}
+
+// consoleMutex 保证多个脚本同时写入同一个输出目标时每行完整
+var consoleMutex sync.Mutex
+
+// WithConsoleOutput 返回携带console输出目标的上下文
+// 使用该上下文发送的请求，JavaScript钩子中console.log的内容会按行写入w，
+// 优先级高于钩子的ConsoleOutput，可用于按请求收集脚本日志
+func WithConsoleOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, consoleOutputKey{}, w)
+}
+
+// consoleOutput 返回console.log的输出目标：请求上下文中的优先，其次为钩子配置的，都没有时返回nil
+func consoleOutput(ctx context.Context, configured io.Writer) io.Writer {
+	if ctx != nil {
+		if w, ok := ctx.Value(consoleOutputKey{}).(io.Writer); ok && w != nil {
+			return w
+		}
+	}
+	return configured
+}
+
+// installConsole 为脚本运行时设置console.log
+// out不为nil时每次调用按行写入out，参数以空格分隔，对象和数组输出为JSON；否则输出到日志记录器
+func installConsole(vm *goja.Runtime, out io.Writer, log logger.Logger) {
+	console := make(map[string]interface{})
+	console["log"] = func(call goja.FunctionCall) goja.Value {
+		args := make([]interface{}, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = arg.Export()
+		}
+
+		if out == nil {
+			logger.OrDefault(log).Infof("[JS] %v", args)
+			return goja.Undefined()
+		}
+
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = formatConsoleArg(arg)
+		}
+		consoleMutex.Lock()
+		io.WriteString(out, strings.Join(parts, " ")+"\n")
+		consoleMutex.Unlock()
+		return goja.Undefined()
+	}
+	vm.Set("console", console)
+}
+
+// formatConsoleArg 按浏览器控制台的习惯格式化参数：字符串原样输出，对象和数组输出为JSON
+func formatConsoleArg(arg interface{}) string {
+	switch val := arg.(type) {
+	case nil:
+		return "null"
+	case string:
+		return val
+	case map[string]interface{}, []interface{}:
+		if encoded, err := json.Marshal(val); err == nil {
+			return string(encoded)
+		}
+	}
+	return fmt.Sprint(arg)
+}
@@ -2,12 +2,14 @@ package hooks
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,16 +18,26 @@ import (
 
 	"crypto"
 
+	"github.com/birdmichael/RenderAPI/pkg/logger"
 	"github.com/dop251/goja"
 )
 
 // JSHook 实现BeforeRequestHook和AsyncBeforeRequestHook接口，用于执行JavaScript预请求脚本
 // 可以用于灵活地处理请求体、添加请求头等操作
 type JSHook struct {
+	Name          string        // 钩子名称，用于错误信息，模板定义中的name会写入此字段
 	ScriptPath    string        // JavaScript脚本文件路径
 	ScriptContent string        // JavaScript脚本内容（优先级高于ScriptPath）
 	IsAsync       bool          // 是否异步执行
-	Timeout       time.Duration // 脚本执行超时时间
+	Timeout       time.Duration // 脚本执行超时时间，同步和异步模式均生效，<=0表示不限制
+	Sandbox       bool          // 是否禁止eval、Function等动态执行代码的途径，不是完整的安全边界
+	Logger        logger.Logger // 日志记录器，为nil时使用logger.Default()
+	ConsoleOutput io.Writer     // console.log的输出目标，为nil时输出到Logger；请求上下文中WithConsoleOutput指定的优先
+}
+
+// SetLogger 设置日志记录器，console.log和调试信息都会输出到该记录器
+func (h *JSHook) SetLogger(l logger.Logger) {
+	h.Logger = l
 }
 
 // NewJSHook 创建一个新的JavaScript钩子
@@ -80,8 +92,8 @@ func (h *JSHook) Before(req *http.Request) (*http.Request, error) {
 			return modifiedReq, nil
 		case err := <-errChan:
 			return req, err
-		case <-time.After(h.Timeout):
-			return req, fmt.Errorf("脚本执行超时")
+		case <-timeoutChan(h.Timeout):
+			return req, ErrScriptTimeout
 		}
 	}
 
@@ -89,6 +101,11 @@ func (h *JSHook) Before(req *http.Request) (*http.Request, error) {
 	return h.executeScript(req)
 }
 
+// GetConfig 返回钩子配置，客户端按其中的名称、异步标志和超时时间执行钩子
+func (h *JSHook) GetConfig() *HookConfig {
+	return newHookConfig("js", h.Name, h.IsAsync, h.Timeout)
+}
+
 // BeforeAsync 异步执行JavaScript脚本
 // 返回两个通道，一个用于获取处理后的请求，一个用于获取可能发生的错误
 // 实现AsyncBeforeRequestHook接口
@@ -121,17 +138,19 @@ func (h *JSHook) executeScript(req *http.Request) (*http.Request, error) {
 		return req, err
 	}
 
-	// 创建JavaScript运行时
+	// 创建JavaScript运行时，超时后中断脚本执行
 	vm := goja.New()
+	stop := watchScript(vm, h.Timeout)
+	defer stop()
 
 	// 设置JavaScript环境
-	if err := h.setupJSEnvironment(vm); err != nil {
+	if err := h.setupJSEnvironment(vm, consoleOutput(req.Context(), h.ConsoleOutput)); err != nil {
 		return req, err
 	}
 
 	// 执行脚本
 	if _, err := vm.RunString(string(scriptContent)); err != nil {
-		return req, fmt.Errorf("执行脚本失败: %w", err)
+		return req, scriptError("执行脚本失败", err)
 	}
 
 	// 如果没有请求体，直接返回
@@ -163,18 +182,13 @@ func (h *JSHook) getScriptContent() ([]byte, error) {
 }
 
 // setupJSEnvironment 设置JavaScript运行环境，添加控制台日志和RSA加密等功能
-func (h *JSHook) setupJSEnvironment(vm *goja.Runtime) error {
+func (h *JSHook) setupJSEnvironment(vm *goja.Runtime, out io.Writer) error {
 	// 添加console.log实现
-	console := make(map[string]interface{})
-	console["log"] = func(call goja.FunctionCall) goja.Value {
-		args := make([]interface{}, len(call.Arguments))
-		for i, arg := range call.Arguments {
-			args[i] = arg.Export()
-		}
-		fmt.Printf("[JS] %v\n", args)
-		return goja.Undefined()
+	installConsole(vm, out, h.Logger)
+
+	if h.Sandbox {
+		removeUnsafeGlobals(vm)
 	}
-	vm.Set("console", console)
 
 	// 添加RSA加密函数
 	vm.Set("rsaEncryptGo", func(call goja.FunctionCall) goja.Value {
@@ -196,6 +210,62 @@ func (h *JSHook) setupJSEnvironment(vm *goja.Runtime) error {
 	return nil
 }
 
+// watchScript 超过timeout后中断vm中正在执行的脚本，返回停止监视的函数
+// goja的中断对死循环同样生效，因此同步模式下也不会永久阻塞调用方
+func watchScript(vm *goja.Runtime, timeout time.Duration) func() {
+	if timeout <= 0 {
+		return func() {}
+	}
+	timer := time.AfterFunc(timeout, func() {
+		vm.Interrupt(ErrScriptTimeout)
+	})
+	return func() { timer.Stop() }
+}
+
+// timeoutChan 返回timeout后触发的通道，timeout<=0时返回nil通道，select时永远不会触发
+func timeoutChan(timeout time.Duration) <-chan time.Time {
+	if timeout <= 0 {
+		return nil
+	}
+	return time.After(timeout)
+}
+
+// scriptError 包装脚本执行错误，脚本因超时被中断时返回ErrScriptTimeout
+func scriptError(msg string, err error) error {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		return fmt.Errorf("%s: %w", msg, ErrScriptTimeout)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// functionPrototypes 各类函数的原型，其constructor属性可以从源码字符串构造函数
+var functionPrototypes = []string{
+	"Function.prototype",
+	"Object.getPrototypeOf(function*(){})",
+	"Object.getPrototypeOf(async function(){})",
+	"Object.getPrototypeOf(async function*(){})",
+}
+
+// removeUnsafeGlobals 移除可以动态执行代码的全局对象，并屏蔽函数原型上的constructor，
+// 使(function(){}).constructor等途径同样无法构造函数。
+// 这只能阻止动态执行代码，脚本仍可以占用CPU和内存，不能作为执行恶意脚本的安全边界
+func removeUnsafeGlobals(vm *goja.Runtime) {
+	for _, src := range functionPrototypes {
+		// 运行时不支持的语法（如异步生成器）不存在对应的构造函数，直接跳过
+		proto, err := vm.RunString(src)
+		if err != nil {
+			continue
+		}
+		proto.ToObject(vm).DefineDataProperty("constructor", goja.Undefined(), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+	}
+
+	global := vm.GlobalObject()
+	for _, name := range []string{"eval", "Function"} {
+		global.Delete(name)
+	}
+}
+
 // processRequestWithJS 使用JS处理请求
 // 将HTTP请求转换为JavaScript对象，调用JS函数处理，再转回HTTP请求
 func (h *JSHook) processRequestWithJS(vm *goja.Runtime, req *http.Request) (*http.Request, error) {
@@ -236,7 +306,7 @@ func (h *JSHook) processRequestWithJS(vm *goja.Runtime, req *http.Request) (*htt
 	// 执行处理函数
 	result, err := processRequestFn(goja.Undefined(), vm.ToValue(jsRequest))
 	if err != nil {
-		return req, fmt.Errorf("执行processRequest函数失败: %w", err)
+		return req, scriptError("执行processRequest函数失败", err)
 	}
 
 	// 处理JavaScript返回的结果
@@ -270,21 +340,18 @@ func (h *JSHook) handleProcessedRequest(req *http.Request, result goja.Value) (*
 	}
 
 	// 处理请求头
-	fmt.Println("处理JS返回的请求头:")
+	log := logger.OrDefault(h.Logger)
 	if headers, ok := processedRequest["headers"].(map[string]interface{}); ok {
 		for k, v := range headers {
 			if strVal, ok := v.(string); ok {
 				req.Header.Set(k, strVal)
-				fmt.Printf("[JS-DEBUG] 设置请求头 %s: %s\n", k, strVal)
+				log.Debugf("JS设置请求头 %s: %s", k, strVal)
 			}
 		}
 	}
 
 	// 打印最终的请求头
-	fmt.Println("JS处理后的所有请求头:")
-	for k, v := range req.Header {
-		fmt.Printf("%s: %v\n", k, v)
-	}
+	log.Debugf("JS处理后的所有请求头: %v", req.Header)
 
 	// 将处理后的请求体重新序列化为JSON
 	newBodyBytes, err := json.Marshal(processedBody)
@@ -297,11 +364,23 @@ func (h *JSHook) handleProcessedRequest(req *http.Request, result goja.Value) (*
 }
 
 // JSResponseHook JavaScript响应钩子，用于在接收到响应后执行JavaScript处理
+// gzip或deflate压缩的响应体（Content-Encoding）先解压再交给脚本，处理后的响应体以明文返回
 type JSResponseHook struct {
+	Name          string        // 钩子名称，用于错误信息
 	ScriptPath    string        // JavaScript脚本文件路径
 	ScriptContent string        // JavaScript脚本内容
 	IsAsync       bool          // 是否异步执行
-	Timeout       time.Duration // 脚本执行超时时间
+	Timeout       time.Duration // 脚本执行超时时间，同步和异步模式均生效，<=0表示不限制
+	Sandbox       bool          // 是否禁止eval、Function等动态执行代码的途径，不是完整的安全边界
+
+	MaxResponseBytes int64         // 读取响应体的最大字节数，<=0表示不限制
+	Logger           logger.Logger // 日志记录器，为nil时使用logger.Default()
+	ConsoleOutput    io.Writer     // console.log的输出目标，为nil时输出到Logger；请求上下文中WithConsoleOutput指定的优先
+}
+
+// SetLogger 设置日志记录器，console.log和调试信息都会输出到该记录器
+func (h *JSResponseHook) SetLogger(l logger.Logger) {
+	h.Logger = l
 }
 
 // NewJSResponseHook 创建一个新的JavaScript响应钩子
@@ -349,8 +428,8 @@ func (h *JSResponseHook) After(resp *http.Response) (*http.Response, error) {
 			return modifiedResp, nil
 		case err := <-errChan:
 			return resp, err
-		case <-time.After(h.Timeout):
-			return resp, fmt.Errorf("脚本执行超时")
+		case <-timeoutChan(h.Timeout):
+			return resp, ErrScriptTimeout
 		}
 	}
 
@@ -358,6 +437,11 @@ func (h *JSResponseHook) After(resp *http.Response) (*http.Response, error) {
 	return h.executeScript(resp)
 }
 
+// GetConfig 返回钩子配置，客户端按其中的名称、异步标志和超时时间执行钩子
+func (h *JSResponseHook) GetConfig() *HookConfig {
+	return newHookConfig("js", h.Name, h.IsAsync, h.Timeout)
+}
+
 // AfterAsync 异步执行JavaScript脚本
 // 实现AsyncAfterResponseHook接口
 func (h *JSResponseHook) AfterAsync(resp *http.Response) (chan *http.Response, chan error) {
@@ -389,17 +473,23 @@ func (h *JSResponseHook) executeScript(resp *http.Response) (*http.Response, err
 		return resp, err
 	}
 
-	// 创建JavaScript运行时
+	// 创建JavaScript运行时，超时后中断脚本执行
 	vm := goja.New()
+	stop := watchScript(vm, h.Timeout)
+	defer stop()
 
 	// 设置JavaScript环境
-	if err := h.setupJSEnvironment(vm); err != nil {
+	var ctx context.Context
+	if resp.Request != nil {
+		ctx = resp.Request.Context()
+	}
+	if err := h.setupJSEnvironment(vm, consoleOutput(ctx, h.ConsoleOutput)); err != nil {
 		return resp, err
 	}
 
 	// 执行脚本
 	if _, err := vm.RunString(string(scriptContent)); err != nil {
-		return resp, fmt.Errorf("执行脚本失败: %w", err)
+		return resp, scriptError("执行脚本失败", err)
 	}
 
 	// 如果没有响应体，直接返回
@@ -431,18 +521,13 @@ func (h *JSResponseHook) getScriptContent() ([]byte, error) {
 
 // setupJSEnvironment 设置JavaScript运行环境
 // 添加控制台日志等功能
-func (h *JSResponseHook) setupJSEnvironment(vm *goja.Runtime) error {
+func (h *JSResponseHook) setupJSEnvironment(vm *goja.Runtime, out io.Writer) error {
 	// 添加console.log实现
-	console := make(map[string]interface{})
-	console["log"] = func(call goja.FunctionCall) goja.Value {
-		args := make([]interface{}, len(call.Arguments))
-		for i, arg := range call.Arguments {
-			args[i] = arg.Export()
-		}
-		fmt.Printf("[JS] %v\n", args)
-		return goja.Undefined()
+	installConsole(vm, out, h.Logger)
+
+	if h.Sandbox {
+		removeUnsafeGlobals(vm)
 	}
-	vm.Set("console", console)
 
 	return nil
 }
@@ -451,12 +536,19 @@ func (h *JSResponseHook) setupJSEnvironment(vm *goja.Runtime) error {
 // 将HTTP响应转换为JavaScript对象，调用JS函数处理，再转回HTTP响应
 func (h *JSResponseHook) processResponseWithJS(vm *goja.Runtime, resp *http.Response) (*http.Response, error) {
 	// 读取响应体
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, err := ReadLimited(resp.Body, h.MaxResponseBytes)
 	if err != nil {
 		return resp, fmt.Errorf("读取响应体失败: %w", err)
 	}
 	resp.Body.Close()
 
+	// 压缩的响应体解压后交给脚本，之后以明文返回，不再重新压缩
+	compressed := bodyBytes
+	if bodyBytes, err = decodeResponseBody(resp, bodyBytes, h.MaxResponseBytes); err != nil {
+		resp.Body = io.NopCloser(bytes.NewReader(compressed))
+		return resp, err
+	}
+
 	// 解析响应体 (尝试解析为JSON，如果失败则保留原始内容)
 	var responseBody interface{}
 	if err := json.Unmarshal(bodyBytes, &responseBody); err != nil {
@@ -473,7 +565,8 @@ func (h *JSResponseHook) processResponseWithJS(vm *goja.Runtime, resp *http.Resp
 
 	// 记录原始状态码，用于调试
 	originalStatusCode := resp.StatusCode
-	fmt.Printf("[DEBUG] 原始状态码: %d\n", originalStatusCode)
+	log := logger.OrDefault(h.Logger)
+	log.Debugf("原始状态码: %d", originalStatusCode)
 
 	// 调用JavaScript处理函数
 	processResponseFn, ok := goja.AssertFunction(vm.Get("processResponse"))
@@ -488,11 +581,11 @@ func (h *JSResponseHook) processResponseWithJS(vm *goja.Runtime, resp *http.Resp
 	if err != nil {
 		// 恢复原始响应体
 		resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-		return resp, fmt.Errorf("执行processResponse函数失败: %w", err)
+		return resp, scriptError("执行processResponse函数失败", err)
 	}
 
 	// 输出处理后的响应对象，用于调试
-	fmt.Printf("[DEBUG] 处理后的响应对象: %+v\n", result.Export())
+	log.Debugf("处理后的响应对象: %+v", result.Export())
 
 	// 处理JavaScript返回的结果
 	return h.handleProcessedResponse(resp, result, bodyBytes)
@@ -508,17 +601,18 @@ func (h *JSResponseHook) handleProcessedResponse(resp *http.Response, result goj
 		resp.Body = io.NopCloser(bytes.NewBuffer(originalBody))
 		return resp, fmt.Errorf("无法解析处理后的响应对象")
 	}
+	log := logger.OrDefault(h.Logger)
 
 	// 处理状态码 - 支持多种数值类型
 	if status, ok := processedResponse["status"].(float64); ok {
 		resp.StatusCode = int(status)
-		fmt.Printf("[DEBUG] 设置状态码为 %d (从float64)\n", int(status))
+		log.Debugf("设置状态码为 %d (从float64)", int(status))
 	} else if status, ok := processedResponse["status"].(int64); ok {
 		resp.StatusCode = int(status)
-		fmt.Printf("[DEBUG] 设置状态码为 %d (从int64)\n", int(status))
+		log.Debugf("设置状态码为 %d (从int64)", int(status))
 	} else if status, ok := processedResponse["status"].(int); ok {
 		resp.StatusCode = status
-		fmt.Printf("[DEBUG] 设置状态码为 %d (从int)\n", status)
+		log.Debugf("设置状态码为 %d (从int)", status)
 	}
 
 	// 处理头部 - 支持两种常见的头部格式
@@ -526,13 +620,13 @@ func (h *JSResponseHook) handleProcessedResponse(resp *http.Response, result goj
 		for k, v := range headers {
 			if strVal, ok := v.(string); ok {
 				resp.Header.Set(k, strVal)
-				fmt.Printf("[DEBUG] 设置头部 %s: %s\n", k, strVal)
+				log.Debugf("设置头部 %s: %s", k, strVal)
 			}
 		}
 	} else if headers, ok := processedResponse["headers"].(map[string]string); ok {
 		for k, v := range headers {
 			resp.Header.Set(k, v)
-			fmt.Printf("[DEBUG] 设置头部 %s: %s\n", k, v)
+			log.Debugf("设置头部 %s: %s", k, v)
 		}
 	}
 
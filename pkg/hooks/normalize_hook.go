@@ -0,0 +1,101 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NormalizeBodyHook 请求体规范化钩子，修正前面的钩子改写请求体后残留的过期请求头
+// 应在其他修改请求体的钩子（FieldTransformHook、JSHook、CommandHook等）之后注册：
+//   - 按实际请求体重新计算ContentLength，请求头中存在Content-Length时同步更新
+//   - JSON对象或数组请求体的Content-Type不是JSON类型时改为application/json，
+//     其他JSON值只在缺少Content-Type时设置
+//   - 请求体明显未被压缩（gzip缺少魔数，其他编码下为合法JSON）时删除过期的Content-Encoding
+type NormalizeBodyHook struct{}
+
+// NewNormalizeBodyHook 创建请求体规范化钩子
+func NewNormalizeBodyHook() *NormalizeBodyHook {
+	return &NormalizeBodyHook{}
+}
+
+// Before 规范化请求体相关的请求头
+func (h *NormalizeBodyHook) Before(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		if req.Header.Get("Content-Length") != "" {
+			req.Header.Set("Content-Length", "0")
+		}
+		return req, nil
+	}
+
+	body, err := ReadRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 原始字节请求体即使恰好是合法JSON也按调用方指定的Content-Type发送
+	isJSON := !IsRawBody(req) && len(bytes.TrimSpace(body)) > 0 && json.Valid(body)
+	if encoding := req.Header.Get("Content-Encoding"); encoding != "" && staleContentEncoding(encoding, body, isJSON) {
+		req.Header.Del("Content-Encoding")
+	}
+
+	if isJSON {
+		contentType := req.Header.Get("Content-Type")
+		first := bytes.TrimSpace(body)[0]
+		if contentType == "" || (first == '{' || first == '[') && !isJSONMediaType(contentType) {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+
+	req.ContentLength = int64(len(body))
+	if req.Header.Get("Content-Length") != "" {
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return req, nil
+}
+
+// BeforeAsync 异步规范化请求体相关的请求头
+func (h *NormalizeBodyHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
+	reqChan := make(chan *http.Request, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedReq, err := h.Before(req)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		reqChan <- modifiedReq
+	}()
+
+	return reqChan, errChan
+}
+
+// staleContentEncoding 判断Content-Encoding是否与请求体不符（请求体已被解压或改写为明文）
+func staleContentEncoding(encoding string, body []byte, isJSON bool) bool {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "identity":
+		return false
+	case "gzip", "x-gzip":
+		return len(body) < 2 || body[0] != 0x1f || body[1] != 0x8b
+	default:
+		// 压缩后的数据不可能是合法的JSON
+		return isJSON
+	}
+}
+
+// isJSONMediaType 判断Content-Type是否为JSON类型，包括application/problem+json等结构化后缀
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,55 @@
+package hooks
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequireHeadersHook 必需请求头钩子，在发送前检查请求头是否齐全，用于及早发现配置错误
+// 任一请求头不存在或值为空时返回错误，错误信息列出所有缺少的请求头；
+// 应在其他设置请求头的钩子之后注册
+type RequireHeadersHook struct {
+	Names []string // 必需的请求头名称，不区分大小写
+}
+
+// NewRequireHeadersHook 创建必需请求头钩子
+func NewRequireHeadersHook(names ...string) *RequireHeadersHook {
+	return &RequireHeadersHook{Names: names}
+}
+
+// Before 检查必需的请求头
+func (h *RequireHeadersHook) Before(req *http.Request) (*http.Request, error) {
+	var missing []string
+	for _, name := range h.Names {
+		value := req.Header.Get(name)
+		// Host不在Header中，由req.Host决定
+		if http.CanonicalHeaderKey(name) == "Host" {
+			value = req.Host
+		}
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("缺少必需的请求头: %s", strings.Join(missing, ", "))
+	}
+	return req, nil
+}
+
+// BeforeAsync 异步检查必需的请求头
+func (h *RequireHeadersHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
+	reqChan := make(chan *http.Request, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedReq, err := h.Before(req)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		reqChan <- modifiedReq
+	}()
+
+	return reqChan, errChan
+}
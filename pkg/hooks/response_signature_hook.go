@@ -0,0 +1,82 @@
+package hooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ResponseSignatureStripHook 响应签名校验钩子
+// 对响应体计算HMAC-SHA256并与签名响应头（十六进制，可带"sha256="前缀）用常量时间比较，
+// 一致时删除签名响应头后返回响应，响应体保持不变；缺少签名或签名不一致时返回ErrInvalidResponseSignature。
+// 签名头的名称默认与AntiReplayHook相同，为X-Signature
+type ResponseSignatureStripHook struct {
+	Secret           string
+	Header           string // 为空时使用X-Signature
+	MaxResponseBytes int64  // 读取响应体的最大字节数，<=0表示不限制
+}
+
+// NewResponseSignatureStripHook 使用默认签名响应头创建响应签名校验钩子
+func NewResponseSignatureStripHook(secret string) *ResponseSignatureStripHook {
+	return &ResponseSignatureStripHook{
+		Secret: secret,
+		Header: DefaultSignatureHeader,
+	}
+}
+
+// After 校验响应签名并删除签名响应头
+func (h *ResponseSignatureStripHook) After(resp *http.Response) (*http.Response, error) {
+	header := h.Header
+	if header == "" {
+		header = DefaultSignatureHeader
+	}
+
+	signature := strings.TrimPrefix(strings.TrimSpace(resp.Header.Get(header)), "sha256=")
+	if signature == "" {
+		return nil, fmt.Errorf("%w: 缺少签名响应头%s", ErrInvalidResponseSignature, header)
+	}
+
+	var body []byte
+	if resp.Body != nil {
+		var err error
+		body, err = ReadLimited(resp.Body, h.MaxResponseBytes)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取响应体失败: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	actual, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(actual, expected) {
+		return nil, fmt.Errorf("%w: 签名与响应体不匹配", ErrInvalidResponseSignature)
+	}
+
+	resp.Header.Del(header)
+	return resp, nil
+}
+
+// AfterAsync 异步校验响应签名
+func (h *ResponseSignatureStripHook) AfterAsync(resp *http.Response) (chan *http.Response, chan error) {
+	respChan := make(chan *http.Response, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedResp, err := h.After(resp)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		respChan <- modifiedResp
+	}()
+
+	return respChan, errChan
+}
@@ -0,0 +1,290 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaViolation 单个不符合JSON Schema的字段
+type SchemaViolation struct {
+	Path   string // 字段路径，如 $.user.emails[1]
+	Reason string // 不符合的原因
+}
+
+// SchemaError 请求体不符合JSON Schema时返回的错误，包含全部不符合的字段
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+// Error 实现error接口
+func (e *SchemaError) Error() string {
+	items := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		items = append(items, v.Path+": "+v.Reason)
+	}
+	return "请求体不符合JSON Schema: " + strings.Join(items, "; ")
+}
+
+// RequestSchemaHook 请求体JSON Schema校验钩子，在请求发出前拦截格式错误的请求体
+// 支持JSON Schema的常用关键字：type、properties、required、additionalProperties、items、
+// enum、const、minimum、maximum、exclusiveMinimum、exclusiveMaximum、minLength、maxLength、
+// pattern、minItems、maxItems，其他关键字会被忽略。请求体为空时不做校验
+type RequestSchemaHook struct {
+	schema   map[string]interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// NewRequestSchemaHook 使用JSON格式的Schema创建校验钩子
+func NewRequestSchemaHook(schema []byte) (*RequestSchemaHook, error) {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		return nil, fmt.Errorf("解析JSON Schema失败: %w", err)
+	}
+
+	h := &RequestSchemaHook{
+		schema:   parsed,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if err := h.compilePatterns(parsed); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// compilePatterns 预编译Schema中所有的pattern
+func (h *RequestSchemaHook) compilePatterns(v interface{}) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if pattern, ok := val["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("JSON Schema中的pattern无效(%s): %w", pattern, err)
+			}
+			h.patterns[pattern] = re
+		}
+		for _, child := range val {
+			if err := h.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range val {
+			if err := h.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Before 校验请求体，不符合时返回*SchemaError，请求体读取后会被恢复；原始字节请求体不校验
+func (h *RequestSchemaHook) Before(req *http.Request) (*http.Request, error) {
+	if IsRawBody(req) {
+		return req, nil
+	}
+	body, err := ReadRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return req, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, &SchemaError{Violations: []SchemaViolation{{Path: "$", Reason: "请求体不是有效的JSON: " + err.Error()}}}
+	}
+
+	if violations := h.Validate(data); len(violations) > 0 {
+		return nil, &SchemaError{Violations: violations}
+	}
+	return req, nil
+}
+
+// BeforeAsync 异步校验请求体
+func (h *RequestSchemaHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
+	reqChan := make(chan *http.Request, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedReq, err := h.Before(req)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		reqChan <- modifiedReq
+	}()
+
+	return reqChan, errChan
+}
+
+// Validate 校验已解码的JSON数据，返回全部不符合的字段
+func (h *RequestSchemaHook) Validate(data interface{}) []SchemaViolation {
+	var violations []SchemaViolation
+	h.validate(h.schema, data, "$", &violations)
+	return violations
+}
+
+// validate 按Schema递归校验数据
+func (h *RequestSchemaHook) validate(schema map[string]interface{}, data interface{}, path string, violations *[]SchemaViolation) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, SchemaViolation{Path: path, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, data) {
+		fail("类型应为%v，实际为%s", t, jsonType(data))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, data) {
+		fail("值不在允许的范围内: %v", enum)
+	}
+	if constant, ok := schema["const"]; ok && !jsonEqual(constant, data) {
+		fail("值应为%v", constant)
+	}
+
+	switch val := data.(type) {
+	case map[string]interface{}:
+		h.validateObject(schema, val, path, violations)
+	case []interface{}:
+		if n, ok := schema["minItems"].(float64); ok && float64(len(val)) < n {
+			fail("元素个数不能少于%v", n)
+		}
+		if n, ok := schema["maxItems"].(float64); ok && float64(len(val)) > n {
+			fail("元素个数不能多于%v", n)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				h.validate(items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(val))
+		if n, ok := schema["minLength"].(float64); ok && length < n {
+			fail("长度不能小于%v", n)
+		}
+		if n, ok := schema["maxLength"].(float64); ok && length > n {
+			fail("长度不能大于%v", n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !h.patterns[pattern].MatchString(val) {
+			fail("不匹配格式%s", pattern)
+		}
+	case float64:
+		if n, ok := schema["minimum"].(float64); ok && val < n {
+			fail("不能小于%v", n)
+		}
+		if n, ok := schema["maximum"].(float64); ok && val > n {
+			fail("不能大于%v", n)
+		}
+		if n, ok := schema["exclusiveMinimum"].(float64); ok && val <= n {
+			fail("必须大于%v", n)
+		}
+		if n, ok := schema["exclusiveMaximum"].(float64); ok && val >= n {
+			fail("必须小于%v", n)
+		}
+	}
+}
+
+// validateObject 校验对象的必填字段、属性和额外属性
+func (h *RequestSchemaHook) validateObject(schema, obj map[string]interface{}, path string, violations *[]SchemaViolation) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, exists := obj[key]; !exists {
+				*violations = append(*violations, SchemaViolation{Path: path + "." + key, Reason: "缺少必填字段"})
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "." + key
+		if propSchema, ok := properties[key].(map[string]interface{}); ok {
+			h.validate(propSchema, obj[key], childPath, violations)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*violations = append(*violations, SchemaViolation{Path: childPath, Reason: "不允许的字段"})
+			}
+		case map[string]interface{}:
+			h.validate(additional, obj[key], childPath, violations)
+		}
+	}
+}
+
+// matchesType 检查数据是否符合type关键字，type可以是字符串或字符串数组
+func matchesType(t interface{}, data interface{}) bool {
+	switch val := t.(type) {
+	case string:
+		actual := jsonType(data)
+		if val == "number" && actual == "integer" {
+			return true
+		}
+		return val == actual
+	case []interface{}:
+		for _, item := range val {
+			if matchesType(item, data) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// jsonType 返回数据对应的JSON Schema类型名
+func jsonType(data interface{}) string {
+	switch val := data.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", data)
+	}
+}
+
+// containsValue 检查values中是否存在与data相等的值
+func containsValue(values []interface{}, data interface{}) bool {
+	for _, v := range values {
+		if jsonEqual(v, data) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual 按JSON语义比较两个值
+func jsonEqual(a, b interface{}) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(aj, bj)
+}
@@ -0,0 +1,107 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// snapshotNameKey 快照名称在上下文中的键
+type snapshotNameKey struct{}
+
+// WithSnapshotName 返回携带快照名称的上下文，使用该上下文发送的请求由SnapshotHook保存为<name>.json
+// client.ExecuteTemplateFile等从文件加载模板的方法会以模板文件名（不含扩展名）自动设置
+func WithSnapshotName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, snapshotNameKey{}, name)
+}
+
+// SnapshotNameFromContext 获取上下文中的快照名称
+func SnapshotNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(snapshotNameKey{}).(string)
+	return name, ok && name != ""
+}
+
+// unsafeSnapshotChars 快照文件名中需要替换的字符
+var unsafeSnapshotChars = regexp.MustCompile(`[^\p{L}\p{N}._-]+`)
+
+// SnapshotHook 响应快照钩子，将响应体保存到Dir目录中，用作回归测试的固定数据
+// 文件名为请求上下文中的快照名称（见WithSnapshotName），没有时使用响应时间戳；同名文件会被覆盖。
+// JSON响应体缩进后保存，其他内容原样保存；保存后响应体仍可完整读取
+type SnapshotHook struct {
+	Dir string
+
+	now func() time.Time // 生成时间戳文件名的时钟，nil表示time.Now
+}
+
+// NewSnapshotHook 创建将响应保存到dir的快照钩子
+func NewSnapshotHook(dir string) *SnapshotHook {
+	return &SnapshotHook{Dir: dir}
+}
+
+// After 保存响应快照
+func (h *SnapshotHook) After(resp *http.Response) (*http.Response, error) {
+	var body []byte
+	if resp.Body != nil {
+		var err error
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取响应体失败: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	content := body
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, body, "", "  ") == nil {
+		pretty.WriteByte('\n')
+		content = pretty.Bytes()
+	}
+
+	if err := os.MkdirAll(h.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建快照目录失败: %w", err)
+	}
+	path := filepath.Join(h.Dir, h.fileName(resp)+".json")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return nil, fmt.Errorf("保存响应快照失败: %w", err)
+	}
+	return resp, nil
+}
+
+// AfterAsync 异步保存响应快照
+func (h *SnapshotHook) AfterAsync(resp *http.Response) (chan *http.Response, chan error) {
+	respChan := make(chan *http.Response, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedResp, err := h.After(resp)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		respChan <- modifiedResp
+	}()
+
+	return respChan, errChan
+}
+
+// fileName 返回不含扩展名的快照文件名，路径分隔符等字符替换为"_"
+func (h *SnapshotHook) fileName(resp *http.Response) string {
+	if resp.Request != nil {
+		if name, ok := SnapshotNameFromContext(resp.Request.Context()); ok {
+			return unsafeSnapshotChars.ReplaceAllString(name, "_")
+		}
+	}
+	now := time.Now
+	if h.now != nil {
+		now = h.now
+	}
+	return now().Format("20060102-150405.000000000")
+}
@@ -0,0 +1,99 @@
+package hooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+)
+
+// DefaultTraceIDHeader 默认的请求ID请求头
+const DefaultTraceIDHeader = "X-Request-ID"
+
+// traceIDKey 请求ID在上下文中的键
+type traceIDKey struct{}
+
+// TraceIDHook 请求ID钩子，为每个请求注入关联ID
+// 请求头不存在时生成UUID，已存在时保留原值；请求ID同时写入请求上下文，
+// 可通过TraceIDFromContext(resp.Request.Context())在响应日志中关联请求
+type TraceIDHook struct {
+	Header      string // 为空时使用X-Request-ID
+	Traceparent bool   // 是否同时设置W3C traceparent头
+}
+
+// NewTraceIDHook 创建请求ID钩子
+func NewTraceIDHook() *TraceIDHook {
+	return &TraceIDHook{Header: DefaultTraceIDHeader}
+}
+
+// Before 为请求注入请求ID
+func (h *TraceIDHook) Before(req *http.Request) (*http.Request, error) {
+	header := h.Header
+	if header == "" {
+		header = DefaultTraceIDHeader
+	}
+
+	id := req.Header.Get(header)
+	if id == "" {
+		var err error
+		id, err = NewUUID()
+		if err != nil {
+			return nil, fmt.Errorf("生成请求ID失败: %w", err)
+		}
+		req.Header.Set(header, id)
+	}
+
+	if h.Traceparent && req.Header.Get("traceparent") == "" {
+		traceparent, err := newTraceparent()
+		if err != nil {
+			return nil, fmt.Errorf("生成traceparent失败: %w", err)
+		}
+		req.Header.Set("traceparent", traceparent)
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), traceIDKey{}, id)), nil
+}
+
+// BeforeAsync 异步为请求注入请求ID
+func (h *TraceIDHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
+	reqChan := make(chan *http.Request, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedReq, err := h.Before(req)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		reqChan <- modifiedReq
+	}()
+
+	return reqChan, errChan
+}
+
+// TraceIDFromContext 获取TraceIDHook写入上下文的请求ID
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(traceIDKey{}).(string)
+	return id, ok
+}
+
+// NewUUID 生成随机的UUID（版本4）
+func NewUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // 版本4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// newTraceparent 生成W3C traceparent：版本-trace-id-parent-id-标志
+func newTraceparent() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "00-" + hex.EncodeToString(b[:16]) + "-" + hex.EncodeToString(b[16:]) + "-01", nil
+}
@@ -0,0 +1,179 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// UnflattenBodyHook 展开请求体中的点分隔键钩子
+// 将JSON请求体中形如"address.city"的键展开为嵌套对象{"address": {"city": ...}}，
+// 同一对象下的展开结果会与已有的嵌套对象合并，嵌套对象和数组中的键同样会被展开。
+// 展开路径与已有的非对象值冲突时返回错误；请求体不是JSON对象时原样发送。
+// 包含空段的键（如"a..b"、".a"）不展开
+type UnflattenBodyHook struct{}
+
+// NewUnflattenBodyHook 创建展开点分隔键钩子
+func NewUnflattenBodyHook() *UnflattenBodyHook {
+	return &UnflattenBodyHook{}
+}
+
+// Before 在请求前展开请求体中的点分隔键
+func (h *UnflattenBodyHook) Before(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || IsRawBody(req) {
+		return req, nil
+	}
+
+	bodyBytes, err := ReadRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用UseNumber保留数字的原始精度
+	decoder := json.NewDecoder(bytes.NewReader(bodyBytes))
+	decoder.UseNumber()
+	var data map[string]interface{}
+	if err := decoder.Decode(&data); err != nil || data == nil {
+		return req, nil
+	}
+
+	changed, err := unflattenValue(data)
+	if err != nil {
+		return nil, fmt.Errorf("展开请求体失败: %w", err)
+	}
+	if !changed {
+		return req, nil
+	}
+
+	newBody, err := encodeJSONBody(data)
+	if err != nil {
+		return nil, err
+	}
+	return ReplaceRequestBody(req, newBody)
+}
+
+// BeforeAsync 异步在请求前展开请求体中的点分隔键
+func (h *UnflattenBodyHook) BeforeAsync(req *http.Request) (chan *http.Request, chan error) {
+	reqChan := make(chan *http.Request, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedReq, err := h.Before(req)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		reqChan <- modifiedReq
+	}()
+
+	return reqChan, errChan
+}
+
+// unflattenValue 递归展开值中所有对象的点分隔键，返回是否有修改
+func unflattenValue(value interface{}) (bool, error) {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		return unflattenObject(val)
+	case []interface{}:
+		changed := false
+		for _, item := range val {
+			itemChanged, err := unflattenValue(item)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || itemChanged
+		}
+		return changed, nil
+	default:
+		return false, nil
+	}
+}
+
+// unflattenObject 原地展开对象中的点分隔键
+func unflattenObject(obj map[string]interface{}) (bool, error) {
+	changed := false
+	for _, child := range obj {
+		childChanged, err := unflattenValue(child)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || childChanged
+	}
+
+	// 按键名排序，保证冲突时的错误信息稳定
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		if isDottedKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := obj[key]
+		delete(obj, key)
+
+		parts := strings.Split(key, ".")
+		current := obj
+		for i, part := range parts[:len(parts)-1] {
+			next, exists := current[part]
+			if !exists {
+				child := make(map[string]interface{})
+				current[part] = child
+				current = child
+				continue
+			}
+			child, ok := next.(map[string]interface{})
+			if !ok {
+				return false, fmt.Errorf("键%s与已有的非对象字段%s冲突", key, strings.Join(parts[:i+1], "."))
+			}
+			current = child
+		}
+
+		last := parts[len(parts)-1]
+		if existing, exists := current[last]; exists {
+			if err := mergeUnflattened(existing, value, key); err != nil {
+				return false, err
+			}
+		} else {
+			current[last] = value
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// mergeUnflattened 目标位置已有值时，两者都是对象则合并，否则视为冲突
+func mergeUnflattened(existing, value interface{}, key string) error {
+	existingObj, ok1 := existing.(map[string]interface{})
+	valueObj, ok2 := value.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return fmt.Errorf("键%s与已有字段冲突", key)
+	}
+	for k, v := range valueObj {
+		if old, exists := existingObj[k]; exists {
+			if err := mergeUnflattened(old, v, key+"."+k); err != nil {
+				return err
+			}
+			continue
+		}
+		existingObj[k] = v
+	}
+	return nil
+}
+
+// isDottedKey 键是否为可展开的点分隔路径
+func isDottedKey(key string) bool {
+	if !strings.Contains(key, ".") {
+		return false
+	}
+	for _, part := range strings.Split(key, ".") {
+		if part == "" {
+			return false
+		}
+	}
+	return true
+}
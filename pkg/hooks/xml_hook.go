@@ -0,0 +1,81 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// XMLToJSONHook XML响应转换钩子，将XML响应体转换为等价的JSON并把Content-Type改为application/json
+// 转换规则与client.DecodeXML相同：结果形如{根元素名: 值}，属性以"@"开头，文本内容存入"#text"，同名子元素合并为数组。
+// 只处理Content-Type为application/xml、text/xml或以+xml结尾的响应；XML解析失败时保留原响应体和Content-Type
+type XMLToJSONHook struct {
+	MaxResponseBytes int64 // 读取响应体的最大字节数，<=0表示不限制
+}
+
+// NewXMLToJSONHook 创建XML响应转换钩子
+func NewXMLToJSONHook() *XMLToJSONHook {
+	return &XMLToJSONHook{}
+}
+
+// After 将XML响应体转换为JSON
+func (h *XMLToJSONHook) After(resp *http.Response) (*http.Response, error) {
+	if resp.Body == nil || !isXMLMediaType(resp.Header.Get("Content-Type")) {
+		return resp, nil
+	}
+
+	body, err := ReadLimited(resp.Body, h.MaxResponseBytes)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+
+	data, err := utils.XMLToMap(body)
+	if err != nil {
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+	converted, err := json.Marshal(data)
+	if err != nil {
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return nil, fmt.Errorf("序列化转换后的响应体失败: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(converted))
+	resp.ContentLength = int64(len(converted))
+	resp.Header.Del("Content-Length")
+	resp.Header.Set("Content-Type", "application/json")
+	return resp, nil
+}
+
+// AfterAsync 异步将XML响应体转换为JSON
+func (h *XMLToJSONHook) AfterAsync(resp *http.Response) (chan *http.Response, chan error) {
+	respChan := make(chan *http.Response, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		modifiedResp, err := h.After(resp)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		respChan <- modifiedResp
+	}()
+
+	return respChan, errChan
+}
+
+// isXMLMediaType 判断Content-Type是否为XML类型，包括application/soap+xml等结构化后缀
+func isXMLMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
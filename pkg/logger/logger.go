@@ -0,0 +1,72 @@
+// Package logger 提供可替换的日志接口，客户端、钩子和命令行工具统一通过它输出日志
+package logger
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// Logger 日志接口，可以替换为任意日志库的适配实现
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// StdLogger 基于标准库log的默认实现
+type StdLogger struct {
+	out   *log.Logger
+	debug bool
+}
+
+// New 创建输出到out的日志记录器，debug为false时忽略Debugf
+func New(out io.Writer, debug bool) *StdLogger {
+	return &StdLogger{
+		out:   log.New(out, "", 0),
+		debug: debug,
+	}
+}
+
+// Debugf 输出调试日志
+func (l *StdLogger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.out.Printf("[DEBUG] "+format, args...)
+	}
+}
+
+// Infof 输出普通日志
+func (l *StdLogger) Infof(format string, args ...interface{}) {
+	l.out.Printf(format, args...)
+}
+
+// Errorf 输出错误日志
+func (l *StdLogger) Errorf(format string, args ...interface{}) {
+	l.out.Printf("[ERROR] "+format, args...)
+}
+
+// nopLogger 丢弃所有日志
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+
+// Nop 不输出任何内容的日志记录器
+var Nop Logger = nopLogger{}
+
+// defaultLogger 未指定日志记录器时使用，与此前直接打印到标准输出的行为保持一致
+var defaultLogger Logger = New(os.Stdout, true)
+
+// Default 返回默认日志记录器
+func Default() Logger {
+	return defaultLogger
+}
+
+// OrDefault l为nil时返回默认日志记录器
+func OrDefault(l Logger) Logger {
+	if l == nil {
+		return defaultLogger
+	}
+	return l
+}
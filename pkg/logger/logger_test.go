@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestStdLogger 测试默认日志实现
+func TestStdLogger(t *testing.T) {
+	t.Run("输出各级别日志", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(&buf, true)
+		l.Debugf("调试 %d", 1)
+		l.Infof("信息 %s", "a")
+		l.Errorf("错误 %v", "b")
+
+		expected := "[DEBUG] 调试 1\n信息 a\n[ERROR] 错误 b\n"
+		if buf.String() != expected {
+			t.Errorf("日志输出错误，期望: %q, 实际: %q", expected, buf.String())
+		}
+	})
+
+	t.Run("关闭调试日志", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(&buf, false)
+		l.Debugf("调试")
+		l.Infof("信息")
+
+		if buf.String() != "信息\n" {
+			t.Errorf("不应输出调试日志，实际: %q", buf.String())
+		}
+	})
+}
+
+// TestOrDefault 测试默认日志记录器回退
+func TestOrDefault(t *testing.T) {
+	if OrDefault(nil) != Default() {
+		t.Error("nil应回退为默认日志记录器")
+	}
+	if OrDefault(Nop) != Nop {
+		t.Error("非nil日志记录器应原样返回")
+	}
+
+	// Nop不应产生任何输出或panic
+	Nop.Debugf("x")
+	Nop.Infof("x")
+	Nop.Errorf("x")
+}
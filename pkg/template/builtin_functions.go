@@ -8,15 +8,23 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"html"
 	"math"
 	"math/rand"
 	"net/url"
+	"os"
+	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
+	"unicode"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
 )
 
 // registerBuiltinFunctions 注册所有内置函数
@@ -41,6 +49,9 @@ func (e *Engine) registerBuiltinFunctions() {
 
 	// 加密与编码函数
 	e.registerCryptoFunctions()
+
+	// 环境变量与文件函数
+	e.registerEnvironmentFunctions()
 }
 
 // registerStringFunctions 注册字符串操作函数
@@ -96,6 +107,27 @@ func (e *Engine) registerStringFunctions() {
 		return result
 	}
 
+	// 将Map编码为查询字符串，键按字典序排列，切片值作为同名的多个参数，nil值被忽略
+	e.funcs["queryString"] = func(params interface{}) (string, error) {
+		values, err := toQueryValues(params)
+		if err != nil {
+			return "", fmt.Errorf("queryString: %w", err)
+		}
+		return values.Encode(), nil
+	}
+
+	// 拼接URL，各部分之间只保留一个斜杠
+	e.funcs["urlJoin"] = func(base string, paths ...string) string {
+		result := base
+		for _, p := range paths {
+			if p == "" {
+				continue
+			}
+			result = strings.TrimRight(result, "/") + "/" + strings.TrimLeft(p, "/")
+		}
+		return result
+	}
+
 	// HTML转义
 	e.funcs["htmlEscape"] = html.EscapeString
 	e.funcs["htmlUnescape"] = html.UnescapeString
@@ -156,6 +188,27 @@ func (e *Engine) registerDateTimeFunctions() {
 		return t.Add(time.Duration(minutes) * time.Minute)
 	}
 
+	// 按Go时长格式（如"1h30m"、"-15m"）偏移时间
+	e.funcs["addDuration"] = func(t time.Time, duration string) (time.Time, error) {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("addDuration: 无效的时长%q: %w", duration, err)
+		}
+		return t.Add(d), nil
+	}
+
+	// 取整到最接近的时间间隔，正好位于中间时向后取整；间隔按UTC对齐，如"15m"对齐到整刻钟
+	e.funcs["roundTime"] = func(t time.Time, interval string) (time.Time, error) {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("roundTime: 无效的时间间隔%q: %w", interval, err)
+		}
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("roundTime: 时间间隔必须大于0: %s", interval)
+		}
+		return t.Round(d), nil
+	}
+
 	// 时间差
 	e.funcs["since"] = time.Since
 	e.funcs["until"] = time.Until
@@ -220,6 +273,21 @@ func (e *Engine) registerMathFunctions() {
 
 	e.funcs["mod"] = math.Mod
 
+	// 百分比与比率，分母为0时返回0
+	e.funcs["percent"] = func(part, whole float64) float64 {
+		if whole == 0 {
+			return 0
+		}
+		return part / whole * 100
+	}
+
+	e.funcs["ratio"] = func(a, b float64) float64 {
+		if b == 0 {
+			return 0
+		}
+		return a / b
+	}
+
 	// 取整
 	e.funcs["ceil"] = math.Ceil
 	e.funcs["floor"] = math.Floor
@@ -302,13 +370,33 @@ func (e *Engine) registerConversionFunctions() {
 
 	// JSON操作
 	e.funcs["jsonEncode"] = func(v interface{}) string {
-		bytes, err := json.Marshal(v)
+		bytes, err := utils.MarshalJSONNoEscape(v, "")
 		if err != nil {
 			return "{}"
 		}
 		return string(bytes)
 	}
 
+	// jsonString 将值转换为字符串后编码为带引号的JSON字符串，用于 "name": {{ jsonString .name }}，
+	// 引号、反斜杠、换行等都会被转义；nil输出""
+	e.funcs["jsonString"] = func(v interface{}) (string, error) {
+		var s string
+		switch val := v.(type) {
+		case nil:
+		case string:
+			s = val
+		case []byte:
+			s = string(val)
+		default:
+			s = fmt.Sprint(val)
+		}
+		encoded, err := utils.MarshalJSONNoEscape(s, "")
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+
 	e.funcs["jsonDecode"] = func(s string) interface{} {
 		var data interface{}
 		err := json.Unmarshal([]byte(s), &data)
@@ -324,12 +412,233 @@ func (e *Engine) registerConversionFunctions() {
 		if err != nil {
 			return s
 		}
-		pretty, err := json.MarshalIndent(data, "", "  ")
+		pretty, err := utils.MarshalJSONNoEscape(data, "  ")
 		if err != nil {
 			return s
 		}
 		return string(pretty)
 	}
+
+	// JSON字段：optional在值为空时不输出整个字段，jsonComma用逗号连接非空片段，
+	// 用于 { {{ jsonComma (jsonField "id" .id) (optional "email" .email) }} }
+	e.funcs["jsonField"] = jsonField
+
+	e.funcs["optional"] = func(key string, value interface{}) (string, error) {
+		if isEmptyJSONValue(value) {
+			return "", nil
+		}
+		field, err := jsonField(key, value)
+		if err != nil {
+			return "", err
+		}
+		return field + ",", nil
+	}
+
+	e.funcs["jsonComma"] = func(fragments ...string) string {
+		parts := make([]string, 0, len(fragments))
+		for _, fragment := range fragments {
+			fragment = strings.TrimSuffix(strings.TrimSpace(fragment), ",")
+			if fragment != "" {
+				parts = append(parts, fragment)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	// XML转换函数，规则见utils.XMLToMap和utils.MapToXML
+	e.funcs["toXml"] = func(root string, v interface{}) (string, error) {
+		data, err := utils.MapToXML(root, v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	e.funcs["fromXml"] = func(s string) (map[string]interface{}, error) {
+		return utils.XMLToMap([]byte(s))
+	}
+
+	e.funcs["xmlEscape"] = func(s string) (string, error) {
+		var buf strings.Builder
+		if err := xml.EscapeText(&buf, []byte(s)); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
+
+// jsonField 输出"key": <JSON值>形式的对象字段，不带逗号
+func jsonField(key string, value interface{}) (string, error) {
+	encodedKey, err := utils.MarshalJSONNoEscape(key, "")
+	if err != nil {
+		return "", err
+	}
+	encodedValue, err := utils.MarshalJSONNoEscape(value, "")
+	if err != nil {
+		return "", fmt.Errorf("字段%s的值无法编码为JSON: %w", key, err)
+	}
+	return string(encodedKey) + ": " + string(encodedValue), nil
+}
+
+// isEmptyJSONValue 判断optional是否应省略该值：nil、空字符串以及空的数组、切片和Map
+// 数字0和false是有意义的值，不视为空
+func isEmptyJSONValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// toQueryValues 将键为字符串的任意Map转换为url.Values，nil视为空Map
+func toQueryValues(params interface{}) (url.Values, error) {
+	values := make(url.Values)
+	if params == nil {
+		return values, nil
+	}
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("参数不是键为字符串的Map: %T", params)
+	}
+
+	iter := v.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+		value := iter.Value().Interface()
+		if value == nil {
+			continue
+		}
+		if b, ok := value.([]byte); ok {
+			values.Add(key, string(b))
+			continue
+		}
+		if items, err := toInterfaceSlice(value); err == nil {
+			for _, item := range items {
+				values.Add(key, fmt.Sprint(item))
+			}
+			continue
+		}
+		values.Add(key, fmt.Sprint(value))
+	}
+	return values, nil
+}
+
+// toInterfaceSlice 将任意切片或数组转换为[]interface{}，nil视为空列表
+func toInterfaceSlice(list interface{}) ([]interface{}, error) {
+	if list == nil {
+		return nil, nil
+	}
+	if items, ok := list.([]interface{}); ok {
+		return items, nil
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("参数不是列表: %T", list)
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// listContains 判断列表中是否有与v相等的元素，相等规则见valuesEqual
+func listContains(name string, list, v interface{}) (bool, error) {
+	items, err := toInterfaceSlice(list)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, item := range items {
+		if valuesEqual(item, v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// valuesEqual 比较两个值是否相等，两者都是数字时按数值比较，否则使用reflect.DeepEqual
+func valuesEqual(a, b interface{}) bool {
+	if af, ok := sortNumber(a); ok {
+		if bf, ok := sortNumber(b); ok {
+			return af == bf
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// sortRank 排序时不同类型的先后顺序：布尔值、数字、字符串、其他值，缺失的键和null排在最后
+func sortRank(v interface{}, ok bool) int {
+	if !ok || v == nil {
+		return 4
+	}
+	if _, isNum := sortNumber(v); isNum {
+		return 1
+	}
+	switch v.(type) {
+	case bool:
+		return 0
+	case string:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// sortNumber 将数字类型的值转换为float64
+func sortNumber(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case json.Number:
+		f, err := val.Float64()
+		return f, err == nil
+	case float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return reflect.ValueOf(val).Convert(reflect.TypeOf(float64(0))).Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// compareSortValues 比较两个排序键，类型不同时按sortRank排序，同类型的其他值按字符串形式比较
+func compareSortValues(a interface{}, aok bool, b interface{}, bok bool) int {
+	ra, rb := sortRank(a, aok), sortRank(b, bok)
+	if ra != rb {
+		return ra - rb
+	}
+	switch ra {
+	case 0:
+		ab, bb := a.(bool), b.(bool)
+		switch {
+		case ab == bb:
+			return 0
+		case !ab:
+			return -1
+		default:
+			return 1
+		}
+	case 1:
+		fa, _ := sortNumber(a)
+		fb, _ := sortNumber(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		default:
+			return 0
+		}
+	case 2:
+		return strings.Compare(a.(string), b.(string))
+	case 3:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	default:
+		return 0
+	}
 }
 
 // registerCollectionFunctions 注册集合操作函数
@@ -372,6 +681,21 @@ func (e *Engine) registerCollectionFunctions() {
 		return -1
 	}
 
+	// list 由参数创建列表，用于 {{ if inList .role (list "admin" "owner") }}
+	e.funcs["list"] = func(items ...interface{}) []interface{} {
+		return append([]interface{}{}, items...)
+	}
+
+	// inList 判断值是否在列表中，数字按数值比较（1与1.0、json.Number("1")相等）
+	e.funcs["inList"] = func(v interface{}, list interface{}) (bool, error) {
+		return listContains("inList", list, v)
+	}
+
+	// hasValue 与inList相同，参数顺序为列表在前，用于 {{ hasValue .roles "admin" }}
+	e.funcs["hasValue"] = func(list interface{}, v interface{}) (bool, error) {
+		return listContains("hasValue", list, v)
+	}
+
 	e.funcs["reverse"] = func(a []interface{}) []interface{} {
 		reversed := make([]interface{}, len(a))
 		for i, item := range a {
@@ -380,7 +704,105 @@ func (e *Engine) registerCollectionFunctions() {
 		return reversed
 	}
 
+	// 排序与分组，list可以是任意切片或数组，key为点分隔路径
+	e.funcs["sortBy"] = func(list interface{}, key string) ([]interface{}, error) {
+		items, err := toInterfaceSlice(list)
+		if err != nil {
+			return nil, fmt.Errorf("sortBy: %w", err)
+		}
+		sorted := append([]interface{}(nil), items...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a, aok := utils.GetPath(sorted[i], key)
+			b, bok := utils.GetPath(sorted[j], key)
+			return compareSortValues(a, aok, b, bok) < 0
+		})
+		return sorted, nil
+	}
+
+	e.funcs["sortStrings"] = func(list interface{}) ([]string, error) {
+		items, err := toInterfaceSlice(list)
+		if err != nil {
+			return nil, fmt.Errorf("sortStrings: %w", err)
+		}
+		strs := make([]string, len(items))
+		for i, item := range items {
+			strs[i] = fmt.Sprint(item)
+		}
+		sort.Strings(strs)
+		return strs, nil
+	}
+
+	e.funcs["reverseList"] = func(list interface{}) ([]interface{}, error) {
+		items, err := toInterfaceSlice(list)
+		if err != nil {
+			return nil, fmt.Errorf("reverseList: %w", err)
+		}
+		reversed := make([]interface{}, len(items))
+		for i, item := range items {
+			reversed[len(items)-i-1] = item
+		}
+		return reversed, nil
+	}
+
+	e.funcs["groupBy"] = func(list interface{}, key string) (map[string][]interface{}, error) {
+		items, err := toInterfaceSlice(list)
+		if err != nil {
+			return nil, fmt.Errorf("groupBy: %w", err)
+		}
+		groups := make(map[string][]interface{})
+		for _, item := range items {
+			name := ""
+			if value, ok := utils.GetPath(item, key); ok && value != nil {
+				name = fmt.Sprint(value)
+			}
+			groups[name] = append(groups[name], item)
+		}
+		return groups, nil
+	}
+
 	// Map操作
+	e.funcs["dict"] = func(pairs ...interface{}) (map[string]interface{}, error) {
+		if len(pairs)%2 != 0 {
+			return nil, fmt.Errorf("dict需要成对的键和值，实际参数个数: %d", len(pairs))
+		}
+		m := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			key, ok := pairs[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("dict的键必须是字符串: %v", pairs[i])
+			}
+			m[key] = pairs[i+1]
+		}
+		return m, nil
+	}
+
+	// merge 合并多个Map，相同的键以后面的为准，nil被忽略
+	e.funcs["merge"] = func(maps ...interface{}) (map[string]interface{}, error) {
+		merged := make(map[string]interface{})
+		for i, m := range maps {
+			if m == nil {
+				continue
+			}
+			v := reflect.ValueOf(m)
+			if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("merge的第%d个参数不是以字符串为键的Map: %T", i+1, m)
+			}
+			iter := v.MapRange()
+			for iter.Next() {
+				merged[iter.Key().String()] = iter.Value().Interface()
+			}
+		}
+		return merged, nil
+	}
+
+	// headersIf 条件为真时返回m，否则返回空Map，与merge组合按条件添加请求头
+	e.funcs["headersIf"] = func(cond interface{}, m interface{}) interface{} {
+		if truth, _ := template.IsTrue(cond); truth {
+			return m
+		}
+		return map[string]interface{}{}
+	}
+
 	e.funcs["keys"] = func(m map[string]interface{}) []string {
 		keys := make([]string, 0, len(m))
 		for k := range m {
@@ -398,11 +820,99 @@ func (e *Engine) registerCollectionFunctions() {
 		return values
 	}
 
+	// 通用的存在性检查，支持任意键类型为字符串的Map和结构体（含指针）
+	e.funcs["has"] = hasField
+
 	e.funcs["hasKey"] = func(m map[string]interface{}, key string) bool {
 		_, ok := m[key]
 		return ok
 	}
 
+	// 按键逐层取嵌套Map中的值，任意一层缺失时返回默认值，用于 {{ dig "user" "address" "city" "未知" . }}
+	e.funcs["dig"] = dig
+
+	// 递归转换Map所有键的命名风格（camel/snake/kebab）
+	e.funcs["mapKeysToCase"] = func(m map[string]interface{}, style string) (map[string]interface{}, error) {
+		convert, err := caseConverter(style)
+		if err != nil {
+			return nil, err
+		}
+		return convertMapKeys(m, convert), nil
+	}
+
+	// 分页参数，页码从1开始
+	e.funcs["paginate"] = func(page, size interface{}) (map[string]interface{}, error) {
+		p, err := positiveInt(page, "页码")
+		if err != nil {
+			return nil, err
+		}
+		n, err := positiveInt(size, "每页数量")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"offset": (p - 1) * n,
+			"limit":  n,
+		}, nil
+	}
+
+	// 游标分页参数，游标为空时（第一页）不包含cursor字段
+	e.funcs["cursorParams"] = func(cursor interface{}, size interface{}) (map[string]interface{}, error) {
+		n, err := positiveInt(size, "每页数量")
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{"limit": n}
+		if cursor != nil && cursor != "" {
+			params["cursor"] = cursor
+		}
+		return params, nil
+	}
+
+	// 第page页（从1开始）第一条记录的偏移量
+	e.funcs["offset"] = func(page, size interface{}) (int, error) {
+		p, err := positiveInt(page, "页码")
+		if err != nil {
+			return 0, err
+		}
+		n, err := positiveInt(size, "每页数量")
+		if err != nil {
+			return 0, err
+		}
+		return (p - 1) * n, nil
+	}
+
+	// 总记录数为total时的总页数，最后不满一页的部分算作一页
+	e.funcs["totalPages"] = func(total, size interface{}) (int, error) {
+		t, err := nonNegativeInt(total, "总数")
+		if err != nil {
+			return 0, err
+		}
+		n, err := positiveInt(size, "每页数量")
+		if err != nil {
+			return 0, err
+		}
+		return (t + n - 1) / n, nil
+	}
+
+	// 分页链接的页码：包含当前页在内最多pageRangeLinks个连续页码，尽量使当前页居中；
+	// 当前页超出范围时按最后一页计算，没有记录时返回空列表
+	e.funcs["pageRange"] = func(page, size, total interface{}) ([]int, error) {
+		p, err := positiveInt(page, "页码")
+		if err != nil {
+			return nil, err
+		}
+		t, err := nonNegativeInt(total, "总数")
+		if err != nil {
+			return nil, err
+		}
+		n, err := positiveInt(size, "每页数量")
+		if err != nil {
+			return nil, err
+		}
+		return pageRange(p, (t+n-1)/n), nil
+	}
+
 	// 集合聚合
 	e.funcs["sum"] = func(a []float64) float64 {
 		sum := 0.0
@@ -437,6 +947,18 @@ func (e *Engine) registerConditionalFunctions() {
 		return val
 	}
 
+	// 输出不带引号的数字，值为nil或空字符串时输出默认值，用于 "age": {{ numDefault .age 0 }}
+	e.funcs["numDefault"] = func(val, fallback interface{}) (string, error) {
+		if val != nil && val != "" {
+			return numberToken(val)
+		}
+		token, err := numberToken(fallback)
+		if err != nil {
+			return "", fmt.Errorf("numDefault的默认值无效: %w", err)
+		}
+		return token, nil
+	}
+
 	e.funcs["coalesce"] = func(values ...interface{}) interface{} {
 		for _, v := range values {
 			if v != nil {
@@ -529,6 +1051,25 @@ func (e *Engine) registerCryptoFunctions() {
 		return string(data)
 	}
 
+	// base64URLEncode 使用URL安全字符且不带填充的Base64编码（RFC 4648 base64url），用于JWT和URL
+	e.funcs["base64URLEncode"] = func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	// base64URLDecode 解码base64url，带有"="填充的输入同样可以解码
+	e.funcs["base64URLDecode"] = func(s string) string {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	// proxyBasicAuth 生成代理Basic认证的Proxy-Authorization请求头的值
+	e.funcs["proxyBasicAuth"] = func(user, pass string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+	}
+
 	e.funcs["hexEncode"] = func(s string) string {
 		return hex.EncodeToString([]byte(s))
 	}
@@ -540,4 +1081,302 @@ func (e *Engine) registerCryptoFunctions() {
 		}
 		return string(data)
 	}
+
+	// JWT函数
+	e.funcs["jwtSign"] = jwtSign
+	e.funcs["jwtDecode"] = jwtDecode
+
+	// 可按时间排序的唯一ID
+	e.funcs["ulid"] = e.ulid
+	e.funcs["ksuid"] = e.ksuid
+}
+
+// registerEnvironmentFunctions 注册环境变量与文件函数
+func (e *Engine) registerEnvironmentFunctions() {
+	// env 读取环境变量，未设置时返回空字符串
+	e.funcs["env"] = func(name string) (string, error) {
+		if !e.envEnabled() {
+			return "", fmt.Errorf("env已被禁用")
+		}
+		return os.Getenv(name), nil
+	}
+
+	// readFile 读取文件内容，可选编码"base64"用于二进制文件
+	e.funcs["readFile"] = func(path string, encoding ...string) (string, error) {
+		if !e.readFileEnabled() {
+			return "", fmt.Errorf("readFile已被禁用")
+		}
+		if len(encoding) > 1 {
+			return "", fmt.Errorf("readFile最多接受一个编码参数")
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取文件失败: %w", err)
+		}
+
+		if len(encoding) == 0 || encoding[0] == "" {
+			return string(data), nil
+		}
+		switch encoding[0] {
+		case "base64":
+			return base64.StdEncoding.EncodeToString(data), nil
+		default:
+			return "", fmt.Errorf("不支持的文件编码: %s", encoding[0])
+		}
+	}
+}
+
+// hasField 检查数据中是否存在指定的键或字段
+// Map检查键是否存在（值为nil也视为存在）；结构体检查导出字段名或json标签名
+func hasField(data interface{}, key string) bool {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		return v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())).IsValid()
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Name == key {
+				return true
+			}
+			if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dig 实现dig函数，参数为若干个键、默认值和根数据
+// 根数据或任意一层为nil、不是键类型为字符串的Map或缺少对应的键时返回默认值，值为nil时同样返回默认值
+func dig(args ...interface{}) (interface{}, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("dig至少需要一个键、默认值和数据，实际参数个数: %d", len(args))
+	}
+	keys := args[:len(args)-2]
+	fallback, current := args[len(args)-2], args[len(args)-1]
+
+	for _, k := range keys {
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("dig的键必须是字符串: %v", k)
+		}
+
+		v := reflect.ValueOf(current)
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return fallback, nil
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			return fallback, nil
+		}
+		value := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+		if !value.IsValid() {
+			return fallback, nil
+		}
+		current = value.Interface()
+	}
+
+	if current == nil {
+		return fallback, nil
+	}
+	return current, nil
+}
+
+// positiveInt 将模板参数转换为正整数，兼容JSON解码得到的float64和字符串
+func positiveInt(v interface{}, name string) (int, error) {
+	n, err := intArg(v, name)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("%s必须大于0: %d", name, n)
+	}
+	return n, nil
+}
+
+// nonNegativeInt 将模板参数转换为非负整数
+func nonNegativeInt(v interface{}, name string) (int, error) {
+	n, err := intArg(v, name)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s不能小于0: %d", name, n)
+	}
+	return n, nil
+}
+
+// intArg 将int、int64、整数值的float64或数字字符串转换为int
+func intArg(v interface{}, name string) (int, error) {
+	var n int
+	switch val := v.(type) {
+	case int:
+		n = val
+	case int64:
+		n = int(val)
+	case float64:
+		if val != float64(int(val)) {
+			return 0, fmt.Errorf("%s必须是整数: %v", name, val)
+		}
+		n = int(val)
+	case string:
+		i, err := strconv.Atoi(val)
+		if err != nil {
+			return 0, fmt.Errorf("%s必须是整数: %s", name, val)
+		}
+		n = i
+	default:
+		return 0, fmt.Errorf("%s类型不支持: %T", name, v)
+	}
+	return n, nil
+}
+
+// pageRangeLinks pageRange最多返回的页码数
+const pageRangeLinks = 5
+
+// pageRange 返回包含当前页的连续页码，当前页尽量居中
+func pageRange(page, pages int) []int {
+	if pages == 0 {
+		return []int{}
+	}
+	page = min(page, pages)
+	start := max(1, page-pageRangeLinks/2)
+	end := min(pages, start+pageRangeLinks-1)
+	// 靠近最后一页时向前补足
+	start = max(1, end-pageRangeLinks+1)
+
+	links := make([]int, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		links = append(links, i)
+	}
+	return links
+}
+
+// jsonNumberPattern JSON数字的语法
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// numberToken 将数值转换为合法的JSON数字字面量，字符串必须符合JSON数字语法
+func numberToken(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(val), nil
+	case float32:
+		return numberToken(float64(val))
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return "", fmt.Errorf("JSON不支持的数值: %v", val)
+		}
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case json.Number:
+		return numberToken(string(val))
+	case string:
+		token := strings.TrimSpace(val)
+		if !jsonNumberPattern.MatchString(token) {
+			return "", fmt.Errorf("不是有效的数字: %q", val)
+		}
+		return token, nil
+	default:
+		return "", fmt.Errorf("不是数字类型: %T", v)
+	}
+}
+
+// caseConverter 根据风格名称返回对应的键名转换函数
+func caseConverter(style string) (func(string) string, error) {
+	switch style {
+	case "camel":
+		return toCamelCase, nil
+	case "snake":
+		return func(s string) string { return strings.Join(splitWords(s), "_") }, nil
+	case "kebab":
+		return func(s string) string { return strings.Join(splitWords(s), "-") }, nil
+	default:
+		return nil, fmt.Errorf("不支持的命名风格: %s", style)
+	}
+}
+
+// convertMapKeys 递归转换Map及嵌套数组中所有Map的键名
+func convertMapKeys(m map[string]interface{}, convert func(string) string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[convert(k)] = convertNestedKeys(v, convert)
+	}
+	return result
+}
+
+// convertNestedKeys 转换嵌套值中的键名，非Map和数组的值原样返回
+func convertNestedKeys(v interface{}, convert func(string) string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return convertMapKeys(val, convert)
+	case []interface{}:
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			items[i] = convertNestedKeys(item, convert)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+// splitWords 将键名拆分为小写单词，支持下划线、连字符、空格和驼峰分隔
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			flush()
+		case unicode.IsUpper(r):
+			// 处理驼峰边界，连续大写（如ID）视为同一个单词
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				flush()
+			}
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// toCamelCase 转换为小驼峰命名
+func toCamelCase(s string) string {
+	words := splitWords(s)
+	for i := 1; i < len(words); i++ {
+		runes := []rune(words[i])
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
 }
@@ -0,0 +1,75 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// 哨兵错误，调用方可以通过errors.Is判断错误类别
+var (
+	ErrTemplateNotFound = errors.New("找不到模板")
+	ErrInvalidJSON      = errors.New("无效的JSON")
+)
+
+// jsonErrorContext JSONSyntaxError中错误位置前后各显示的字符数
+const jsonErrorContext = 20
+
+// JSONSyntaxError JSON语法错误及其位置，Line和Column从1开始，Column按字符计算
+type JSONSyntaxError struct {
+	Offset  int64  // 出错字符的字节偏移量，从0开始
+	Line    int    // 出错字符所在的行
+	Column  int    // 出错字符所在的列
+	Context string // 错误位置附近的内容，"»"标出出错的位置
+	Err     error  // 原始的解析错误
+}
+
+// Error 返回带有行列位置和附近内容的错误信息
+func (e *JSONSyntaxError) Error() string {
+	return fmt.Sprintf("第%d行第%d列（偏移量%d）: %v，附近内容: %q", e.Line, e.Column, e.Offset, e.Err, e.Context)
+}
+
+// Unwrap 返回原始的解析错误
+func (e *JSONSyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// LocateJSONError err是解析data时的*json.SyntaxError时返回带有位置信息的*JSONSyntaxError，否则原样返回err
+func LocateJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	// SyntaxError.Offset是读取出错字符之后的字节数；内容不完整时指向末尾
+	pos := int(syntaxErr.Offset) - 1
+	if syntaxErr.Error() == "unexpected end of JSON input" {
+		pos = len(data)
+	}
+	pos = max(0, min(pos, len(data)))
+
+	line := bytes.Count(data[:pos], []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(data[:pos], '\n') + 1
+	column := utf8.RuneCount(data[lineStart:pos]) + 1
+
+	start := pos
+	for i := 0; i < jsonErrorContext && start > 0; i++ {
+		_, size := utf8.DecodeLastRune(data[:start])
+		start -= size
+	}
+	end := pos
+	for i := 0; i < jsonErrorContext && end < len(data); i++ {
+		_, size := utf8.DecodeRune(data[end:])
+		end += size
+	}
+
+	return &JSONSyntaxError{
+		Offset:  int64(pos),
+		Line:    line,
+		Column:  column,
+		Context: string(data[start:pos]) + "»" + string(data[pos:end]),
+		Err:     err,
+	}
+}
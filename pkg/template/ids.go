@@ -0,0 +1,108 @@
+package template
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// crockfordAlphabet ULID使用的Crockford Base32字符表
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	// base62Alphabet KSUID使用的Base62字符表
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// ksuidEpoch KSUID时间戳的起点（2014-05-13 16:53:20 UTC）
+	ksuidEpoch = 1400000000
+)
+
+// SetClock 设置ulid、ksuid等基于时间的ID函数使用的时钟，nil表示使用time.Now
+// 主要用于测试中生成可预期的时间部分
+func (e *Engine) SetClock(clock func() time.Time) {
+	e.idMutex.Lock()
+	defer e.idMutex.Unlock()
+
+	e.clock = clock
+}
+
+// ulid 生成ULID：48位毫秒时间戳 + 80位随机数，编码为26位Crockford Base32字符串
+// 同一毫秒内生成的ULID在上一个的随机部分上加一，保证按生成顺序排序
+func (e *Engine) ulid() (string, error) {
+	e.idMutex.Lock()
+	defer e.idMutex.Unlock()
+
+	ms := uint64(e.now().UnixMilli())
+	if ms <= e.lastULIDTime {
+		// 同一毫秒或时钟回拨时沿用上一个时间戳并递增随机部分
+		ms = e.lastULIDTime
+		if !incrementBytes(e.lastULIDRand[:]) {
+			return "", fmt.Errorf("同一毫秒内生成的ULID数量超过上限")
+		}
+	} else if _, err := rand.Read(e.lastULIDRand[:]); err != nil {
+		return "", fmt.Errorf("生成ULID失败: %w", err)
+	}
+	e.lastULIDTime = ms
+
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (8 * (5 - i)))
+	}
+	copy(id[6:], e.lastULIDRand[:])
+	return encodeBase(id[:], crockfordAlphabet, 26), nil
+}
+
+// ksuid 生成KSUID：32位秒级时间戳（自2014-05-13起）+ 128位随机数，编码为27位Base62字符串
+// KSUID按秒排序，同一秒内的顺序是随机的
+func (e *Engine) ksuid() (string, error) {
+	e.idMutex.Lock()
+	now := e.now()
+	e.idMutex.Unlock()
+
+	seconds := now.Unix() - ksuidEpoch
+	if seconds < 0 || seconds > 0xFFFFFFFF {
+		return "", fmt.Errorf("时间超出KSUID可表示的范围: %s", now)
+	}
+
+	var id [20]byte
+	id[0] = byte(seconds >> 24)
+	id[1] = byte(seconds >> 16)
+	id[2] = byte(seconds >> 8)
+	id[3] = byte(seconds)
+	if _, err := rand.Read(id[4:]); err != nil {
+		return "", fmt.Errorf("生成KSUID失败: %w", err)
+	}
+	return encodeBase(id[:], base62Alphabet, 27), nil
+}
+
+// now 返回ID函数使用的当前时间，调用方需持有idMutex
+func (e *Engine) now() time.Time {
+	if e.clock != nil {
+		return e.clock()
+	}
+	return time.Now()
+}
+
+// incrementBytes 将字节数组视为大端整数加一，溢出时返回false
+func incrementBytes(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeBase 将字节数组视为大端整数，按字符表编码为固定长度的字符串，高位补零
+func encodeBase(data []byte, alphabet string, length int) string {
+	n := new(big.Int).SetBytes(data)
+	base := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = alphabet[mod.Int64()]
+	}
+	return string(out)
+}
@@ -0,0 +1,63 @@
+package template
+
+import (
+	"text/template"
+	"text/template/parse"
+)
+
+// usesInclude 判断模板（包括其中define的模板）是否调用了include函数
+func usesInclude(tmpl *template.Template) bool {
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && nodeUsesFunc(t.Tree.Root, "include") {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeUsesFunc 遍历语法树，判断是否引用了名为name的函数
+func nodeUsesFunc(node parse.Node, name string) bool {
+	switch n := node.(type) {
+	case nil:
+		return false
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if nodeUsesFunc(child, name) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeUsesFunc(n.Pipe, name)
+	case *parse.IfNode:
+		return nodeUsesFunc(n.Pipe, name) || nodeUsesFunc(n.List, name) || nodeUsesFunc(n.ElseList, name)
+	case *parse.RangeNode:
+		return nodeUsesFunc(n.Pipe, name) || nodeUsesFunc(n.List, name) || nodeUsesFunc(n.ElseList, name)
+	case *parse.WithNode:
+		return nodeUsesFunc(n.Pipe, name) || nodeUsesFunc(n.List, name) || nodeUsesFunc(n.ElseList, name)
+	case *parse.TemplateNode:
+		return nodeUsesFunc(n.Pipe, name)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if nodeUsesFunc(cmd, name) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if nodeUsesFunc(arg, name) {
+				return true
+			}
+		}
+	case *parse.ChainNode:
+		return nodeUsesFunc(n.Node, name)
+	case *parse.IdentifierNode:
+		return n.Ident == name
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package template
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// jwtSign 生成紧凑格式的JWT
+// header中的alg决定签名算法：HS256（默认）使用key作为HMAC密钥，RS256使用key作为PEM格式的RSA私钥
+// header和claims可以是Map或JSON对象字符串
+func jwtSign(header, claims interface{}, key string) (string, error) {
+	h, err := jwtObject(header, "header")
+	if err != nil {
+		return "", err
+	}
+	if h == nil {
+		h = make(map[string]interface{})
+	}
+	c, err := jwtObject(claims, "claims")
+	if err != nil {
+		return "", err
+	}
+	if c == nil {
+		return "", fmt.Errorf("jwtSign: claims不能为空")
+	}
+	if key == "" {
+		return "", fmt.Errorf("jwtSign: 密钥不能为空")
+	}
+
+	if _, ok := h["alg"]; !ok {
+		h["alg"] = "HS256"
+	}
+	if _, ok := h["typ"]; !ok {
+		h["typ"] = "JWT"
+	}
+	alg, _ := h["alg"].(string)
+
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return "", fmt.Errorf("jwtSign: 序列化header失败: %w", err)
+	}
+	claimsJSON, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("jwtSign: 序列化claims失败: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	var signature []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case "RS256":
+		privateKey, err := parseRSAPrivateKey(key)
+		if err != nil {
+			return "", fmt.Errorf("jwtSign: %w", err)
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("jwtSign: 签名失败: %w", err)
+		}
+	default:
+		return "", fmt.Errorf("jwtSign: 不支持的签名算法: %v", h["alg"])
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// jwtDecode 解码JWT的header和claims，不校验签名
+// 返回 {"header": {...}, "claims": {...}}
+func jwtDecode(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("jwtDecode: 无效的JWT格式")
+	}
+
+	result := make(map[string]interface{}, 2)
+	for i, name := range []string{"header", "claims"} {
+		segment, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("jwtDecode: 解码%s失败: %w", name, err)
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(segment, &obj); err != nil {
+			return nil, fmt.Errorf("jwtDecode: 解析%s失败: %w", name, err)
+		}
+		result[name] = obj
+	}
+	return result, nil
+}
+
+// jwtObject 将Map或JSON对象字符串转换为Map，nil返回nil
+func jwtObject(v interface{}, name string) (map[string]interface{}, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		// 复制一份，避免修改调用方的数据
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = item
+		}
+		return m, nil
+	case map[string]string:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = item
+		}
+		return m, nil
+	case string:
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(val), &m); err != nil {
+			return nil, fmt.Errorf("jwtSign: %s不是有效的JSON对象: %w", name, err)
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("jwtSign: %s必须是Map或JSON对象字符串，实际类型: %T", name, v)
+	}
+}
+
+// parseRSAPrivateKey 解析PKCS1或PKCS8格式的PEM私钥
+func parseRSAPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("无法解析PEM格式的私钥")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("私钥不是RSA类型")
+	}
+	return key, nil
+}
@@ -0,0 +1,84 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
+)
+
+// SnapshotRender 渲染JSON模板并返回规范化的快照
+// 快照按键名排序并缩进输出，与模板中键的书写顺序和空白无关，适合用于模板回归测试
+func (e *Engine) SnapshotRender(name string, data interface{}) ([]byte, error) {
+	rendered, err := e.Execute(name, data)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalJSON([]byte(rendered))
+}
+
+// CanonicalJSON 将JSON转换为规范格式：对象键排序、两个空格缩进、数字保持原始写法
+func CanonicalJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("渲染结果不是有效的JSON: %w", LocateJSONError(data, err)))
+	}
+	if decoder.More() {
+		return nil, utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("渲染结果包含多个JSON值"))
+	}
+
+	return utils.MarshalJSONNoEscape(v, "  ")
+}
+
+// DiffSnapshots 比较两个快照，相同时返回空字符串
+// 不同时返回逐行差异，"-"开头的行只存在于expected中，"+"开头的行只存在于actual中
+func DiffSnapshots(expected, actual []byte) string {
+	expectedText := strings.TrimRight(string(expected), "\n")
+	actualText := strings.TrimRight(string(actual), "\n")
+	if expectedText == actualText {
+		return ""
+	}
+
+	a := strings.Split(expectedText, "\n")
+	b := strings.Split(actualText, "\n")
+
+	// lcs[i][j] 表示a[i:]与b[j:]的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			diff.WriteString("+ " + b[j] + "\n")
+			j++
+		default:
+			diff.WriteString("- " + a[i] + "\n")
+			i++
+		}
+	}
+	return diff.String()
+}
@@ -0,0 +1,62 @@
+package template
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// undefinedFuncPattern 匹配text/template解析时"函数未定义"的错误信息
+var undefinedFuncPattern = regexp.MustCompile(`function "([^"]+)" not defined`)
+
+// suggestFunc 解析错误是引用了未定义的函数时，返回编辑距离最近的已注册函数名
+// 比较时忽略大小写，距离超过名称长度的三分之一（至少为2）时不给出建议；调用方需持有e.mutex
+func (e *Engine) suggestFunc(parseErr error) (string, bool) {
+	match := undefinedFuncPattern.FindStringSubmatch(parseErr.Error())
+	if match == nil {
+		return "", false
+	}
+	target := strings.ToLower(match[1])
+
+	maxDistance := len([]rune(target)) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	names := make([]string, 0, len(e.funcs))
+	for name := range e.funcs {
+		names = append(names, name)
+	}
+	// 距离相同时按字母顺序取第一个，保证结果稳定
+	sort.Strings(names)
+
+	best, bestDistance := "", maxDistance+1
+	for _, name := range names {
+		if d := editDistance(target, strings.ToLower(name)); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance 计算两个字符串的Levenshtein编辑距离（按字符计算）
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -5,29 +5,127 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/birdmichael/RenderAPI/internal/utils"
 )
 
+// DefaultMaxIncludeDepth 默认的模板最大包含深度
+const DefaultMaxIncludeDepth = 10
+
 // Engine 提供模板处理功能
 type Engine struct {
-	templates map[string]*template.Template
-	mutex     sync.RWMutex      // 添加读写锁保证并发安全
-	funcs     template.FuncMap  // 添加自定义函数映射
-	cache     map[string][]byte // 添加结果缓存，提高性能
+	templates       map[string]*template.Template
+	includes        map[string]bool   // 调用了include的模板，执行时需要按深度重新绑定include
+	mutex           sync.RWMutex      // 添加读写锁保证并发安全
+	funcs           template.FuncMap  // 添加自定义函数映射
+	cache           map[string][]byte // 添加结果缓存，提高性能
+	maxIncludeDepth int               // include的最大嵌套深度，用于检测循环包含
+	disableReadFile bool              // 是否禁用readFile函数，渲染不受信任的模板时应禁用
+	disableEnv      bool              // 是否禁用env函数，渲染不受信任的模板时应禁用
+	leftDelim       string            // 模板左定界符，为空表示使用默认的"{{"
+	rightDelim      string            // 模板右定界符，为空表示使用默认的"}}"
+
+	idMutex      sync.Mutex       // 保护ID生成器的状态
+	clock        func() time.Time // ulid、ksuid使用的时钟，nil表示time.Now
+	lastULIDTime uint64           // 上一个ULID的毫秒时间戳
+	lastULIDRand [10]byte         // 上一个ULID的随机部分，同一毫秒内递增
+}
+
+// globalFuncs 通过RegisterGlobalFunc注册、新建引擎时自动添加的函数
+var (
+	globalFuncs      = make(template.FuncMap)
+	globalFuncsMutex sync.RWMutex
+)
+
+// RegisterGlobalFunc 注册全局模板函数，之后通过NewEngine创建的引擎都会包含该函数
+// 与内置函数同名时覆盖内置函数；已创建的引擎不受影响，通常在init中调用。
+// name不是有效的标识符，或fn不是返回一个值（或一个值和error）的函数时panic
+func RegisterGlobalFunc(name string, fn interface{}) {
+	if err := checkFunc(name, fn); err != nil {
+		panic(fmt.Sprintf("注册全局模板函数失败: %v", err))
+	}
+
+	globalFuncsMutex.Lock()
+	defer globalFuncsMutex.Unlock()
+
+	globalFuncs[name] = fn
+}
+
+// UnregisterGlobalFunc 移除通过RegisterGlobalFunc注册的全局模板函数，已创建的引擎不受影响
+func UnregisterGlobalFunc(name string) {
+	globalFuncsMutex.Lock()
+	defer globalFuncsMutex.Unlock()
+
+	delete(globalFuncs, name)
+}
+
+// errorType error接口的反射类型
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// checkFunc 检查函数名和函数是否可以作为模板函数使用，规则与text/template一致
+func checkFunc(name string, fn interface{}) error {
+	if !isIdentifier(name) {
+		return fmt.Errorf("函数名 %q 不是有效的标识符", name)
+	}
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return fmt.Errorf("%s 的值不是函数: %T", name, fn)
+	}
+	switch t := v.Type(); {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return fmt.Errorf("函数 %s 必须返回一个值，或一个值和error", name)
+	}
+	return nil
+}
+
+// isIdentifier 判断name是否为由字母、数字和下划线组成且不以数字开头的标识符
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
 }
 
 // NewEngine 创建一个新的模板引擎，并初始化内置函数
 func NewEngine() *Engine {
 	engine := &Engine{
 		templates: make(map[string]*template.Template),
+		includes:  make(map[string]bool),
 		funcs:     make(template.FuncMap),
 		cache:     make(map[string][]byte),
+
+		maxIncludeDepth: DefaultMaxIncludeDepth,
 	}
 
 	// 初始化内置函数
 	engine.registerBuiltinFunctions()
 
+	// 添加全局函数
+	globalFuncsMutex.RLock()
+	for name, fn := range globalFuncs {
+		engine.funcs[name] = fn
+	}
+	globalFuncsMutex.RUnlock()
+
+	// include在执行时会按当前深度重新绑定，这里注册顶层实现以便解析模板
+	engine.funcs["include"] = func(name string, data interface{}) (string, error) {
+		return engine.executeDepth(name, data, 1)
+	}
+
 	return engine
 }
 
@@ -39,22 +137,50 @@ func (e *Engine) AddFunc(name string, fn interface{}) {
 	e.funcs[name] = fn
 }
 
+// FuncNames 返回已注册的模板函数名（内置函数、全局函数和自定义函数），按字母顺序排列
+// 不包括text/template预定义的printf、len、index等函数
+func (e *Engine) FuncNames() []string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	names := make([]string, 0, len(e.funcs))
+	for name := range e.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasFunc 检查模板函数是否已注册，范围与FuncNames相同
+func (e *Engine) HasFunc(name string) bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	_, exists := e.funcs[name]
+	return exists
+}
+
 // AddTemplate 添加模板
 func (e *Engine) AddTemplate(name, tmplStr string) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
 	// 创建带有自定义函数的模板
-	tmpl := template.New(name).Funcs(e.funcs)
+	tmpl := template.New(name).Delims(e.leftDelim, e.rightDelim).Funcs(e.funcs)
 
 	// 解析模板
 	parsedTmpl, err := tmpl.Parse(tmplStr)
 	if err != nil {
+		// 引用了拼写错误的函数时附带最接近的函数名
+		if suggestion, ok := e.suggestFunc(err); ok {
+			return fmt.Errorf("解析模板失败: %w（是否要使用%s？）", err, suggestion)
+		}
 		return fmt.Errorf("解析模板失败: %w", err)
 	}
 
 	// 存储模板
 	e.templates[name] = parsedTmpl
+	e.includes[name] = usesInclude(parsedTmpl)
 
 	// 清除此模板的缓存
 	delete(e.cache, name)
@@ -86,14 +212,115 @@ func (e *Engine) RemoveTemplate(name string) {
 	defer e.mutex.Unlock()
 
 	delete(e.templates, name)
+	delete(e.includes, name)
 	delete(e.cache, name)
 }
 
+// SetMaxIncludeDepth 设置include的最大嵌套深度，超过时渲染失败
+// 用于防止模板相互包含导致无限递归，n<=0时恢复默认值
+func (e *Engine) SetMaxIncludeDepth(n int) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if n <= 0 {
+		n = DefaultMaxIncludeDepth
+	}
+	e.maxIncludeDepth = n
+}
+
+// SetDelimiters 设置模板的定界符，如"<%"和"%>"，只影响之后添加的模板
+// 请求体中需要原样保留"{{ }}"（例如嵌入其他模板语言）时使用；任一参数为空时对应一侧恢复默认值
+func (e *Engine) SetDelimiters(left, right string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.leftDelim = left
+	e.rightDelim = right
+}
+
+// HasActions 判断文本是否包含模板动作（按当前定界符），不包含时渲染结果与原文相同
+func (e *Engine) HasActions(text string) bool {
+	e.mutex.RLock()
+	left := e.leftDelim
+	e.mutex.RUnlock()
+
+	if left == "" {
+		left = "{{"
+	}
+	return strings.Contains(text, left)
+}
+
+// SetReadFileEnabled 启用或禁用模板中的readFile函数（默认启用）
+// 渲染来源不受信任的模板时应禁用，避免模板读取本机任意文件
+func (e *Engine) SetReadFileEnabled(enabled bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.disableReadFile = !enabled
+}
+
+// readFileEnabled 返回readFile函数是否可用
+func (e *Engine) readFileEnabled() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return !e.disableReadFile
+}
+
+// SetEnvEnabled 启用或禁用模板中的env函数（默认启用）
+// 渲染来源不受信任的模板时应禁用，避免模板读取环境变量中的密钥
+func (e *Engine) SetEnvEnabled(enabled bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.disableEnv = !enabled
+}
+
+// envEnabled 返回env函数是否可用
+func (e *Engine) envEnabled() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return !e.disableEnv
+}
+
 // Execute 执行模板并返回渲染后的内容
+// 模板中可以通过 {{ include "name" . }} 嵌入其他已注册模板的渲染结果
 func (e *Engine) Execute(name string, data interface{}) (string, error) {
-	tmpl, exists := e.GetTemplate(name)
+	return e.executeDepth(name, data, 0)
+}
+
+// executeDepth 在指定的包含深度下执行模板
+func (e *Engine) executeDepth(name string, data interface{}, depth int) (string, error) {
+	e.mutex.RLock()
+	maxDepth := e.maxIncludeDepth
+	e.mutex.RUnlock()
+
+	if depth > maxDepth {
+		return "", fmt.Errorf("模板包含深度超过上限(%d)，可能存在循环包含: %s", maxDepth, name)
+	}
+
+	e.mutex.RLock()
+	tmpl, exists := e.templates[name]
+	includes := e.includes[name]
+	e.mutex.RUnlock()
 	if !exists {
-		return "", fmt.Errorf("找不到模板: %s", name)
+		return "", utils.WrapSentinel(ErrTemplateNotFound, fmt.Errorf("找不到模板: %s", name))
+	}
+
+	// 调用了include的模板需要克隆并绑定携带当前深度的include函数，避免并发执行互相影响；
+	// 其他模板直接执行，不需要付出克隆的开销
+	if includes {
+		var err error
+		tmpl, err = tmpl.Clone()
+		if err != nil {
+			return "", fmt.Errorf("执行模板失败: %w", err)
+		}
+		tmpl.Funcs(template.FuncMap{
+			"include": func(includeName string, includeData interface{}) (string, error) {
+				return e.executeDepth(includeName, includeData, depth+1)
+			},
+		})
 	}
 
 	var buf bytes.Buffer
@@ -127,11 +354,11 @@ func (e *Engine) RenderJSONTemplate(name string, data interface{}) ([]byte, erro
 	// 验证结果是否是有效的JSON
 	var result interface{}
 	if err := json.Unmarshal([]byte(renderedJSON), &result); err != nil {
-		return nil, fmt.Errorf("渲染结果不是有效的JSON: %w", err)
+		return nil, utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("渲染结果不是有效的JSON: %w", LocateJSONError([]byte(renderedJSON), err)))
 	}
 
-	// 再次序列化，确保格式正确
-	resultBytes, err := json.Marshal(result)
+	// 再次序列化，确保格式正确（不转义HTML字符）
+	resultBytes, err := utils.MarshalJSONNoEscape(result, "")
 	if err != nil {
 		return nil, fmt.Errorf("重新序列化JSON失败: %w", err)
 	}
@@ -170,11 +397,11 @@ func (e *Engine) FormatJSON(jsonBytes []byte) ([]byte, error) {
 
 	// 解析JSON
 	if err := json.Unmarshal(jsonBytes, &temp); err != nil {
-		return nil, fmt.Errorf("解析JSON失败: %w", err)
+		return nil, utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("解析JSON失败: %w", err))
 	}
 
 	// 重新格式化
-	formatted, err := json.MarshalIndent(temp, "", "  ")
+	formatted, err := utils.MarshalJSONNoEscape(temp, "  ")
 	if err != nil {
 		return nil, fmt.Errorf("格式化JSON失败: %w", err)
 	}
@@ -182,11 +409,21 @@ func (e *Engine) FormatJSON(jsonBytes []byte) ([]byte, error) {
 	return formatted, nil
 }
 
-// ValidateJSON 验证JSON是否有效
+// FormatJSONOrdered 格式化JSON字符串并保留对象键的原始顺序
+// FormatJSON会按键名重新排序，FormatJSONOrdered按原始顺序输出，数字也保持原始写法，适合用于比较差异
+func (e *Engine) FormatJSONOrdered(jsonBytes []byte) ([]byte, error) {
+	formatted, err := utils.PrettyJSONOrdered(jsonBytes, "  ")
+	if err != nil {
+		return nil, utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("解析JSON失败: %w", err))
+	}
+	return formatted, nil
+}
+
+// ValidateJSON 验证JSON是否有效，语法错误时返回的错误包含*JSONSyntaxError，带有出错的行列位置和附近内容
 func (e *Engine) ValidateJSON(jsonBytes []byte) error {
 	var temp interface{}
 	if err := json.Unmarshal(jsonBytes, &temp); err != nil {
-		return fmt.Errorf("JSON验证失败: %w", err)
+		return utils.WrapSentinel(ErrInvalidJSON, fmt.Errorf("JSON验证失败: %w", LocateJSONError(jsonBytes, err)))
 	}
 	return nil
 }
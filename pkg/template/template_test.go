@@ -1,9 +1,26 @@
 package template
 
 import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestNewEngine 测试创建模板引擎
@@ -55,6 +72,78 @@ func TestAddFunc(t *testing.T) {
 	}
 }
 
+// TestFuncNames 测试列出和检查已注册的模板函数
+func TestFuncNames(t *testing.T) {
+	engine := NewEngine()
+	engine.AddFunc("multiply", func(a, b int) int {
+		return a * b
+	})
+
+	names := engine.FuncNames()
+	if !sort.StringsAreSorted(names) {
+		t.Error("函数名应按字母顺序排列")
+	}
+	for _, name := range []string{"toUpper", "jsonEncode", "include", "dig", "multiply"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("FuncNames应包含%s", name)
+		}
+		if !engine.HasFunc(name) {
+			t.Errorf("HasFunc(%q)应返回true", name)
+		}
+	}
+
+	for _, name := range []string{"toUpperr", "printf", ""} {
+		if engine.HasFunc(name) {
+			t.Errorf("HasFunc(%q)应返回false", name)
+		}
+	}
+
+	// 修改返回的切片不影响引擎
+	names[0] = "changed"
+	if engine.HasFunc("changed") {
+		t.Error("修改FuncNames的返回值不应影响引擎")
+	}
+}
+
+// TestUnknownFuncSuggestion 测试引用拼写错误的函数时错误信息中给出最接近的函数名
+func TestUnknownFuncSuggestion(t *testing.T) {
+	engine := NewEngine()
+	engine.AddFunc("tenantID", func() string { return "t1" })
+
+	tests := []struct {
+		template   string
+		suggestion string
+	}{
+		{`{{ touper .x }}`, "toUpper"},
+		{`{{ jsonEncod .x }}`, "jsonEncode"},
+		{`{{ tenantId }}`, "tenantID"},
+		{`{{ if inlist .role (list "admin") }}{{ end }}`, "inList"},
+	}
+	for _, tt := range tests {
+		err := engine.AddTemplate("typo", tt.template)
+		if err == nil {
+			t.Fatalf("%s 应返回解析错误", tt.template)
+		}
+		if !strings.Contains(err.Error(), "not defined") {
+			t.Errorf("应保留原始的解析错误，实际: %v", err)
+		}
+		if !strings.Contains(err.Error(), "是否要使用"+tt.suggestion+"？") {
+			t.Errorf("%s 应建议%s，实际: %v", tt.template, tt.suggestion, err)
+		}
+	}
+
+	// 没有相近的函数或不是未定义函数的错误时不给出建议
+	for _, tmpl := range []string{`{{ completelyUnknownFunction .x }}`, `{{ .x `} {
+		err := engine.AddTemplate("typo", tmpl)
+		if err == nil {
+			t.Fatalf("%s 应返回解析错误", tmpl)
+		}
+		if strings.Contains(err.Error(), "是否要使用") {
+			t.Errorf("%s 不应给出建议，实际: %v", tmpl, err)
+		}
+	}
+}
+
 // TestAddTemplate 测试添加模板
 func TestAddTemplate(t *testing.T) {
 	engine := NewEngine()
@@ -364,6 +453,50 @@ func TestFormatJSON(t *testing.T) {
 	}
 }
 
+// TestFormatJSONOrdered 测试格式化JSON时保留键的原始顺序
+func TestFormatJSONOrdered(t *testing.T) {
+	engine := NewEngine()
+
+	input := []byte(`{"name":"王五","id":9007199254740993,"url":"https://a.com/?x=1&y=2","nested":{"z":1.50,"a":[]},"tags":["b","a"],"empty":{},"ok":true,"none":null}`)
+	formatted, err := engine.FormatJSONOrdered(input)
+	if err != nil {
+		t.Fatalf("格式化JSON失败: %v", err)
+	}
+
+	// 键顺序、数字写法保持原样，HTML字符不转义，空容器与MarshalIndent一致
+	expected := `{
+  "name": "王五",
+  "id": 9007199254740993,
+  "url": "https://a.com/?x=1&y=2",
+  "nested": {
+    "z": 1.50,
+    "a": []
+  },
+  "tags": [
+    "b",
+    "a"
+  ],
+  "empty": {},
+  "ok": true,
+  "none": null
+}`
+	if string(formatted) != expected {
+		t.Errorf("格式化结果错误，期望:\n%s\n实际:\n%s", expected, formatted)
+	}
+
+	// 已格式化的内容再次格式化结果不变
+	again, err := engine.FormatJSONOrdered(formatted)
+	if err != nil || string(again) != expected {
+		t.Errorf("重复格式化结果应不变: %s, %v", again, err)
+	}
+
+	for _, invalid := range []string{`[1,`, `{"a":1} {"b":2}`, `{"a" 1}`} {
+		if _, err := engine.FormatJSONOrdered([]byte(invalid)); !errors.Is(err, ErrInvalidJSON) {
+			t.Errorf("无效JSON %s 应返回ErrInvalidJSON，实际: %v", invalid, err)
+		}
+	}
+}
+
 // TestValidateJSON 测试验证JSON
 func TestValidateJSON(t *testing.T) {
 	engine := NewEngine()
@@ -616,6 +749,13 @@ func TestBuiltinFunctions(t *testing.T) {
 			expected: "true|false|true|true|false|true",
 		},
 
+		{
+			name:     "合并Map",
+			template: `{{ jsonEncode (merge (dict "a" 1 "b" 2) (headersIf .on (dict "b" 3)) (headersIf .off (dict "c" 4))) }}`,
+			data:     map[string]interface{}{"on": true, "off": false},
+			expected: `{"a":1,"b":3}`,
+		},
+
 		// 加密与编码函数测试
 		{
 			name:     "哈希函数",
@@ -629,6 +769,12 @@ func TestBuiltinFunctions(t *testing.T) {
 			data:     map[string]interface{}{"str": "Hello World"},
 			expected: "SGVsbG8gV29ybGQ=|Hello World",
 		},
+		{
+			name:     "代理认证",
+			template: `{{ proxyBasicAuth .user .pass }}`,
+			data:     map[string]interface{}{"user": "user", "pass": "pass"},
+			expected: "Basic dXNlcjpwYXNz",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -650,3 +796,1321 @@ func TestBuiltinFunctions(t *testing.T) {
 		})
 	}
 }
+
+// TestMapKeysToCase 测试递归转换Map键名风格
+func TestMapKeysToCase(t *testing.T) {
+	engine := NewEngine()
+
+	err := engine.AddTemplate("map-keys-case", `{{ jsonEncode (mapKeysToCase .data "camel") }}`)
+	if err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	data := map[string]interface{}{
+		"data": map[string]interface{}{
+			"user_name": "张三",
+			"home_address": map[string]interface{}{
+				"zip_code": "100000",
+			},
+			"phone_numbers": []interface{}{
+				map[string]interface{}{"area_code": "010"},
+			},
+		},
+	}
+
+	result, err := engine.Execute("map-keys-case", data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+
+	expected := `{"homeAddress":{"zipCode":"100000"},"phoneNumbers":[{"areaCode":"010"}],"userName":"张三"}`
+	if result != expected {
+		t.Errorf("期望: %s, 实际: %s", expected, result)
+	}
+
+	// 其他风格
+	convert, _ := caseConverter("snake")
+	if got := convert("userID"); got != "user_id" {
+		t.Errorf("snake风格转换错误，期望: user_id, 实际: %s", got)
+	}
+	convert, _ = caseConverter("kebab")
+	if got := convert("HTTPStatusCode"); got != "http-status-code" {
+		t.Errorf("kebab风格转换错误，期望: http-status-code, 实际: %s", got)
+	}
+
+	// 不支持的风格
+	if _, err := caseConverter("pascal"); err == nil {
+		t.Error("应该拒绝不支持的命名风格")
+	}
+}
+
+// TestHas 测试通用的存在性检查函数
+func TestHas(t *testing.T) {
+	engine := NewEngine()
+
+	tmpl := `{"name": "{{ .Name }}"{{ if has . "Email" }}, "email": "{{ .Email }}"{{ end }}}`
+	if err := engine.AddTemplate("has", tmpl); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	type user struct {
+		Name  string
+		Email string `json:"email"`
+	}
+	type anonymous struct {
+		Name string
+	}
+
+	cases := []struct {
+		name     string
+		data     interface{}
+		expected string
+	}{
+		{"通用Map", map[string]interface{}{"Name": "张三", "Email": "a@example.com"}, `{"name": "张三", "email": "a@example.com"}`},
+		{"缺少键的Map", map[string]interface{}{"Name": "张三"}, `{"name": "张三"}`},
+		{"字符串Map", map[string]string{"Name": "张三", "Email": "b@example.com"}, `{"name": "张三", "email": "b@example.com"}`},
+		{"结构体", user{Name: "张三", Email: "c@example.com"}, `{"name": "张三", "email": "c@example.com"}`},
+		{"结构体指针", &user{Name: "张三", Email: "d@example.com"}, `{"name": "张三", "email": "d@example.com"}`},
+		{"缺少字段的结构体", anonymous{Name: "张三"}, `{"name": "张三"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := engine.Execute("has", c.data)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			if result != c.expected {
+				t.Errorf("期望: %s, 实际: %s", c.expected, result)
+			}
+		})
+	}
+
+	// json标签名和空值
+	if !hasField(user{}, "email") {
+		t.Error("应识别json标签名")
+	}
+	if hasField(nil, "Name") || hasField((*user)(nil), "Name") {
+		t.Error("nil数据不应包含任何字段")
+	}
+	if !hasField(map[string]interface{}{"Email": nil}, "Email") {
+		t.Error("值为nil的键也应视为存在")
+	}
+}
+
+// TestPercentAndRatio 测试百分比与比率函数
+func TestPercentAndRatio(t *testing.T) {
+	engine := NewEngine()
+
+	cases := []struct {
+		template string
+		expected string
+	}{
+		{`{{ percent 25 200 }}`, "12.5"},
+		{`{{ percent 1 3 | round }}`, "33"},
+		{`{{ percent 5 0 }}`, "0"},
+		{`{{ ratio 3 4 }}`, "0.75"},
+		{`{{ ratio 3 0 }}`, "0"},
+	}
+
+	for i, c := range cases {
+		name := "percent_" + strconv.Itoa(i)
+		if err := engine.AddTemplate(name, c.template); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		result, err := engine.Execute(name, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		if result != c.expected {
+			t.Errorf("%s 期望: %s, 实际: %s", c.template, c.expected, result)
+		}
+	}
+}
+
+// TestJSONNoHTMLEscape 测试JSON序列化不转义HTML字符
+func TestJSONNoHTMLEscape(t *testing.T) {
+	engine := NewEngine()
+
+	data := map[string]interface{}{
+		"url":  "https://example.com/?a=1&b=2",
+		"expr": "a < b && c > d",
+	}
+
+	// jsonEncode
+	if err := engine.AddTemplate("encode", `{{ jsonEncode . }}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	result, err := engine.Execute("encode", data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	expected := `{"expr":"a < b && c > d","url":"https://example.com/?a=1&b=2"}`
+	if result != expected {
+		t.Errorf("jsonEncode转义了HTML字符，期望: %s, 实际: %s", expected, result)
+	}
+
+	// 请求体渲染
+	if err := engine.AddTemplate("body", `{"url": "{{ .url }}", "expr": "{{ .expr }}"}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	body, err := engine.RenderJSONTemplate("body", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	if string(body) != expected {
+		t.Errorf("渲染结果转义了HTML字符，期望: %s, 实际: %s", expected, body)
+	}
+}
+
+// TestPaginate 测试分页参数函数
+func TestPaginate(t *testing.T) {
+	engine := NewEngine()
+
+	err := engine.AddTemplate("paginate", `{{ $p := paginate .page .size }}{{ $p.offset }},{{ $p.limit }}`)
+	if err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	cases := []struct {
+		page, size interface{}
+		expected   string
+	}{
+		{1, 20, "0,20"},
+		{3, 20, "40,20"},
+		{float64(5), float64(10), "40,10"}, // JSON解码得到的数字
+		{"2", "25", "25,25"},
+	}
+	for _, c := range cases {
+		result, err := engine.Execute("paginate", map[string]interface{}{"page": c.page, "size": c.size})
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		if result != c.expected {
+			t.Errorf("page=%v size=%v 期望: %s, 实际: %s", c.page, c.size, c.expected, result)
+		}
+	}
+
+	// 非法页码
+	if _, err := engine.Execute("paginate", map[string]interface{}{"page": 0, "size": 20}); err == nil {
+		t.Error("页码为0时应该返回错误")
+	}
+
+	err = engine.AddTemplate("cursor", `{{ jsonEncode (cursorParams .cursor 50) }}`)
+	if err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	result, err := engine.Execute("cursor", map[string]interface{}{"cursor": "abc"})
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	if result != `{"cursor":"abc","limit":50}` {
+		t.Errorf("游标分页参数错误: %s", result)
+	}
+	result, err = engine.Execute("cursor", map[string]interface{}{"cursor": ""})
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	if result != `{"limit":50}` {
+		t.Errorf("首页不应包含游标: %s", result)
+	}
+}
+
+// TestPageMetadata 测试offset、totalPages和pageRange
+func TestPageMetadata(t *testing.T) {
+	engine := NewEngine()
+
+	cases := []struct {
+		name     string
+		template string
+		data     map[string]interface{}
+		expected string
+	}{
+		{"第一页偏移量", `{{ offset 1 20 }}`, nil, "0"},
+		{"偏移量", `{{ offset .page .size }}`, map[string]interface{}{"page": float64(3), "size": "25"}, "50"},
+		{"整除的总页数", `{{ totalPages 100 20 }}`, nil, "5"},
+		{"最后一页不满", `{{ totalPages 101 20 }}`, nil, "6"},
+		{"不足一页", `{{ totalPages 1 20 }}`, nil, "1"},
+		{"没有记录", `{{ totalPages 0 20 }}`, nil, "0"},
+		{"页数较少时返回全部页码", `{{ pageRange 2 10 35 }}`, nil, "[1 2 3 4]"},
+		{"当前页居中", `{{ pageRange 5 10 95 }}`, nil, "[3 4 5 6 7]"},
+		{"靠近第一页", `{{ pageRange 1 10 95 }}`, nil, "[1 2 3 4 5]"},
+		{"最后一页不满", `{{ pageRange 10 10 95 }}`, nil, "[6 7 8 9 10]"},
+		{"页码超出范围", `{{ pageRange 20 10 95 }}`, nil, "[6 7 8 9 10]"},
+		{"没有记录时为空", `{{ pageRange 1 10 0 }}`, nil, "[]"},
+		{"渲染分页链接", `{{ range pageRange 2 20 45 }}<a href="?page={{ . }}">{{ . }}</a>{{ end }}`, nil,
+			`<a href="?page=1">1</a><a href="?page=2">2</a><a href="?page=3">3</a>`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := engine.AddTemplate("pages", c.template); err != nil {
+				t.Fatalf("添加模板失败: %v", err)
+			}
+			result, err := engine.Execute("pages", c.data)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			if result != c.expected {
+				t.Errorf("期望: %s, 实际: %s", c.expected, result)
+			}
+		})
+	}
+
+	// 每页数量为0、页码为0或总数为负数时返回错误
+	for _, tmpl := range []string{
+		`{{ offset 1 0 }}`, `{{ offset 0 20 }}`,
+		`{{ totalPages 100 0 }}`, `{{ totalPages -1 20 }}`,
+		`{{ pageRange 1 0 100 }}`, `{{ pageRange 0 20 100 }}`,
+	} {
+		if err := engine.AddTemplate("pages_error", tmpl); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		if _, err := engine.Execute("pages_error", nil); err == nil {
+			t.Errorf("%s 应返回错误", tmpl)
+		}
+	}
+}
+
+// TestInclude 测试模板包含
+func TestInclude(t *testing.T) {
+	engine := NewEngine()
+
+	if err := engine.AddTemplate("address", `{"city": "{{ .city }}"}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	if err := engine.AddTemplate("user", `{"name": "{{ .name }}", "address": {{ include "address" .address }}}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	data := map[string]interface{}{
+		"name":    "张三",
+		"address": map[string]interface{}{"city": "北京"},
+	}
+
+	result, err := engine.RenderJSONTemplate("user", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+
+	expected := `{"address":{"city":"北京"},"name":"张三"}`
+	if string(result) != expected {
+		t.Errorf("期望: %s, 实际: %s", expected, string(result))
+	}
+}
+
+// TestUsesInclude 测试识别调用了include的模板，只有这些模板在执行时需要克隆
+func TestUsesInclude(t *testing.T) {
+	tests := map[string]bool{
+		`{{ .name }}`: false,
+		`{{ if .ok }}{{ toUpper .name }}{{ end }}`: false,
+		`{{ .include }}`:                                                           false,
+		`{{ include "a" . }}`:                                                      true,
+		`{{ printf "%s" (include "a" .) }}`:                                        true,
+		`{{ $x := include "a" . }}{{ $x }}`:                                        true,
+		`{{ range .items }}{{ else }}{{ include "a" . }}{{ end }}`:                 true,
+		`{{ with .x }}{{ if . }}{{ include "a" . }}{{ end }}{{ end }}`:             true,
+		`{{ define "inner" }}{{ include "a" . }}{{ end }}{{ template "inner" . }}`: true,
+	}
+
+	engine := NewEngine()
+	for text, want := range tests {
+		if err := engine.AddTemplate("t", text); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		engine.mutex.RLock()
+		got := engine.includes["t"]
+		engine.mutex.RUnlock()
+		if got != want {
+			t.Errorf("%s: 期望 %v, 实际 %v", text, want, got)
+		}
+	}
+
+	engine.RemoveTemplate("t")
+	if _, ok := engine.includes["t"]; ok {
+		t.Error("删除模板时应同时删除include标记")
+	}
+}
+
+// TestIncludeCycle 测试检测模板循环包含
+func TestIncludeCycle(t *testing.T) {
+	engine := NewEngine()
+	engine.SetMaxIncludeDepth(5)
+
+	if err := engine.AddTemplate("a", `a-{{ include "b" . }}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	if err := engine.AddTemplate("b", `b-{{ include "a" . }}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := engine.Execute("a", nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("应该检测到循环包含")
+		}
+		if !strings.Contains(err.Error(), "循环包含") {
+			t.Errorf("错误消息不正确: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("循环包含未被检测，渲染无法结束")
+	}
+}
+
+// TestNumDefault 测试numDefault输出不带引号的数字
+func TestNumDefault(t *testing.T) {
+	engine := NewEngine()
+	err := engine.AddTemplate("numDefault", `{"age": {{ numDefault .age 0 }}, "score": {{ numDefault .score 1.5 }}}`)
+	if err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	tests := []struct {
+		data     map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"age": 30, "score": 98.5}, `{"age": 30, "score": 98.5}`},
+		{map[string]interface{}{"age": nil}, `{"age": 0, "score": 1.5}`},
+		{map[string]interface{}{"age": "", "score": json.Number("9007199254740993")}, `{"age": 0, "score": 9007199254740993}`},
+		{map[string]interface{}{"age": float64(42), "score": " -2e3 "}, `{"age": 42, "score": -2e3}`},
+	}
+	for _, tt := range tests {
+		result, err := engine.Execute("numDefault", tt.data)
+		if err != nil {
+			t.Errorf("渲染失败(%v): %v", tt.data, err)
+			continue
+		}
+		if result != tt.expected || !json.Valid([]byte(result)) {
+			t.Errorf("期望: %s, 实际: %s", tt.expected, result)
+		}
+	}
+
+	// 非数字的值返回错误，避免生成无效的JSON
+	for _, value := range []interface{}{"abc", true, math.NaN(), "0x10"} {
+		if _, err := engine.Execute("numDefault", map[string]interface{}{"age": value}); err == nil {
+			t.Errorf("%v 不是数字，应返回错误", value)
+		}
+	}
+}
+
+// TestOptionalJSONFields 测试数据缺失时完整省略可选字段
+func TestOptionalJSONFields(t *testing.T) {
+	engine := NewEngine()
+	templates := map[string]string{
+		// optional之后跟必需字段，逗号由optional输出
+		"optional": `{ {{ optional "email" .email }}{{ optional "tags" .tags }}{{ jsonField "name" .name }} }`,
+		// 全部可选时用jsonComma连接，避免多余的逗号
+		"jsonComma": `{ {{ jsonComma (optional "email" .email) (optional "age" .age) (optional "tags" .tags) }} }`,
+	}
+	for name, text := range templates {
+		if err := engine.AddTemplate(name, text); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+	}
+
+	tests := []struct {
+		tmpl     string
+		data     map[string]interface{}
+		expected string
+	}{
+		{"optional", map[string]interface{}{"name": "张三", "email": "a@b.com", "tags": []string{"x"}}, `{ "email": "a@b.com","tags": ["x"],"name": "张三" }`},
+		{"optional", map[string]interface{}{"name": "张三", "email": "", "tags": []string{}}, `{ "name": "张三" }`},
+		{"optional", map[string]interface{}{"name": `"引号"`}, `{ "name": "\"引号\"" }`},
+		{"jsonComma", map[string]interface{}{"email": "a@b.com", "age": 0}, `{ "email": "a@b.com", "age": 0 }`},
+		{"jsonComma", map[string]interface{}{"tags": map[string]interface{}{"k": true}}, `{ "tags": {"k":true} }`},
+		{"jsonComma", map[string]interface{}{}, `{  }`},
+	}
+	for _, tt := range tests {
+		result, err := engine.Execute(tt.tmpl, tt.data)
+		if err != nil {
+			t.Errorf("渲染失败(%v): %v", tt.data, err)
+			continue
+		}
+		if result != tt.expected || !json.Valid([]byte(result)) {
+			t.Errorf("期望: %s, 实际: %s", tt.expected, result)
+		}
+	}
+}
+
+// TestQueryStringFunctions 测试编码查询字符串和拼接URL
+func TestQueryStringFunctions(t *testing.T) {
+	engine := NewEngine()
+	data := map[string]interface{}{
+		"params": map[string]interface{}{
+			"q":     "张三 & 李四",
+			"tag":   []interface{}{"a/b", "c=d"},
+			"page":  2,
+			"empty": "",
+			"skip":  nil,
+		},
+		"strs": map[string]string{"b": "2", "a": "1"},
+		"multi": map[string][]string{
+			"id": {"1", "2", "3"},
+		},
+		"base": "https://api.example.com/v1/",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"特殊字符和多值", `{{ queryString .params }}`, "empty=&page=2&q=%E5%BC%A0%E4%B8%89+%26+%E6%9D%8E%E5%9B%9B&tag=a%2Fb&tag=c%3Dd"},
+		{"键排序", `{{ queryString .strs }}`, "a=1&b=2"},
+		{"字符串切片", `{{ queryString .multi }}`, "id=1&id=2&id=3"},
+		{"dict构造", `{{ queryString (dict "sort" "-created" "limit" 10) }}`, "limit=10&sort=-created"},
+		{"空Map", `{{ queryString .missing }}`, ""},
+		{"拼接URL", `{{ urlJoin .base "/users/" "42" }}`, "https://api.example.com/v1/users/42"},
+		{"拼接查询字符串", `{{ urlJoin .base "search" }}?{{ queryString .strs }}`, "https://api.example.com/v1/search?a=1&b=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := "query_" + tt.name
+			if err := engine.AddTemplate(tmpl, tt.template); err != nil {
+				t.Fatalf("添加模板失败: %v", err)
+			}
+			result, err := engine.Execute(tmpl, data)
+			if err != nil {
+				t.Fatalf("渲染失败: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("期望: %s, 实际: %s", tt.expected, result)
+			}
+		})
+	}
+
+	if err := engine.AddTemplate("query_invalid", `{{ queryString "a=1" }}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	if _, err := engine.Execute("query_invalid", nil); err == nil {
+		t.Error("参数不是Map时应返回错误")
+	}
+}
+
+// TestDurationFunctions 测试按时长偏移和取整时间
+func TestDurationFunctions(t *testing.T) {
+	engine := NewEngine()
+	base := time.Date(2024, 3, 1, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"增加时长", `{{ formatTime (addDuration .t "1h30m") "15:04:05" }}`, "11:37:30"},
+		{"减少时长", `{{ formatTime (addDuration .t "-8m") "15:04:05" }}`, "09:59:30"},
+		{"跨天", `{{ formatTime (addDuration .t "24h") "2006-01-02 15:04" }}`, "2024-03-02 10:07"},
+		{"向前取整", `{{ formatTime (roundTime (addDuration .t "-1m") "15m") "15:04:05" }}`, "10:00:00"},
+		{"中间值向后取整到刻钟", `{{ formatTime (roundTime .t "15m") "15:04:05" }}`, "10:15:00"},
+		{"向后取整", `{{ formatTime (roundTime (addDuration .t "1m") "15m") "15:04:05" }}`, "10:15:00"},
+		{"中间值向后取整", `{{ formatTime (roundTime .t "1m") "15:04:05" }}`, "10:08:00"},
+		{"组合使用", `{{ formatTime (roundTime (addDuration .t "2h") "1h") "15:04" }}`, "12:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := "duration_" + tt.name
+			if err := engine.AddTemplate(tmpl, tt.template); err != nil {
+				t.Fatalf("添加模板失败: %v", err)
+			}
+			result, err := engine.Execute(tmpl, map[string]interface{}{"t": base})
+			if err != nil {
+				t.Fatalf("渲染失败: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("期望: %s, 实际: %s", tt.expected, result)
+			}
+		})
+	}
+
+	for name, text := range map[string]string{
+		"无效时长": `{{ addDuration .t "1 hour" }}`,
+		"间隔为0": `{{ roundTime .t "0s" }}`,
+	} {
+		tmpl := "duration_error_" + name
+		if err := engine.AddTemplate(tmpl, text); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		if _, err := engine.Execute(tmpl, map[string]interface{}{"t": base}); err == nil {
+			t.Errorf("%s应返回错误", name)
+		}
+	}
+}
+
+// TestSortAndGroup 测试按键排序和分组用户列表
+func TestSortAndGroup(t *testing.T) {
+	engine := NewEngine()
+	users := []map[string]interface{}{
+		{"name": "张三", "age": 30, "role": "admin"},
+		{"name": "李四", "age": json.Number("25")},
+		{"name": "王五", "age": 30.0, "role": "user"},
+		{"name": "赵六", "role": "admin"},
+		{"name": "钱七", "age": "未知", "role": nil},
+	}
+	data := map[string]interface{}{"users": users, "tags": []interface{}{"b", 3, "a"}}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			"按数字排序，同值保持原顺序，字符串和缺失的排在最后",
+			`{{ range sortBy .users "age" }}{{ .name }} {{ end }}`,
+			"李四 张三 王五 钱七 赵六 ",
+		},
+		{
+			"按字符串排序",
+			`{{ range sortBy .users "role" }}{{ .name }} {{ end }}`,
+			"张三 赵六 王五 李四 钱七 ",
+		},
+		{
+			"排序后反转",
+			`{{ range reverseList (sortBy .users "age") }}{{ .name }} {{ end }}`,
+			"赵六 钱七 王五 张三 李四 ",
+		},
+		{
+			"排序字符串",
+			`{{ jsonEncode (sortStrings .tags) }}`,
+			`["3","a","b"]`,
+		},
+		{
+			"按角色分组",
+			`{{ range $role, $list := groupBy .users "role" }}[{{ $role }}:{{ range $list }}{{ .name }}{{ end }}]{{ end }}`,
+			"[:李四钱七][admin:张三赵六][user:王五]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := "sort_" + tt.name
+			if err := engine.AddTemplate(tmpl, tt.template); err != nil {
+				t.Fatalf("添加模板失败: %v", err)
+			}
+			result, err := engine.Execute(tmpl, data)
+			if err != nil {
+				t.Fatalf("渲染失败: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("期望: %s, 实际: %s", tt.expected, result)
+			}
+		})
+	}
+
+	if _, err := engine.funcs["sortBy"].(func(interface{}, string) ([]interface{}, error))("users", "age"); err == nil {
+		t.Error("参数不是列表时应返回错误")
+	}
+}
+
+// TestRegisterGlobalFunc 测试全局函数自动添加到新建的引擎
+func TestRegisterGlobalFunc(t *testing.T) {
+	before := NewEngine()
+	RegisterGlobalFunc("shout", func(s string) string {
+		return strings.ToUpper(s) + "!"
+	})
+	t.Cleanup(func() {
+		UnregisterGlobalFunc("shout")
+	})
+
+	engine := NewEngine()
+	if err := engine.AddTemplate("global", `{{ shout .name }}`); err != nil {
+		t.Fatalf("新建的引擎应包含全局函数: %v", err)
+	}
+	result, err := engine.Execute("global", map[string]interface{}{"name": "hi"})
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	if result != "HI!" {
+		t.Errorf("期望: HI!, 实际: %s", result)
+	}
+
+	// 注册之前创建的引擎不受影响
+	if err := before.AddTemplate("global", `{{ shout .name }}`); err == nil {
+		t.Error("注册之前创建的引擎不应包含全局函数")
+	}
+
+	// 移除后新建的引擎不再包含该函数
+	UnregisterGlobalFunc("shout")
+	if NewEngine().HasFunc("shout") {
+		t.Error("移除后新建的引擎不应包含全局函数")
+	}
+}
+
+// TestRegisterGlobalFuncInvalid 测试注册无效的全局函数时panic
+func TestRegisterGlobalFuncInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		funcName string
+		fn       interface{}
+	}{
+		{"非函数值", "answer", 42},
+		{"nil", "empty", nil},
+		{"空函数名", "", func() string { return "" }},
+		{"函数名包含连字符", "to-upper", strings.ToUpper},
+		{"函数名以数字开头", "1st", strings.ToUpper},
+		{"没有返回值", "noop", func() {}},
+		{"第二个返回值不是error", "pair", func() (string, int) { return "", 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("注册 %q 时应panic", tt.funcName)
+				}
+				UnregisterGlobalFunc(tt.funcName)
+			}()
+			RegisterGlobalFunc(tt.funcName, tt.fn)
+		})
+	}
+
+	// 有效的函数名可以包含下划线、数字和非ASCII字母
+	RegisterGlobalFunc("_名称2", func(s string) (string, error) { return s, nil })
+	defer UnregisterGlobalFunc("_名称2")
+	if !NewEngine().HasFunc("_名称2") {
+		t.Error("有效的全局函数应注册成功")
+	}
+}
+
+// TestSetDelimiters 测试自定义定界符，默认定界符的内容原样输出
+func TestSetDelimiters(t *testing.T) {
+	engine := NewEngine()
+
+	if err := engine.AddTemplate("default", `{{ .name }}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	engine.SetDelimiters("<%", "%>")
+	err := engine.AddTemplate("custom", `{"name": "<% toUpper .name %>", "template": "Hello {{ user.name }}!"}`)
+	if err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	data := map[string]interface{}{"name": "alice"}
+	result, err := engine.Execute("custom", data)
+	if err != nil {
+		t.Fatalf("执行模板失败: %v", err)
+	}
+	expected := `{"name": "ALICE", "template": "Hello {{ user.name }}!"}`
+	if result != expected {
+		t.Errorf("期望: %s, 实际: %s", expected, result)
+	}
+
+	// 修改定界符前添加的模板不受影响
+	if result, err := engine.Execute("default", data); err != nil || result != "alice" {
+		t.Errorf("已添加的模板应使用原定界符: %s, %v", result, err)
+	}
+
+	// 恢复默认定界符
+	engine.SetDelimiters("", "")
+	if err := engine.AddTemplate("restored", `{{ .name }}<% .name %>`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	if result, err := engine.Execute("restored", data); err != nil || result != "alice<% .name %>" {
+		t.Errorf("恢复默认定界符后结果错误: %s, %v", result, err)
+	}
+}
+
+// TestEnvAndReadFile 测试读取环境变量和文件
+func TestEnvAndReadFile(t *testing.T) {
+	t.Setenv("RENDERAPI_TEST_TOKEN", "secret-token")
+
+	dir := t.TempDir()
+	textFile := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(textFile, []byte("hello"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	binFile := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(binFile, []byte{0x00, 0xff, 0x10}, 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+
+	engine := NewEngine()
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"已设置的环境变量", `{{ env "RENDERAPI_TEST_TOKEN" }}`, "secret-token"},
+		{"未设置的环境变量", `[{{ env "RENDERAPI_TEST_UNSET" }}]`, "[]"},
+		{"读取文本文件", `{{ readFile .path }}`, "hello"},
+		{"Base64读取二进制文件", `{{ readFile .bin "base64" }}`, "AP8Q"},
+	}
+
+	data := map[string]interface{}{"path": textFile, "bin": binFile}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := engine.AddTemplate(tt.name, tt.template); err != nil {
+				t.Fatalf("添加模板失败: %v", err)
+			}
+			result, err := engine.Execute(tt.name, data)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("期望: %q, 实际: %q", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("文件不存在", func(t *testing.T) {
+		engine.AddTemplate("missing", `{{ readFile "/nonexistent/file" }}`)
+		if _, err := engine.Execute("missing", nil); err == nil {
+			t.Error("读取不存在的文件应返回错误")
+		}
+	})
+
+	t.Run("禁用readFile", func(t *testing.T) {
+		engine.SetReadFileEnabled(false)
+		defer engine.SetReadFileEnabled(true)
+
+		_, err := engine.Execute("读取文本文件", data)
+		if err == nil || !strings.Contains(err.Error(), "readFile已被禁用") {
+			t.Errorf("禁用后应返回错误，实际: %v", err)
+		}
+	})
+
+	t.Run("禁用env", func(t *testing.T) {
+		engine.SetEnvEnabled(false)
+		defer engine.SetEnvEnabled(true)
+
+		engine.AddTemplate("env", `{{ env "RENDERAPI_TEST_TOKEN" }}`)
+		_, err := engine.Execute("env", nil)
+		if err == nil || !strings.Contains(err.Error(), "env已被禁用") {
+			t.Errorf("禁用后应返回错误，实际: %v", err)
+		}
+	})
+}
+
+// TestJWTSign 测试生成和解码JWT
+func TestJWTSign(t *testing.T) {
+	engine := NewEngine()
+	claims := map[string]interface{}{"sub": "user-1", "admin": true}
+
+	// verifyClaims 解码令牌并检查claims与输入一致
+	verifyClaims := func(t *testing.T, token, alg string) {
+		t.Helper()
+		if err := engine.AddTemplate("decode", `{{ $t := jwtDecode .token }}{{ $t.header.alg }}|{{ $t.claims.sub }}|{{ $t.claims.admin }}`); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		result, err := engine.Execute("decode", map[string]interface{}{"token": token})
+		if err != nil {
+			t.Fatalf("解码JWT失败: %v", err)
+		}
+		if expected := alg + "|user-1|true"; result != expected {
+			t.Errorf("解码结果错误，期望: %s, 实际: %s", expected, result)
+		}
+	}
+
+	t.Run("HS256", func(t *testing.T) {
+		engine.AddTemplate("hs256", `{{ jwtSign .header .claims "secret" }}`)
+		token, err := engine.Execute("hs256", map[string]interface{}{"header": nil, "claims": claims})
+		if err != nil {
+			t.Fatalf("生成JWT失败: %v", err)
+		}
+
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			t.Fatalf("JWT格式错误: %s", token)
+		}
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil)); parts[2] != expected {
+			t.Errorf("签名校验失败，期望: %s, 实际: %s", expected, parts[2])
+		}
+		verifyClaims(t, token, "HS256")
+	})
+
+	t.Run("RS256", func(t *testing.T) {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("生成RSA密钥失败: %v", err)
+		}
+		pemKey := string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		}))
+
+		engine.AddTemplate("rs256", `{{ jwtSign .header .claims .key }}`)
+		token, err := engine.Execute("rs256", map[string]interface{}{
+			"header": `{"alg":"RS256","kid":"k1"}`,
+			"claims": claims,
+			"key":    pemKey,
+		})
+		if err != nil {
+			t.Fatalf("生成JWT失败: %v", err)
+		}
+
+		parts := strings.Split(token, ".")
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("签名校验失败: %v", err)
+		}
+		verifyClaims(t, token, "RS256")
+	})
+
+	t.Run("参数校验", func(t *testing.T) {
+		invalid := []struct {
+			name   string
+			header interface{}
+			claims interface{}
+			key    string
+		}{
+			{"空密钥", nil, claims, ""},
+			{"空claims", nil, nil, "secret"},
+			{"不支持的算法", map[string]interface{}{"alg": "none"}, claims, "secret"},
+			{"无效的RSA私钥", map[string]interface{}{"alg": "RS256"}, claims, "not-a-pem"},
+			{"claims不是对象", nil, "[1,2]", "secret"},
+		}
+		for _, tt := range invalid {
+			if _, err := jwtSign(tt.header, tt.claims, tt.key); err == nil {
+				t.Errorf("%s: 应返回错误", tt.name)
+			}
+		}
+
+		if _, err := jwtDecode("a.b"); err == nil {
+			t.Error("无效的JWT格式应返回错误")
+		}
+	})
+}
+
+// TestSentinelErrors 测试模板引擎返回的错误可以通过errors.Is匹配哨兵错误
+func TestSentinelErrors(t *testing.T) {
+	engine := NewEngine()
+
+	if _, err := engine.Execute("missing", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("应匹配ErrTemplateNotFound，实际: %v", err)
+	}
+
+	engine.AddTemplate("broken", `{"name": {{ .name }}}`)
+	if _, err := engine.RenderJSONTemplate("broken", map[string]interface{}{"name": "abc"}); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("应匹配ErrInvalidJSON，实际: %v", err)
+	}
+
+	if err := engine.ValidateJSON([]byte(`{`)); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("应匹配ErrInvalidJSON，实际: %v", err)
+	}
+	if _, err := engine.FormatJSON([]byte(`[1,`)); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("应匹配ErrInvalidJSON，实际: %v", err)
+	}
+}
+
+// TestSnapshotRender 测试快照与模板中键的顺序无关
+func TestSnapshotRender(t *testing.T) {
+	engine := NewEngine()
+	engine.AddTemplate("v1", `{"user": {"name": "{{ .name }}", "age": {{ .age }}}, "id": 1, "tags": ["a", "b"]}`)
+	engine.AddTemplate("v2", `{
+		"id": 1,
+		"tags": ["a", "b"],
+		"user": {"age": {{ .age }}, "name": "{{ .name }}"}
+	}`)
+	data := map[string]interface{}{"name": "张三&李四", "age": 30}
+
+	snap1, err := engine.SnapshotRender("v1", data)
+	if err != nil {
+		t.Fatalf("生成快照失败: %v", err)
+	}
+	snap2, err := engine.SnapshotRender("v2", data)
+	if err != nil {
+		t.Fatalf("生成快照失败: %v", err)
+	}
+
+	if diff := DiffSnapshots(snap1, snap2); diff != "" {
+		t.Errorf("等价的渲染结果快照应相同，差异:\n%s", diff)
+	}
+
+	expected := "{\n  \"id\": 1,\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ],\n  \"user\": {\n    \"age\": 30,\n    \"name\": \"张三&李四\"\n  }\n}"
+	if string(snap1) != expected {
+		t.Errorf("快照格式错误，期望:\n%s\n实际:\n%s", expected, snap1)
+	}
+
+	t.Run("差异输出", func(t *testing.T) {
+		changed, _ := engine.SnapshotRender("v1", map[string]interface{}{"name": "张三&李四", "age": 31})
+		diff := DiffSnapshots(snap1, changed)
+		if !strings.Contains(diff, `-     "age": 30,`) || !strings.Contains(diff, `+     "age": 31,`) {
+			t.Errorf("差异内容不正确:\n%s", diff)
+		}
+	})
+
+	t.Run("无效的JSON", func(t *testing.T) {
+		engine.AddTemplate("invalid", `{"name": {{ .name }}}`)
+		if _, err := engine.SnapshotRender("invalid", data); !errors.Is(err, ErrInvalidJSON) {
+			t.Errorf("应返回ErrInvalidJSON，实际: %v", err)
+		}
+	})
+}
+
+// TestXMLFunctions 测试XML转换函数
+func TestXMLFunctions(t *testing.T) {
+	engine := NewEngine()
+	tests := []struct {
+		name     string
+		template string
+		data     interface{}
+		expected string
+	}{
+		{"toXml", `{{ toXml "user" .user }}`,
+			map[string]interface{}{"user": map[string]interface{}{"@id": 1, "name": "张三"}},
+			`<user id="1"><name>张三</name></user>`},
+		{"fromXml", `{{ $u := (fromXml .xml).user }}{{ $u.name }}|{{ index $u "@id" }}`,
+			map[string]interface{}{"xml": `<user id="1"><name>张三</name></user>`},
+			"张三|1"},
+		{"xmlEscape", `<name>{{ xmlEscape .name }}</name>`,
+			map[string]interface{}{"name": `"a" & <b>`},
+			`<name>&#34;a&#34; &amp; &lt;b&gt;</name>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := engine.AddTemplate(tt.name, tt.template); err != nil {
+				t.Fatalf("添加模板失败: %v", err)
+			}
+			result, err := engine.Execute(tt.name, tt.data)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("期望: %s, 实际: %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestSortableIDs 测试ULID和KSUID的格式与排序
+func TestSortableIDs(t *testing.T) {
+	engine := NewEngine()
+	current := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	engine.SetClock(func() time.Time { return current })
+
+	engine.AddTemplate("ulid", `{{ ulid }}`)
+	engine.AddTemplate("ksuid", `{{ ksuid }}`)
+	render := func(name string) string {
+		t.Helper()
+		result, err := engine.Execute(name, nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		return result
+	}
+
+	t.Run("ULID", func(t *testing.T) {
+		ulidPattern := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+
+		first := render("ulid")
+		// 同一毫秒内生成，依靠随机部分递增保证顺序
+		second := render("ulid")
+		current = current.Add(time.Millisecond)
+		third := render("ulid")
+
+		for _, id := range []string{first, second, third} {
+			if !ulidPattern.MatchString(id) {
+				t.Errorf("ULID格式错误: %s", id)
+			}
+		}
+		if !(first < second && second < third) {
+			t.Errorf("ULID应按生成顺序排序: %s, %s, %s", first, second, third)
+		}
+		// 时间部分为前10个字符，2024-01-02T03:04:05Z 对应的毫秒时间戳
+		if first[:10] != "01HK421P48" {
+			t.Errorf("ULID时间部分错误: %s", first[:10])
+		}
+	})
+
+	t.Run("KSUID", func(t *testing.T) {
+		ksuidPattern := regexp.MustCompile(`^[0-9A-Za-z]{27}$`)
+
+		var ids []string
+		for i := 0; i < 5; i++ {
+			ids = append(ids, render("ksuid"))
+			current = current.Add(time.Second)
+		}
+
+		for i, id := range ids {
+			if !ksuidPattern.MatchString(id) {
+				t.Errorf("KSUID格式错误: %s", id)
+			}
+			if i > 0 && ids[i-1] >= id {
+				t.Errorf("KSUID应按时间排序: %s >= %s", ids[i-1], id)
+			}
+		}
+	})
+}
+
+// TestBase64URLFunctions 测试base64url编码不包含+、/和=且可以还原
+func TestBase64URLFunctions(t *testing.T) {
+	engine := NewEngine()
+
+	for _, input := range []string{"<<???>>", "Hello World", "中文?~", "a", ""} {
+		if err := engine.AddTemplate("base64url", "{{ base64URLEncode .str }}"); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		encoded, err := engine.Execute("base64url", map[string]interface{}{"str": input})
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		if strings.ContainsAny(encoded, "+/=") {
+			t.Errorf("%q的编码结果不应包含+、/或=: %s", input, encoded)
+		}
+
+		if err := engine.AddTemplate("base64url_decode", "{{ base64URLDecode .encoded }}"); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		decoded, err := engine.Execute("base64url_decode", map[string]interface{}{"encoded": encoded})
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		if decoded != input {
+			t.Errorf("解码结果错误, 期望: %q, 实际: %q", input, decoded)
+		}
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{`{{ base64Encode "<<???>>" }}|{{ base64URLEncode "<<???>>" }}`, "PDw/Pz8+Pg==|PDw_Pz8-Pg"},
+		{`{{ base64URLDecode "PDw_Pz8-Pg==" }}`, "<<???>>"},
+		{`{{ base64URLDecode "PDw/Pz8+Pg" }}`, ""},
+	}
+	for _, tt := range tests {
+		if err := engine.AddTemplate("base64url_case", tt.template); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		result, err := engine.Execute("base64url_case", nil)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		if result != tt.expected {
+			t.Errorf("%s 期望: %q, 实际: %q", tt.template, tt.expected, result)
+		}
+	}
+}
+
+// TestDig 测试逐层安全取值
+func TestDig(t *testing.T) {
+	engine := NewEngine()
+	data := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":    "张三",
+			"address": map[string]interface{}{"city": "上海", "zip": nil},
+			"tags":    map[string]string{"level": "vip"},
+			"age":     30,
+		},
+		"empty": nil,
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		data     interface{}
+		expected string
+	}{
+		{"存在的路径", `{{ dig "user" "address" "city" "未知" . }}`, data, "上海"},
+		{"单层路径", `{{ dig "user" "name" "" . }}`, data, "张三"},
+		{"返回嵌套Map", `{{ jsonEncode (dig "user" "address" dict .) }}`, data, `{"city":"上海","zip":null}`},
+		{"缺少的中间层", `{{ dig "user" "company" "name" "未知" . }}`, data, "未知"},
+		{"缺少的最后一层", `{{ dig "user" "address" "street" "未知" . }}`, data, "未知"},
+		{"中间层为nil", `{{ dig "empty" "city" "未知" . }}`, data, "未知"},
+		{"值为nil", `{{ dig "user" "address" "zip" "000000" . }}`, data, "000000"},
+		{"中间层不是Map", `{{ dig "user" "age" "value" 0 . }}`, data, "0"},
+		{"其他类型的Map", `{{ dig "user" "tags" "level" "normal" . }}`, data, "vip"},
+		{"根数据为nil", `{{ dig "user" "name" "匿名" . }}`, nil, "匿名"},
+		{"默认值保留类型", `{{ printf "%T" (dig "user" "score" 10 .) }}`, data, "int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := engine.AddTemplate("dig", tt.template); err != nil {
+				t.Fatalf("添加模板失败: %v", err)
+			}
+			result, err := engine.Execute("dig", tt.data)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("期望: %q, 实际: %q", tt.expected, result)
+			}
+		})
+	}
+
+	// 参数不足或键不是字符串时返回错误
+	for _, tmpl := range []string{`{{ dig "a" . }}`, `{{ dig 1 "默认" . }}`} {
+		if err := engine.AddTemplate("dig_error", tmpl); err != nil {
+			t.Fatalf("添加模板失败: %v", err)
+		}
+		if _, err := engine.Execute("dig_error", data); err == nil {
+			t.Errorf("%s 应返回错误", tmpl)
+		}
+	}
+}
+
+// TestJSONString 测试jsonString输出的JSON字符串总是有效
+func TestJSONString(t *testing.T) {
+	engine := NewEngine()
+	if err := engine.AddTemplate("json_string", `{"name": {{ jsonString .name }}, "note": {{ jsonString .note }}}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	inputs := []string{
+		`张三`,
+		`他说"你好"`,
+		`C:\Users\admin\`,
+		"第一行\n第二行\r\n\t缩进",
+		`</script><b>&amp;</b>`,
+		"控制字符\x00\x1f",
+		`{{ .name }}`,
+		``,
+	}
+	for _, input := range inputs {
+		rendered, err := engine.RenderJSONTemplate("json_string", map[string]interface{}{"name": input, "note": 12.5})
+		if err != nil {
+			t.Fatalf("%q 渲染结果应为有效的JSON: %v", input, err)
+		}
+
+		var result map[string]interface{}
+		if err := json.Unmarshal(rendered, &result); err != nil {
+			t.Fatalf("解析渲染结果失败: %v", err)
+		}
+		if result["name"] != input {
+			t.Errorf("还原的字符串错误, 期望: %q, 实际: %q", input, result["name"])
+		}
+		if result["note"] != "12.5" {
+			t.Errorf("非字符串的值应转换为字符串, 实际: %v", result["note"])
+		}
+	}
+
+	tests := []struct {
+		data     interface{}
+		expected string
+	}{
+		{map[string]interface{}{"v": `a"b\c` + "\n"}, `"a\"b\\c\n"`},
+		{map[string]interface{}{"v": "<a&b>"}, `"<a&b>"`},
+		{map[string]interface{}{"v": nil}, `""`},
+		{map[string]interface{}{"v": true}, `"true"`},
+	}
+	if err := engine.AddTemplate("json_string_raw", `{{ jsonString .v }}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	for _, tt := range tests {
+		result, err := engine.Execute("json_string_raw", tt.data)
+		if err != nil {
+			t.Fatalf("执行模板失败: %v", err)
+		}
+		if result != tt.expected {
+			t.Errorf("期望: %s, 实际: %s", tt.expected, result)
+		}
+	}
+}
+
+// TestListMembership 测试list、inList和hasValue
+func TestListMembership(t *testing.T) {
+	engine := NewEngine()
+	data := map[string]interface{}{
+		"role":  "owner",
+		"guest": "guest",
+		"code":  json.Number("200"),
+		"ids":   []interface{}{float64(1), float64(2), float64(3)},
+		"roles": []string{"admin", "editor"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"条件分支命中", `{{ if inList .role (list "admin" "owner") }}允许{{ else }}拒绝{{ end }}`, "允许"},
+		{"条件分支未命中", `{{ if inList .guest (list "admin" "owner") }}允许{{ else }}拒绝{{ end }}`, "拒绝"},
+		{"整数与浮点数", `{{ inList 2 .ids }}`, "true"},
+		{"json.Number与整数", `{{ inList .code (list 200 404) }}`, "true"},
+		{"数字与字符串不相等", `{{ inList "1" .ids }}`, "false"},
+		{"hasValue", `{{ hasValue .ids 3 }}`, "true"},
+		{"字符串切片", `{{ hasValue .roles "editor" }}`, "true"},
+		{"空列表", `{{ inList "admin" (list) }}`, "false"},
+		{"nil列表", `{{ hasValue .missing "admin" }}`, "false"},
+		{"list创建列表", `{{ jsonEncode (list "a" 1 true) }}`, `["a",1,true]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := engine.AddTemplate("membership", tt.template); err != nil {
+				t.Fatalf("添加模板失败: %v", err)
+			}
+			result, err := engine.Execute("membership", data)
+			if err != nil {
+				t.Fatalf("执行模板失败: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("期望: %q, 实际: %q", tt.expected, result)
+			}
+		})
+	}
+
+	// 参数不是列表时返回错误
+	if err := engine.AddTemplate("membership_error", `{{ inList "a" .role }}`); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+	if _, err := engine.Execute("membership_error", data); err == nil {
+		t.Error("列表参数不是列表时应返回错误")
+	}
+}
+
+// TestJSONErrorLocation 测试渲染结果不是有效的JSON时报告出错的位置
+func TestJSONErrorLocation(t *testing.T) {
+	engine := NewEngine()
+	// 第3行的"age"之后缺少逗号，错误位于第4行的"city"
+	tmpl := `{
+  "name": "{{ .name }}",
+  "age": {{ .age }}
+  "city": "上海"
+}`
+	if err := engine.AddTemplate("missing_comma", tmpl); err != nil {
+		t.Fatalf("添加模板失败: %v", err)
+	}
+
+	_, err := engine.RenderJSONTemplate("missing_comma", map[string]interface{}{"name": "张三", "age": 30})
+	if !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("应返回ErrInvalidJSON，实际: %v", err)
+	}
+	var syntaxErr *JSONSyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("错误应包含*JSONSyntaxError，实际: %v", err)
+	}
+	if syntaxErr.Line != 4 || syntaxErr.Column != 3 {
+		t.Errorf("错误位置错误，期望: 第4行第3列, 实际: 第%d行第%d列", syntaxErr.Line, syntaxErr.Column)
+	}
+	if !strings.Contains(syntaxErr.Context, "30\n  »\"city\"") {
+		t.Errorf("附近内容应标出出错的位置，实际: %q", syntaxErr.Context)
+	}
+	if !strings.Contains(err.Error(), "第4行第3列") {
+		t.Errorf("错误信息应包含行列位置，实际: %v", err)
+	}
+	var jsonErr *json.SyntaxError
+	if !errors.As(err, &jsonErr) {
+		t.Error("应保留原始的*json.SyntaxError")
+	}
+
+	// 列按字符计算，多字节字符算一列
+	err = engine.ValidateJSON([]byte(`{"名字": "张三" "年龄": 30}`))
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("错误应包含*JSONSyntaxError，实际: %v", err)
+	}
+	if syntaxErr.Line != 1 || syntaxErr.Column != 13 {
+		t.Errorf("错误位置错误，期望: 第1行第13列, 实际: 第%d行第%d列", syntaxErr.Line, syntaxErr.Column)
+	}
+	if syntaxErr.Offset != int64(strings.Index(`{"名字": "张三" "年龄": 30}`, `"年龄"`)) {
+		t.Errorf("偏移量错误，实际: %d", syntaxErr.Offset)
+	}
+
+	// 内容不完整时指向末尾
+	err = engine.ValidateJSON([]byte(`{"a": [1, 2`))
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("错误应包含*JSONSyntaxError，实际: %v", err)
+	}
+	if syntaxErr.Column != 12 || syntaxErr.Context != `{"a": [1, 2»` {
+		t.Errorf("不完整的JSON应指向末尾，实际: 第%d行第%d列 %q", syntaxErr.Line, syntaxErr.Column, syntaxErr.Context)
+	}
+}